@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/pkg/logger"
+	"net/http"
+)
+
+type APIKeyController struct {
+	APIKeyService service.APIKeyService
+	log           logger.Logger
+}
+
+func NewAPIKeyController(apiKeyService service.APIKeyService, logger logger.Logger) *APIKeyController {
+	return &APIKeyController{APIKeyService: apiKeyService, log: logger}
+}
+
+// @Summary Issue API key
+// @Description Issue a new API key with the given scopes. The plaintext key is only returned once.
+// @Tags api-keys
+// @Accept json
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
+// @Param apiKey body dtos.CreateAPIKeyRequestDto true "API key details"
+// @Success 201 {object} dtos.APIKeyResponseDto
+// @Failure 400 {object} dtos.ProblemDetails
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Router /api-keys [post]
+func (c *APIKeyController) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var request dtos.CreateAPIKeyRequestDto
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeInvalidRequestBody, "Error parsing request body").WithCause(err), "")
+		return
+	}
+
+	key, rawKey, err := c.APIKeyService.Issue(r.Context(), request.Name, request.Scopes)
+	if err != nil {
+		WriteError(w, r, c.log, err, "Failed to issue API key")
+		return
+	}
+
+	response := dtos.APIKeyResponseDto{APIKey: key, Key: rawKey}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+
+	logger.WithContext(r.Context(), c.log).Info("api key issued successfully", "apiKeyId", key.Id)
+}
+
+// @Summary List API keys
+// @Description List all API keys including revoked ones. Secrets are never returned.
+// @Tags api-keys
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
+// @Success 200 {object} dtos.APIKeyListResponseDto
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Router /api-keys [get]
+func (c *APIKeyController) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := c.APIKeyService.List(r.Context())
+	if err != nil {
+		WriteError(w, r, c.log, err, "Error fetching API keys")
+		return
+	}
+
+	response := dtos.APIKeyListResponseDto{APIKeys: keys}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Revoke API key
+// @Description Revoke an API key so it can no longer be used
+// @Tags api-keys
+// @Produce application/problem+json
+// @Security ApiKeyAuth
+// @Param id path string true "API key Id"
+// @Success 204
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 404 {object} dtos.ProblemDetails
+// @Failure 409 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Router /api-keys/{id} [delete]
+func (c *APIKeyController) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeMissingParameter, "API key Id is required"), "")
+		return
+	}
+
+	err := c.APIKeyService.Revoke(r.Context(), id)
+	if err != nil {
+		WriteError(w, r, c.log, err, "Failed to revoke API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	logger.WithContext(r.Context(), c.log).Info("api key revoked successfully", "apiKeyId", id)
+}
+
+// @Summary Rotate API key
+// @Description Issue a replacement key with the same name and scopes and revoke the old one
+// @Tags api-keys
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
+// @Param id path string true "API key Id"
+// @Success 201 {object} dtos.APIKeyResponseDto
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 404 {object} dtos.ProblemDetails
+// @Failure 409 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Router /api-keys/{id}/rotate [post]
+func (c *APIKeyController) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeMissingParameter, "API key Id is required"), "")
+		return
+	}
+
+	key, rawKey, err := c.APIKeyService.Rotate(r.Context(), id)
+	if err != nil {
+		WriteError(w, r, c.log, err, "Failed to rotate API key")
+		return
+	}
+
+	response := dtos.APIKeyResponseDto{APIKey: key, Key: rawKey}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+
+	logger.WithContext(r.Context(), c.log).Info("api key rotated successfully", "oldApiKeyId", id, "apiKeyId", key.Id)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"github.com/google/uuid"
+	"math"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+	"net/http"
+	"strconv"
+)
+
+// WriteError is the single place where service and repository errors become
+// HTTP responses. Typed errors expose their own message and code; anything
+// else becomes a 500 with fallbackMessage. The underlying error is only
+// logged, under the request id returned to the client as correlation_id.
+func WriteError(w http.ResponseWriter, r *http.Request, log logger.Logger, err error, fallbackMessage string) {
+	requestId := model.RequestIdFromContext(r.Context())
+	if requestId == "" {
+		requestId = uuid.NewString()
+	}
+
+	appErr, ok := apperrors.As(err)
+	if !ok {
+		appErr = apperrors.New(apperrors.KindInternal, apperrors.CodeInternal, fallbackMessage)
+	}
+
+	status := StatusFor(appErr.Kind)
+	detail := appErr.Message
+	if appErr.Kind == apperrors.KindInternal && fallbackMessage != "" {
+		detail = fallbackMessage
+	}
+
+	if appErr.Kind == apperrors.KindInternal {
+		logger.WithContext(r.Context(), log).Error("request failed", "request_id", requestId, "method", r.Method, "path", r.URL.Path, "status", status, "error", err)
+	}
+
+	if appErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+	}
+
+	problem := dtos.NewProblemDetails(status, appErr.Code, detail, r.URL.Path, requestId)
+	problem.Fields = appErr.Fields
+	dtos.WriteProblem(w, problem)
+}
+
+func StatusFor(kind apperrors.Kind) int {
+	switch kind {
+	case apperrors.KindNotFound:
+		return http.StatusNotFound
+	case apperrors.KindValidation:
+		return http.StatusBadRequest
+	case apperrors.KindInsufficientFunds:
+		return http.StatusUnprocessableEntity
+	case apperrors.KindConflict:
+		return http.StatusConflict
+	case apperrors.KindForbidden:
+		return http.StatusForbidden
+	case apperrors.KindUnauthorized:
+		return http.StatusUnauthorized
+	case apperrors.KindRateLimited:
+		return http.StatusTooManyRequests
+	case apperrors.KindUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/internal/stream"
+	"moneyTransfer/pkg/logger"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// sseRetry tells EventSource clients how long to wait before reconnecting.
+const sseRetry = 3 * time.Second
+
+type EventStreamController struct {
+	UserService service.UserService
+	hub         *stream.Hub
+	heartbeat   time.Duration
+	upgrader    websocket.Upgrader
+	log         logger.Logger
+}
+
+func NewEventStreamController(userService service.UserService, hub *stream.Hub, heartbeat time.Duration, logger logger.Logger) *EventStreamController {
+	return &EventStreamController{UserService: userService, hub: hub, heartbeat: heartbeat, log: logger}
+}
+
+// @Summary Stream user events
+// @Description Stream the events of a user's transfers and balance as Server-Sent Events, or over WebSocket when the request is an upgrade.
+// @Description Each event has its sequence as id; reconnect with Last-Event-ID (or last_event_id) to resume. A "resync" event means some events were missed and state should be refetched.
+// @Tags users
+// @Produce text/event-stream,application/problem+json
+// @Security ApiKeyAuth
+// @Param id path string true "User Id"
+// @Param Last-Event-ID header int false "Resume after this event"
+// @Param last_event_id query int false "Resume after this event, for clients that cannot set headers"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} dtos.ProblemDetails
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 404 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Router /users/{id}/events [get]
+func (c *EventStreamController) StreamUserEvents(w http.ResponseWriter, r *http.Request) {
+	userId := mux.Vars(r)["id"]
+	if _, err := uuid.Parse(userId); err != nil {
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeValidationFailed, "User Id must be a valid UUID"), "")
+		return
+	}
+
+	after, err := lastEventId(r)
+	if err != nil {
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeValidationFailed, "Last-Event-ID must be an event sequence number"), "")
+		return
+	}
+
+	if _, err := c.UserService.GetById(r.Context(), userId); err != nil {
+		WriteError(w, r, c.log, err, "Error fetching user")
+		return
+	}
+
+	sub := c.hub.Subscribe(userId, after)
+	defer sub.Close()
+
+	logger.WithContext(r.Context(), c.log).Info("event stream opened", "userId", userId, "after", after, "websocket", websocket.IsWebSocketUpgrade(r))
+	if websocket.IsWebSocketUpgrade(r) {
+		c.serveWebSocket(w, r, sub)
+	} else {
+		c.serveSSE(w, r, sub)
+	}
+	logger.WithContext(r.Context(), c.log).Info("event stream closed", "userId", userId)
+}
+
+func (c *EventStreamController) serveSSE(w http.ResponseWriter, r *http.Request, sub *stream.Subscription) {
+	// The stream outlives the server's write timeout, which would cut it off.
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logger.WithContext(r.Context(), c.log).Warn("failed to clear write deadline for event stream", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	if !sub.Complete {
+		fmt.Fprintf(w, "event: %s\ndata: {}\n\n", stream.EventResync)
+	}
+	for _, event := range sub.Replay {
+		if err := writeSSEEvent(w, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(c.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				return
+			}
+			if err := writeSSEEvent(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSEEvent(w http.ResponseWriter, event model.DomainEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Sequence, event.Type, data)
+	return err
+}
+
+// serveWebSocket sends each event as a JSON text message. A message whose
+// type is "resync" stands for the SSE resync event.
+func (c *EventStreamController) serveWebSocket(w http.ResponseWriter, r *http.Request, sub *stream.Subscription) {
+	conn, err := c.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// The upgrader has already answered the request.
+		logger.WithContext(r.Context(), c.log).Warn("websocket upgrade failed", "error", err)
+		return
+	}
+	defer conn.Close()
+
+	// Reading handles pongs and notices when the client goes away; clients
+	// are not expected to send anything.
+	closed := make(chan struct{})
+	conn.SetReadLimit(512)
+	conn.SetReadDeadline(time.Now().Add(2 * c.heartbeat))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2 * c.heartbeat))
+	})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	send := func(message any) error {
+		conn.SetWriteDeadline(time.Now().Add(c.heartbeat))
+		return conn.WriteJSON(message)
+	}
+
+	if !sub.Complete {
+		if err := send(map[string]string{"type": stream.EventResync}); err != nil {
+			return
+		}
+	}
+	for _, event := range sub.Replay {
+		if err := send(event); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(c.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case event, ok := <-sub.Events:
+			if !ok {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(time.Second))
+				return
+			}
+			if err := send(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.heartbeat)); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// lastEventId reads the resume point from the Last-Event-ID header that
+// EventSource sends when reconnecting, or the last_event_id query parameter.
+// It is 0 when neither is set.
+func lastEventId(r *http.Request) (int64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid last event id %q", value)
+	}
+	return id, nil
+}
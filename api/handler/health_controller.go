@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"encoding/json"
+	"moneyTransfer/internal/health"
+	"moneyTransfer/pkg/logger"
+	"net/http"
+)
+
+type HealthController struct {
+	checker *health.Checker
+	log     logger.Logger
+}
+
+func NewHealthController(checker *health.Checker, logger logger.Logger) *HealthController {
+	return &HealthController{checker: checker, log: logger}
+}
+
+// @Summary Liveness probe
+// @Description Report whether the process is healthy or should be restarted: workers are running and none is stuck on a job
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /livez [get]
+func (c *HealthController) Livez(w http.ResponseWriter, r *http.Request) {
+	c.writeReport(w, "liveness", c.checker.Live(r.Context()))
+}
+
+// @Summary Readiness probe
+// @Description Report whether the server should receive traffic: the database answers and the queue backlog is within limits. Fails while shutting down.
+// @Tags health
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /readyz [get]
+func (c *HealthController) Readyz(w http.ResponseWriter, r *http.Request) {
+	c.writeReport(w, "readiness", c.checker.Ready(r.Context()))
+}
+
+func (c *HealthController) writeReport(w http.ResponseWriter, probe string, report health.Report) {
+	status := http.StatusOK
+	if !report.OK() {
+		status = http.StatusServiceUnavailable
+	}
+	if report.Status == health.StatusFailing {
+		c.log.Warn(probe+" check failed", "checks", report.Checks)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/pkg/logger"
+	"net/http"
+	"strings"
+)
+
+// LogLevelController reads and changes the level of the running logger. A
+// change lasts until the process restarts and is recorded in the audit log.
+type LogLevelController struct {
+	level *slog.LevelVar
+	audit service.AuditService
+	log   logger.Logger
+}
+
+func NewLogLevelController(level *slog.LevelVar, audit service.AuditService, logger logger.Logger) *LogLevelController {
+	return &LogLevelController{level: level, audit: audit, log: logger}
+}
+
+// @Summary Get log level
+// @Description Return the minimum level currently written to the logs
+// @Tags admin
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
+// @Success 200 {object} dtos.LogLevelDto
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Router /admin/log-level [get]
+func (c *LogLevelController) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dtos.LogLevelDto{Level: levelName(c.level.Level())})
+}
+
+// @Summary Set log level
+// @Description Change the minimum level written to the logs without a restart. The change is not persisted but is recorded in the audit log.
+// @Tags admin
+// @Accept json
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
+// @Param level body dtos.LogLevelDto true "New level: debug, info, warn or error"
+// @Success 200 {object} dtos.LogLevelDto
+// @Failure 400 {object} dtos.ProblemDetails
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Failure 500 {object} dtos.ProblemDetails
+// @Router /admin/log-level [put]
+func (c *LogLevelController) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var request dtos.LogLevelDto
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeInvalidRequestBody, "Error parsing request body").WithCause(err), "")
+		return
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(request.Level)); err != nil {
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeInvalidLogLevel, "level must be one of debug, info, warn or error").WithCause(err), "")
+		return
+	}
+
+	// Recorded first, so a change that cannot be audited is not made.
+	previous := c.level.Level()
+	before, after := dtos.LogLevelDto{Level: levelName(previous)}, dtos.LogLevelDto{Level: levelName(level)}
+	if err := c.audit.Record(r.Context(), model.AuditActionLogLevelChanged, model.AuditEntitySetting, "log.level", before, after); err != nil {
+		WriteError(w, r, c.log, err, "Failed to change log level")
+		return
+	}
+	c.level.Set(level)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dtos.LogLevelDto{Level: levelName(level)})
+
+	// Logged at warn so the change is recorded whatever the new level.
+	logger.WithContext(r.Context(), c.log).Warn("log level changed", "from", levelName(previous), "to", levelName(level))
+}
+
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
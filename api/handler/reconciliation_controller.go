@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"encoding/json"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/pkg/logger"
+	"net/http"
+)
+
+type ReconciliationController struct {
+	ReconciliationService service.ReconciliationService
+	log                   logger.Logger
+}
+
+func NewReconciliationController(reconciliationService service.ReconciliationService, logger logger.Logger) *ReconciliationController {
+	return &ReconciliationController{ReconciliationService: reconciliationService, log: logger}
+}
+
+// @Summary Run ledger reconciliation
+// @Description Recompute every balance from the transactions table and check global conservation of money
+// @Tags admin
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
+// @Success 200 {object} model.ReconciliationReport
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Failure 500 {object} dtos.ProblemDetails
+// @Router /admin/reconciliation [get]
+func (c *ReconciliationController) Reconcile(w http.ResponseWriter, r *http.Request) {
+	report, err := c.ReconciliationService.Reconcile(r.Context())
+	if err != nil {
+		WriteError(w, r, c.log, err, "Failed to run reconciliation")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+
+	logger.WithContext(r.Context(), c.log).Info("reconciliation report generated", "balanced", report.Balanced)
+}
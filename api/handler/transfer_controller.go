@@ -2,42 +2,53 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"moneyTransfer/internal/domain/apperrors"
 	"moneyTransfer/internal/domain/dtos"
 	"moneyTransfer/internal/domain/model"
 	"moneyTransfer/internal/domain/service"
 	"moneyTransfer/pkg/logger"
 	"net/http"
+	"strconv"
+	"time"
 )
 
 type TransferController struct {
 	TransferService service.TransferService
-	log             logger.Logger
+	// maxWait caps the wait parameter of CreateTransaction.
+	maxWait time.Duration
+	log     logger.Logger
 }
 
-func NewTransferController(transferService service.TransferService, logger logger.Logger) *TransferController {
-	return &TransferController{TransferService: transferService, log: logger}
+func NewTransferController(transferService service.TransferService, maxWait time.Duration, logger logger.Logger) *TransferController {
+	return &TransferController{TransferService: transferService, maxWait: maxWait, log: logger}
 }
 
 // @Summary Get transactions by user Id
 // @Description Get all transactions for a specific user
 // @Tags transfers
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
 // @Param userId path string true "User Id"
 // @Success 200 {array} dtos.TransactionResponseDto
-// @Failure 400 {object} dtos.ErrorResponse
+// @Failure 400 {object} dtos.ProblemDetails
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
 // @Router /transfers/{userId} [get]
 func (c *TransferController) GetTransactionsByUserId(w http.ResponseWriter, r *http.Request) {
 	userId := mux.Vars(r)["userId"]
 	if userId == "" {
-		dtos.WriteErrorResponse(w, "User Id is required", "GetUserBalance: User Id is required", http.StatusBadRequest)
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeMissingParameter, "User Id is required"), "")
 		return
 	}
 
 	transactions, err := c.TransferService.GetTransactionsByUserId(r.Context(), userId)
 	if err != nil {
-		dtos.WriteErrorResponse(w, "Error fetching transactions", err.Error(), http.StatusInternalServerError)
+		WriteError(w, r, c.log, err, "Error fetching transactions")
 		return
 	}
 
@@ -46,40 +57,100 @@ func (c *TransferController) GetTransactionsByUserId(w http.ResponseWriter, r *h
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 
-	c.log.Info("transactions fetched successfully", "response", response)
+	logger.WithContext(r.Context(), c.log).Info("transactions fetched successfully", "response", response)
 }
 
 // @Summary Create new transaction
-// @Description Create a new money transfer transaction
+// @Description Create a new money transfer transaction. Without wait it answers 202 with status PENDING as soon as the transfer is queued.
+// @Description With wait, the call blocks until the transfer is processed or the wait elapses. It then answers 200 with the final status, SUCCESS or FAILED, or 202 while still PENDING.
 // @Tags transfers
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
 // @Param transaction body dtos.TransactionRequestDto true "Transaction details"
+// @Param wait query string false "How long to wait for the outcome, as a duration (5s) or seconds (5)"
 // @Success 200 {object} dtos.CreateTransactionResponseDto
-// @Failure 400 {object} dtos.ErrorResponse
+// @Success 202 {object} dtos.CreateTransactionResponseDto
+// @Failure 400 {object} dtos.ProblemDetails
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Failure 422 {object} dtos.ProblemDetails
+// @Failure 500 {object} dtos.ProblemDetails
+// @Failure 503 {object} dtos.ProblemDetails
 // @Router /transfers [post]
 func (c *TransferController) CreateTransaction(w http.ResponseWriter, r *http.Request) {
+	wait, err := c.parseWait(r)
+	if err != nil {
+		WriteError(w, r, c.log, err, "")
+		return
+	}
+
 	var transactionRequestDto dtos.TransactionRequestDto
-	err := json.NewDecoder(r.Body).Decode(&transactionRequestDto)
+	err = json.NewDecoder(r.Body).Decode(&transactionRequestDto)
 	if err != nil {
-		dtos.WriteErrorResponse(w, "Error parsing request body", err.Error(), http.StatusBadRequest)
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeInvalidRequestBody, "Error parsing request body").WithCause(err), "")
+		return
+	}
+
+	id, err := c.TransferService.CreateTransfer(r.Context(), transactionRequestDto.From, transactionRequestDto.To, transactionRequestDto.Amount)
+	if err != nil {
+		WriteError(w, r, c.log, err, "Failed to create transfer")
+		return
+	}
+
+	if wait > 0 {
+		c.waitForTransfer(w, r, id, wait)
 		return
 	}
 
-	id, err := c.TransferService.CreateTransfer(r.Context(), transactionRequestDto.From.String(), transactionRequestDto.To.String(), transactionRequestDto.Amount)
+	c.writeCreated(w, r, id, model.StatusPending, "transaction accepted")
+}
+
+func (c *TransferController) waitForTransfer(w http.ResponseWriter, r *http.Request, id uuid.UUID, wait time.Duration) {
+	tx, err := c.TransferService.WaitForTransfer(r.Context(), id, wait)
 	if err != nil {
-		dtos.WriteErrorResponse(w, "Failed to create transfer", err.Error(), http.StatusInternalServerError)
+		WriteError(w, r, c.log, err, "Failed to wait for transfer")
 		return
 	}
 
-	response := dtos.CreateTransactionResponseDto{
-		TransactionId: id,
-		Status:        model.StatusSuccess,
-		Message:       "Transaction was successful",
+	c.writeCreated(w, r, id, tx.Status, "transaction processed while waiting")
+}
+
+// writeCreated answers a created transfer: 200 once it has its final status,
+// 202 while the worker has yet to process it.
+func (c *TransferController) writeCreated(w http.ResponseWriter, r *http.Request, id uuid.UUID, txStatus, msg string) {
+	response := dtos.NewCreateTransactionResponse(id, txStatus)
+	status := http.StatusOK
+	if txStatus == model.StatusPending {
+		status = http.StatusAccepted
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(response)
 
-	c.log.Info("transaction was successful", "response", response)
+	logger.WithContext(r.Context(), c.log).Info(msg, "response", response)
+}
+
+// parseWait reads the wait query parameter, either a duration such as 5s or
+// a number of seconds. It is 0 when absent.
+func (c *TransferController) parseWait(r *http.Request) (time.Duration, error) {
+	value := r.URL.Query().Get("wait")
+	if value == "" {
+		return 0, nil
+	}
+
+	wait, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, apperrors.Validation(apperrors.CodeValidationFailed, "wait must be a duration such as 5s").WithCause(err)
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait < 0 || wait > c.maxWait {
+		return 0, apperrors.Validation(apperrors.CodeValidationFailed, fmt.Sprintf("wait must be between 0s and %s", c.maxWait))
+	}
+	return wait, nil
 }
@@ -3,6 +3,7 @@ package handler
 import (
 	"encoding/json"
 	"github.com/gorilla/mux"
+	"moneyTransfer/internal/domain/apperrors"
 	"moneyTransfer/internal/domain/dtos"
 	"moneyTransfer/internal/domain/service"
 	"moneyTransfer/pkg/logger"
@@ -22,23 +23,27 @@ func NewUserController(service service.UserService, logger logger.Logger) *UserC
 // @Description Get current balance for a specific user
 // @Tags users
 // @Accept json
-// @Produce json
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
 // @Param userId path string true "User Id"
 // @Success 200 {object} dtos.BalanceResponseDto
-// @Failure 400 {object} dtos.ErrorResponse
-// @Failure 404 {object} dtos.ErrorResponse
-// @Failure 500 {object} dtos.ErrorResponse
+// @Failure 400 {object} dtos.ProblemDetails
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 404 {object} dtos.ProblemDetails
+// @Failure 500 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
 // @Router /balance/{userId} [get]
 func (c *UserController) GetUserBalance(w http.ResponseWriter, r *http.Request) {
 	userId := mux.Vars(r)["userId"]
 	if userId == "" {
-		dtos.WriteErrorResponse(w, "User Id is required", "GetUserBalance: User Id is required", http.StatusBadRequest)
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeMissingParameter, "User Id is required"), "")
 		return
 	}
 
 	balance, err := c.UserService.GetBalance(r.Context(), userId)
 	if err != nil {
-		dtos.WriteErrorResponse(w, "Error fetching balance", err.Error(), http.StatusInternalServerError)
+		WriteError(w, r, c.log, err, "Error fetching balance")
 		return
 	}
 
@@ -47,5 +52,5 @@ func (c *UserController) GetUserBalance(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 
-	c.log.Info("balance fetched successfully", "response", response)
+	logger.WithContext(r.Context(), c.log).Info("balance fetched successfully", "response", response)
 }
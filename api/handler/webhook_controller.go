@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/pkg/logger"
+	"net/http"
+)
+
+type WebhookController struct {
+	WebhookService service.WebhookService
+	log            logger.Logger
+}
+
+func NewWebhookController(webhookService service.WebhookService, logger logger.Logger) *WebhookController {
+	return &WebhookController{WebhookService: webhookService, log: logger}
+}
+
+// @Summary Register webhook
+// @Description Register an endpoint notified of transfer status changes. Its host must resolve to public addresses. Deliveries are signed with the returned secret, which is only shown once.
+// @Tags webhooks
+// @Accept json
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
+// @Param webhook body dtos.CreateWebhookRequestDto true "Webhook details"
+// @Success 201 {object} dtos.WebhookResponseDto
+// @Failure 400 {object} dtos.ProblemDetails
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Router /webhooks [post]
+func (c *WebhookController) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var request dtos.CreateWebhookRequestDto
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeInvalidRequestBody, "Error parsing request body").WithCause(err), "")
+		return
+	}
+
+	webhook, err := c.WebhookService.Register(r.Context(), request.URL, request.Events, request.Accounts)
+	if err != nil {
+		WriteError(w, r, c.log, err, "Failed to register webhook")
+		return
+	}
+
+	response := dtos.WebhookResponseDto{Webhook: webhook, Secret: webhook.Secret}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+
+	logger.WithContext(r.Context(), c.log).Info("webhook registered successfully", "webhookId", webhook.Id)
+}
+
+// @Summary List webhooks
+// @Description List the registered webhooks. Secrets are never returned.
+// @Tags webhooks
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
+// @Success 200 {object} dtos.WebhookListResponseDto
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Router /webhooks [get]
+func (c *WebhookController) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := c.WebhookService.List(r.Context())
+	if err != nil {
+		WriteError(w, r, c.log, err, "Error fetching webhooks")
+		return
+	}
+
+	response := dtos.WebhookListResponseDto{Webhooks: webhooks}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Delete webhook
+// @Description Delete a webhook together with its delivery log
+// @Tags webhooks
+// @Produce application/problem+json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook Id"
+// @Success 204
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 404 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Router /webhooks/{id} [delete]
+func (c *WebhookController) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeMissingParameter, "Webhook Id is required"), "")
+		return
+	}
+
+	err := c.WebhookService.Delete(r.Context(), id)
+	if err != nil {
+		WriteError(w, r, c.log, err, "Failed to delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	logger.WithContext(r.Context(), c.log).Info("webhook deleted successfully", "webhookId", id)
+}
+
+// @Summary List webhook deliveries
+// @Description List the latest deliveries of a webhook with the outcome of their last attempt, newest first
+// @Tags webhooks
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook Id"
+// @Success 200 {object} dtos.WebhookDeliveryListResponseDto
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 404 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Router /webhooks/{id}/deliveries [get]
+func (c *WebhookController) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeMissingParameter, "Webhook Id is required"), "")
+		return
+	}
+
+	deliveries, err := c.WebhookService.ListDeliveries(r.Context(), id)
+	if err != nil {
+		WriteError(w, r, c.log, err, "Error fetching webhook deliveries")
+		return
+	}
+
+	response := dtos.WebhookDeliveryListResponseDto{Deliveries: deliveries}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// @Summary Redeliver webhook delivery
+// @Description Send a delivery again right away with a fresh set of retries, whatever its current status
+// @Tags webhooks
+// @Produce json,application/problem+json
+// @Security ApiKeyAuth
+// @Param id path string true "Webhook Id"
+// @Param deliveryId path string true "Delivery Id"
+// @Success 202 {object} dtos.WebhookDeliveryResponseDto
+// @Failure 401 {object} dtos.ProblemDetails
+// @Failure 403 {object} dtos.ProblemDetails
+// @Failure 404 {object} dtos.ProblemDetails
+// @Failure 429 {object} dtos.ProblemDetails
+// @Router /webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (c *WebhookController) Redeliver(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, deliveryId := vars["id"], vars["deliveryId"]
+	if id == "" || deliveryId == "" {
+		WriteError(w, r, c.log, apperrors.Validation(apperrors.CodeMissingParameter, "Webhook Id and delivery Id are required"), "")
+		return
+	}
+
+	delivery, err := c.WebhookService.Redeliver(r.Context(), id, deliveryId)
+	if err != nil {
+		WriteError(w, r, c.log, err, "Failed to redeliver webhook delivery")
+		return
+	}
+
+	response := dtos.WebhookDeliveryResponseDto{Delivery: delivery}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+
+	logger.WithContext(r.Context(), c.log).Info("webhook redelivery queued successfully", "webhookId", id, "deliveryId", deliveryId)
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"moneyTransfer/pkg/logger"
+	"net/http"
+	"time"
+)
+
+// quietRoutes are polled by probes and scrapers; their requests are logged at
+// debug level so they do not drown out the rest.
+var quietRoutes = map[string]bool{
+	"/livez":   true,
+	"/readyz":  true,
+	"/metrics": true,
+}
+
+// AccessLog logs one line per request once it has been served. Register it
+// after RequestId so the line carries the request id.
+func AccessLog(log logger.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := newStatusRecorder(w)
+			next.ServeHTTP(recorder, r)
+
+			route := routeTemplate(r)
+			args := []any{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"route", route,
+				"status", recorder.Status(),
+				"bytes", recorder.Size(),
+				"duration", time.Since(start),
+				"remote_addr", r.RemoteAddr,
+				"user_agent", r.UserAgent(),
+			}
+			reqLog := logger.WithContext(r.Context(), log)
+			switch {
+			case quietRoutes[route]:
+				reqLog.Debug("request served", args...)
+			case recorder.Status() >= http.StatusInternalServerError:
+				reqLog.Warn("request served", args...)
+			default:
+				reqLog.Info("request served", args...)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/gorilla/mux"
+	"moneyTransfer/api/handler"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/pkg/logger"
+	"net/http"
+	"strings"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+type Authenticator struct {
+	apiKeyService service.APIKeyService
+	log           logger.Logger
+}
+
+func NewAuthenticator(apiKeyService service.APIKeyService, logger logger.Logger) *Authenticator {
+	return &Authenticator{apiKeyService: apiKeyService, log: logger}
+}
+
+// RequireScope rejects requests without a valid API key granting scope.
+// The key is read from the X-API-Key header or an "Authorization: Bearer" header.
+func (a *Authenticator) RequireScope(scope string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, err := a.Authenticate(r.Context(), extractAPIKey(r), scope, r.URL.Path)
+			if err != nil {
+				switch {
+				case errors.Is(err, errMissingAPIKey):
+					w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				case errors.Is(err, apperrors.ErrUnauthorized):
+					w.Header().Set("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+				}
+				handler.WriteError(w, r, a.log, err, "Failed to authenticate")
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+var errMissingAPIKey = apperrors.Unauthorized(apperrors.CodeAuthenticationMissing, "Authentication required")
+
+// Authenticate checks that rawKey is a valid API key granting scope for the
+// call to path, and returns ctx carrying the key and its actor. It is shared
+// by the REST middleware and the gRPC interceptor.
+func (a *Authenticator) Authenticate(ctx context.Context, rawKey, scope, path string) (context.Context, error) {
+	if rawKey == "" {
+		return ctx, errMissingAPIKey
+	}
+
+	key, err := a.apiKeyService.Authenticate(ctx, rawKey)
+	if err != nil {
+		return ctx, err
+	}
+
+	if !key.HasScope(scope) {
+		logger.WithContext(ctx, a.log).Warn("api key missing scope", "apiKeyId", key.Id, "scope", scope, "path", path)
+		return ctx, apperrors.Forbidden(apperrors.CodeInsufficientScope, "api key lacks scope "+scope)
+	}
+
+	ctx = model.WithAPIKey(ctx, key)
+	return model.WithActor(ctx, "apikey:"+key.Id.String()), nil
+}
+
+func extractAPIKey(r *http.Request) string {
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+
+	auth := r.Header.Get("Authorization")
+	if len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(auth[len("Bearer "):])
+	}
+
+	return ""
+}
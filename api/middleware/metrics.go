@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"moneyTransfer/pkg/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// unmatchedRoute labels requests that reached the middleware without a mux
+// route, keeping raw paths out of the labels.
+const unmatchedRoute = "unmatched"
+
+// Metrics records each request in m, labelled by method, route template and
+// status code.
+func Metrics(m *metrics.HTTPMetrics) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+			if route == "" {
+				route = unmatchedRoute
+			}
+
+			inFlight := m.InFlight.WithLabelValues(r.Method, route)
+			inFlight.Inc()
+			defer inFlight.Dec()
+
+			start := time.Now()
+			recorder := newStatusRecorder(w)
+			next.ServeHTTP(recorder, r)
+			duration := time.Since(start).Seconds()
+
+			code := strconv.Itoa(recorder.Status())
+			m.Requests.WithLabelValues(r.Method, route, code).Inc()
+			m.Duration.WithLabelValues(r.Method, route, code).Observe(duration)
+			m.ResponseSize.WithLabelValues(r.Method, route, code).Observe(float64(recorder.Size()))
+		})
+	}
+}
+
+// routeTemplate is the path template of the mux route serving r, e.g.
+// /transfers/{userId}, or "" outside a matched route.
+func routeTemplate(r *http.Request) string {
+	current := mux.CurrentRoute(r)
+	if current == nil {
+		return ""
+	}
+	template, err := current.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return template
+}
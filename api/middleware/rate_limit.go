@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"io"
+	"math"
+	"moneyTransfer/api/handler"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+	"moneyTransfer/pkg/ratelimit"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const maxPeekBodySize = 1 << 20
+
+// KeyFunc extracts the bucket key for a request. An empty key skips the policy.
+type KeyFunc func(r *http.Request) string
+
+// Names of the policies. The gRPC API keys its calls by them, since its
+// requests are not HTTP requests.
+const (
+	PolicyIP      = "ip"
+	PolicyClient  = "client"
+	PolicyAccount = "account"
+)
+
+type RateLimitPolicy struct {
+	Name    string
+	Limiter *ratelimit.Limiter
+	Key     KeyFunc
+}
+
+// RateLimiter holds the rate limit policies for each named route.
+type RateLimiter struct {
+	policies map[string][]RateLimitPolicy
+	log      logger.Logger
+}
+
+func NewRateLimiter(logger logger.Logger) *RateLimiter {
+	return &RateLimiter{policies: make(map[string][]RateLimitPolicy), log: logger}
+}
+
+func (rl *RateLimiter) AddPolicy(route string, policy RateLimitPolicy) {
+	rl.policies[route] = append(rl.policies[route], policy)
+}
+
+// For returns a middleware enforcing every policy registered for route.
+// A request must pass all of them; the headers describe the policy that
+// refused it, or else whichever policy is closest to its limit.
+func (rl *RateLimiter) For(route string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			d, ok := rl.Allow(route, func(policy RateLimitPolicy) string { return policy.Key(r) })
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			writeRateLimitHeaders(w, d.Policy, d.Result)
+			if !d.Result.Allowed {
+				logger.WithContext(r.Context(), rl.log).Warn("rate limit exceeded", "route", route, "policy", d.Policy.Name, "key", d.Key)
+				handler.WriteError(w, r, rl.log, apperrors.RateLimited(fmt.Sprintf("rate limit %q exceeded", d.Policy.Name), d.Result.RetryAfter), "")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitDecision is the outcome of a route's policies for one request.
+type RateLimitDecision struct {
+	Policy RateLimitPolicy
+	Key    string
+	Result ratelimit.Result
+}
+
+// Allow checks every policy of route, keyed by key, and takes a token from
+// each only if all of them allow the request, so a request refused by the
+// per-account limit does not use up the client's. It returns the refusing
+// policy that frees up last, or else the one closest to its limit. ok is
+// false when no policy applies.
+func (rl *RateLimiter) Allow(route string, key func(RateLimitPolicy) string) (d RateLimitDecision, ok bool) {
+	var decisions []RateLimitDecision
+	var takes []ratelimit.Take
+	for _, policy := range rl.policies[route] {
+		if k := key(policy); k != "" {
+			decisions = append(decisions, RateLimitDecision{Policy: policy, Key: k})
+			takes = append(takes, ratelimit.Take{Limiter: policy.Limiter, Key: policy.Name + ":" + k})
+		}
+	}
+	if len(takes) == 0 {
+		return RateLimitDecision{}, false
+	}
+
+	for i, result := range ratelimit.AllowAll(takes...) {
+		decisions[i].Result = result
+	}
+
+	d = decisions[0]
+	for _, other := range decisions[1:] {
+		if (d.Result.Allowed && other.Result.Remaining < d.Result.Remaining) || other.Result.RetryAfter > d.Result.RetryAfter {
+			d = other
+		}
+	}
+	return d, true
+}
+
+func writeRateLimitHeaders(w http.ResponseWriter, policy RateLimitPolicy, result ratelimit.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limiter.Limit(), ceilSeconds(policy.Limiter.Window())))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// ClientKey identifies the caller by API key when authenticated and by
+// remote IP otherwise.
+func ClientKey(r *http.Request) string {
+	if key, ok := model.APIKeyFromContext(r.Context()); ok {
+		return "apikey:" + key.Id.String()
+	}
+	return "ip:" + clientIP(r)
+}
+
+// IPKey identifies the caller by remote IP, for limits that run before
+// authentication.
+func IPKey(r *http.Request) string {
+	return "ip:" + clientIP(r)
+}
+
+// PathAccountKey keys requests by the account id in the given route variable.
+func PathAccountKey(param string) KeyFunc {
+	return func(r *http.Request) string {
+		if id := mux.Vars(r)[param]; id != "" {
+			return "account:" + id
+		}
+		return ""
+	}
+}
+
+// TransferSenderKey keys transfer requests by the sending account. The body
+// is restored so the handler can decode it again.
+func TransferSenderKey(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBodySize))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+	if err != nil {
+		return ""
+	}
+
+	var request dtos.TransactionRequestDto
+	if err := json.Unmarshal(body, &request); err != nil {
+		return ""
+	}
+
+	senderId, err := uuid.Parse(request.From)
+	if err != nil {
+		return ""
+	}
+
+	return "account:" + senderId.String()
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"github.com/google/uuid"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+	"net/http"
+)
+
+const RequestIdHeader = "X-Request-ID"
+
+// CorrelationIdHeader is the header request ids were first sent under. It is
+// still accepted and echoed for existing clients.
+const CorrelationIdHeader = "X-Correlation-ID"
+
+// RequestId reuses the caller's X-Request-ID, or X-Correlation-ID, or
+// generates one. The id is echoed in the response, stored in the request
+// context and added to every line logged for the request.
+func RequestId(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIdHeader)
+		if id == "" {
+			id = r.Header.Get(CorrelationIdHeader)
+		}
+		if !model.ValidRequestId(id) {
+			id = uuid.NewString()
+		}
+
+		w.Header().Set(RequestIdHeader, id)
+		w.Header().Set(CorrelationIdHeader, id)
+
+		ctx := model.WithRequestId(r.Context(), id)
+		ctx = logger.WithFields(ctx, "request_id", id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"moneyTransfer/api/middleware"
+	"moneyTransfer/pkg/logger"
+	"moneyTransfer/pkg/ratelimit"
+)
+
+const (
+	RouteListTransfers  = "transfers.list"
+	RouteCreateTransfer = "transfers.create"
+	RouteGetBalance     = "balance.get"
+	RouteUserEvents     = "users.events"
+	RouteAPIKeys        = "api-keys"
+	RouteWebhooks       = "webhooks"
+	RouteAdmin          = "admin"
+
+	// RouteAuthenticate is checked before authentication on every protected
+	// route, so callers guessing API keys are limited by IP.
+	RouteAuthenticate = "authenticate"
+)
+
+// NewDefaultRateLimiter builds the per-route policies. Transfers are also
+// limited per sending account so one client cannot fill the job queue on
+// behalf of a single account.
+func NewDefaultRateLimiter(log logger.Logger) *middleware.RateLimiter {
+	rl := middleware.NewRateLimiter(log)
+
+	rl.AddPolicy(RouteAuthenticate, middleware.RateLimitPolicy{Name: middleware.PolicyIP, Limiter: ratelimit.NewLimiter(50, 100), Key: middleware.IPKey})
+
+	rl.AddPolicy(RouteCreateTransfer, middleware.RateLimitPolicy{Name: middleware.PolicyClient, Limiter: ratelimit.NewLimiter(5, 10), Key: middleware.ClientKey})
+	rl.AddPolicy(RouteCreateTransfer, middleware.RateLimitPolicy{Name: middleware.PolicyAccount, Limiter: ratelimit.NewLimiter(1, 5), Key: middleware.TransferSenderKey})
+
+	rl.AddPolicy(RouteListTransfers, middleware.RateLimitPolicy{Name: middleware.PolicyClient, Limiter: ratelimit.NewLimiter(20, 40), Key: middleware.ClientKey})
+	rl.AddPolicy(RouteListTransfers, middleware.RateLimitPolicy{Name: middleware.PolicyAccount, Limiter: ratelimit.NewLimiter(5, 20), Key: middleware.PathAccountKey("userId")})
+
+	rl.AddPolicy(RouteGetBalance, middleware.RateLimitPolicy{Name: middleware.PolicyClient, Limiter: ratelimit.NewLimiter(20, 40), Key: middleware.ClientKey})
+	rl.AddPolicy(RouteGetBalance, middleware.RateLimitPolicy{Name: middleware.PolicyAccount, Limiter: ratelimit.NewLimiter(5, 20), Key: middleware.PathAccountKey("userId")})
+
+	// Streams are long-lived, so this only limits how fast clients reconnect.
+	rl.AddPolicy(RouteUserEvents, middleware.RateLimitPolicy{Name: middleware.PolicyClient, Limiter: ratelimit.NewLimiter(1, 10), Key: middleware.ClientKey})
+
+	rl.AddPolicy(RouteAPIKeys, middleware.RateLimitPolicy{Name: middleware.PolicyClient, Limiter: ratelimit.NewLimiter(1, 5), Key: middleware.ClientKey})
+	rl.AddPolicy(RouteWebhooks, middleware.RateLimitPolicy{Name: middleware.PolicyClient, Limiter: ratelimit.NewLimiter(2, 10), Key: middleware.ClientKey})
+	rl.AddPolicy(RouteAdmin, middleware.RateLimitPolicy{Name: middleware.PolicyClient, Limiter: ratelimit.NewLimiter(0.2, 2), Key: middleware.ClientKey})
+
+	return rl
+}
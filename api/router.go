@@ -2,25 +2,68 @@ package api
 
 import (
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/swaggo/http-swagger"
 	"moneyTransfer/api/handler"
+	"moneyTransfer/api/middleware"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
 	"moneyTransfer/pkg/metrics"
+	"net/http"
 )
 
 func InitRouter(
 	transferController *handler.TransferController,
 	userController *handler.UserController,
+	apiKeyController *handler.APIKeyController,
+	reconciliationController *handler.ReconciliationController,
+	webhookController *handler.WebhookController,
+	eventStreamController *handler.EventStreamController,
+	healthController *handler.HealthController,
+	logLevelController *handler.LogLevelController,
+	authenticator *middleware.Authenticator,
+	rateLimiter *middleware.RateLimiter,
+	registry *prometheus.Registry,
+	log logger.Logger,
 ) *mux.Router {
 	router := mux.NewRouter()
 
-	router.HandleFunc("/transfers/{userId}", transferController.GetTransactionsByUserId).Methods("GET")
-	router.HandleFunc("/transfers", transferController.CreateTransaction).Methods("POST")
-	router.HandleFunc("/balance/{userId}", userController.GetUserBalance).Methods("GET")
+	// An IP limit runs before authentication, so a client cannot try keys
+	// without limit. The route's own limits run after it, keyed by API key.
+	protected := func(route, scope string, h http.HandlerFunc) http.Handler {
+		return rateLimiter.For(RouteAuthenticate)(authenticator.RequireScope(scope)(rateLimiter.For(route)(h)))
+	}
 
-	router.Use(metrics.NewPrometheusMiddleware())
+	router.Handle("/transfers/{userId}", protected(RouteListTransfers, model.ScopeTransfersRead, transferController.GetTransactionsByUserId)).Methods("GET")
+	router.Handle("/transfers", protected(RouteCreateTransfer, model.ScopeTransfersWrite, transferController.CreateTransaction)).Methods("POST")
+	router.Handle("/balance/{userId}", protected(RouteGetBalance, model.ScopeBalancesRead, userController.GetUserBalance)).Methods("GET")
+	router.Handle("/users/{id}/events", protected(RouteUserEvents, model.ScopeTransfersRead, eventStreamController.StreamUserEvents)).Methods("GET")
 
-	router.Handle("/metrics", promhttp.Handler())
+	router.Handle("/api-keys", protected(RouteAPIKeys, model.ScopeAdmin, apiKeyController.CreateAPIKey)).Methods("POST")
+	router.Handle("/api-keys", protected(RouteAPIKeys, model.ScopeAdmin, apiKeyController.ListAPIKeys)).Methods("GET")
+	router.Handle("/api-keys/{id}", protected(RouteAPIKeys, model.ScopeAdmin, apiKeyController.RevokeAPIKey)).Methods("DELETE")
+	router.Handle("/api-keys/{id}/rotate", protected(RouteAPIKeys, model.ScopeAdmin, apiKeyController.RotateAPIKey)).Methods("POST")
+
+	router.Handle("/webhooks", protected(RouteWebhooks, model.ScopeWebhooks, webhookController.CreateWebhook)).Methods("POST")
+	router.Handle("/webhooks", protected(RouteWebhooks, model.ScopeWebhooks, webhookController.ListWebhooks)).Methods("GET")
+	router.Handle("/webhooks/{id}", protected(RouteWebhooks, model.ScopeWebhooks, webhookController.DeleteWebhook)).Methods("DELETE")
+	router.Handle("/webhooks/{id}/deliveries", protected(RouteWebhooks, model.ScopeWebhooks, webhookController.ListDeliveries)).Methods("GET")
+	router.Handle("/webhooks/{id}/deliveries/{deliveryId}/redeliver", protected(RouteWebhooks, model.ScopeWebhooks, webhookController.Redeliver)).Methods("POST")
+
+	router.Handle("/admin/reconciliation", protected(RouteAdmin, model.ScopeAdmin, reconciliationController.Reconcile)).Methods("GET")
+	router.Handle("/admin/log-level", protected(RouteAdmin, model.ScopeAdmin, logLevelController.GetLogLevel)).Methods("GET")
+	router.Handle("/admin/log-level", protected(RouteAdmin, model.ScopeAdmin, logLevelController.SetLogLevel)).Methods("PUT")
+
+	router.Use(middleware.RequestId)
+	router.Use(middleware.AccessLog(log))
+	router.Use(middleware.Tracing)
+	router.Use(middleware.Metrics(metrics.NewHTTPMetrics(registry)))
+
+	// Probes are unauthenticated and unlimited, like /metrics.
+	router.HandleFunc("/livez", healthController.Livez).Methods("GET")
+	router.HandleFunc("/readyz", healthController.Readyz).Methods("GET")
+	router.Handle("/metrics", promhttp.InstrumentMetricHandler(registry, promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
 	return router
@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+)
+
+// errorDomain is the ErrorInfo domain of the errors returned by the API.
+const errorDomain = "moneytransfer"
+
+// toStatus is the gRPC counterpart of handler.WriteError. Typed errors keep
+// their message and code, reported as the ErrorInfo reason alongside the
+// request id; field errors become a BadRequest detail. Anything else is an
+// Internal error with fallbackMessage, and only then is the cause logged.
+func toStatus(ctx context.Context, log logger.Logger, method string, err error, fallbackMessage string) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	requestId := model.RequestIdFromContext(ctx)
+	if requestId == "" {
+		requestId = uuid.NewString()
+	}
+
+	appErr, ok := apperrors.As(err)
+	if !ok {
+		appErr = apperrors.New(apperrors.KindInternal, apperrors.CodeInternal, fallbackMessage)
+	}
+
+	code := CodeFor(appErr.Kind)
+	message := appErr.Message
+	if appErr.Kind == apperrors.KindInternal && fallbackMessage != "" {
+		message = fallbackMessage
+	}
+
+	if appErr.Kind == apperrors.KindInternal {
+		logger.WithContext(ctx, log).Error("request failed", "request_id", requestId, "method", method, "code", code.String(), "error", err)
+	}
+
+	st := status.New(code, message)
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: appErr.Code, Domain: errorDomain, Metadata: map[string]string{"request_id": requestId}}}
+	if len(appErr.Fields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(appErr.Fields))
+		for i, f := range appErr.Fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Reason: f.Code, Description: f.Message}
+		}
+		details = append(details, &errdetails.BadRequest{FieldViolations: violations})
+	}
+	if appErr.RetryAfter > 0 {
+		details = append(details, &errdetails.RetryInfo{RetryDelay: durationpb.New(appErr.RetryAfter)})
+	}
+
+	if withDetails, detailErr := st.WithDetails(details...); detailErr == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// CodeFor maps an error kind to its gRPC code, as handler.StatusFor maps it
+// to an HTTP status.
+func CodeFor(kind apperrors.Kind) codes.Code {
+	switch kind {
+	case apperrors.KindNotFound:
+		return codes.NotFound
+	case apperrors.KindValidation:
+		return codes.InvalidArgument
+	case apperrors.KindInsufficientFunds:
+		return codes.FailedPrecondition
+	case apperrors.KindConflict:
+		return codes.Aborted
+	case apperrors.KindForbidden:
+		return codes.PermissionDenied
+	case apperrors.KindUnauthorized:
+		return codes.Unauthenticated
+	case apperrors.KindRateLimited:
+		return codes.ResourceExhausted
+	case apperrors.KindUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
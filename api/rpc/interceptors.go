@@ -0,0 +1,276 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"moneyTransfer/api"
+	"moneyTransfer/api/middleware"
+	"moneyTransfer/api/rpc/transferv1"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+	"moneyTransfer/pkg/metrics"
+	"net"
+	"path"
+	"strings"
+	"time"
+)
+
+// Metadata keys read by the interceptors, the lower-cased REST headers.
+var (
+	requestIdKey     = strings.ToLower(middleware.RequestIdHeader)
+	correlationIdKey = strings.ToLower(middleware.CorrelationIdHeader)
+	apiKeyKey        = strings.ToLower(middleware.APIKeyHeader)
+)
+
+// interceptor runs around one call, unary or streaming, and may give it a
+// new context. req is the request message of unary calls and nil for streams,
+// whose messages are only received by the handler.
+type interceptor func(ctx context.Context, method string, req any, call func(context.Context) error) error
+
+// NewGRPCServer returns a gRPC server for s. Calls are traced, given a
+// request id, logged and measured like HTTP requests, then authenticated by
+// API key and rate limited by the policies of the matching REST route.
+func NewGRPCServer(s *Server, authenticator *middleware.Authenticator, rateLimiter *middleware.RateLimiter, m *metrics.GRPCMetrics, log logger.Logger) *grpc.Server {
+	interceptors := []interceptor{
+		requestId, accessLog(log), measure(m),
+		rateLimit(rateLimiter, log, authenticateRoute),
+		authenticate(authenticator, log),
+		rateLimit(rateLimiter, log, methodRoute),
+	}
+
+	unary := make([]grpc.UnaryServerInterceptor, len(interceptors))
+	streams := make([]grpc.StreamServerInterceptor, len(interceptors))
+	for i, in := range interceptors {
+		unary[i], streams[i] = unaryInterceptor(in), streamInterceptor(in)
+	}
+
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(streams...),
+	)
+	transferv1.RegisterTransferServiceServer(server, s)
+	return server
+}
+
+func unaryInterceptor(in interceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var resp any
+		err := in(ctx, info.FullMethod, req, func(ctx context.Context) error {
+			var err error
+			resp, err = handler(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+func streamInterceptor(in interceptor) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return in(ss.Context(), info.FullMethod, nil, func(ctx context.Context) error {
+			return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		})
+	}
+}
+
+// serverStream replaces the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// requestId is the RequestId middleware for gRPC: it reuses the caller's
+// x-request-id, or x-correlation-id, or generates one, and sends it back in
+// the response header.
+func requestId(ctx context.Context, _ string, _ any, call func(context.Context) error) error {
+	id := firstMetadata(ctx, requestIdKey)
+	if id == "" {
+		id = firstMetadata(ctx, correlationIdKey)
+	}
+	if !model.ValidRequestId(id) {
+		id = uuid.NewString()
+	}
+
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestIdKey, id))
+
+	ctx = model.WithRequestId(ctx, id)
+	ctx = logger.WithFields(ctx, "request_id", id)
+	return call(ctx)
+}
+
+// accessLog logs one line per call once it has been served. Server-side
+// failures are logged at warn, like 5xx responses.
+func accessLog(log logger.Logger) interceptor {
+	return func(ctx context.Context, method string, _ any, call func(context.Context) error) error {
+		start := time.Now()
+		err := call(ctx)
+
+		code := status.Code(err)
+		args := []any{
+			"method", method,
+			"code", code.String(),
+			"duration", time.Since(start),
+		}
+		if p, ok := peer.FromContext(ctx); ok {
+			args = append(args, "remote_addr", p.Addr.String())
+		}
+
+		callLog := logger.WithContext(ctx, log)
+		switch code {
+		case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss, codes.Unimplemented:
+			callLog.Warn("rpc served", args...)
+		default:
+			callLog.Info("rpc served", args...)
+		}
+		return err
+	}
+}
+
+// measure records each call in m.
+func measure(m *metrics.GRPCMetrics) interceptor {
+	return func(ctx context.Context, method string, _ any, call func(context.Context) error) error {
+		service, name := splitMethod(method)
+
+		inFlight := m.InFlight.WithLabelValues(service, name)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		err := call(ctx)
+
+		code := status.Code(err).String()
+		m.Handled.WithLabelValues(service, name, code).Inc()
+		m.Duration.WithLabelValues(service, name, code).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// authenticate requires the API key scope of methodScopes, read from the
+// x-api-key or "authorization: Bearer" metadata. Methods without a scope are
+// refused.
+func authenticate(authenticator *middleware.Authenticator, log logger.Logger) interceptor {
+	return func(ctx context.Context, method string, _ any, call func(context.Context) error) error {
+		scope, ok := methodScopes[method]
+		if !ok {
+			return status.Errorf(codes.Unimplemented, "unknown method %s", method)
+		}
+
+		ctx, err := authenticator.Authenticate(ctx, apiKeyFromMetadata(ctx), scope, method)
+		if err != nil {
+			return toStatus(ctx, log, method, err, "Failed to authenticate")
+		}
+		return call(ctx)
+	}
+}
+
+// rateLimit enforces the rate limit policies of the route returned for each
+// method. Calls share their buckets with the REST API, so a client cannot
+// double its allowance by switching protocols.
+func rateLimit(rateLimiter *middleware.RateLimiter, log logger.Logger, route func(method string) string) interceptor {
+	return func(ctx context.Context, method string, req any, call func(context.Context) error) error {
+		d, ok := rateLimiter.Allow(route(method), callKey(ctx, req))
+		if ok && !d.Result.Allowed {
+			logger.WithContext(ctx, log).Warn("rate limit exceeded", "method", method, "policy", d.Policy.Name, "key", d.Key)
+			return toStatus(ctx, log, method, apperrors.RateLimited(fmt.Sprintf("rate limit %q exceeded", d.Policy.Name), d.Result.RetryAfter), "")
+		}
+		return call(ctx)
+	}
+}
+
+func authenticateRoute(string) string {
+	return api.RouteAuthenticate
+}
+
+func methodRoute(method string) string {
+	return methodRoutes[method]
+}
+
+// callKey keys a call for each policy the way the REST policies key a
+// request: by caller IP, by API key once authenticated, or by the account
+// the request names.
+func callKey(ctx context.Context, req any) func(middleware.RateLimitPolicy) string {
+	return func(policy middleware.RateLimitPolicy) string {
+		switch policy.Name {
+		case middleware.PolicyIP:
+			return peerKey(ctx)
+		case middleware.PolicyClient:
+			if key, ok := model.APIKeyFromContext(ctx); ok {
+				return "apikey:" + key.Id.String()
+			}
+			return peerKey(ctx)
+		case middleware.PolicyAccount:
+			return accountKey(req)
+		default:
+			return ""
+		}
+	}
+}
+
+func peerKey(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return "ip:" + host
+}
+
+// accountKey keys transfers by sending account and reads by the account
+// they are about, like TransferSenderKey and PathAccountKey.
+func accountKey(req any) string {
+	var account string
+	switch req := req.(type) {
+	case interface{ GetFrom() string }:
+		account = req.GetFrom()
+	case interface{ GetUserId() string }:
+		account = req.GetUserId()
+	}
+
+	id, err := uuid.Parse(account)
+	if err != nil {
+		return ""
+	}
+	return "account:" + id.String()
+}
+
+func apiKeyFromMetadata(ctx context.Context) string {
+	if key := firstMetadata(ctx, apiKeyKey); key != "" {
+		return key
+	}
+
+	auth := firstMetadata(ctx, "authorization")
+	if len(auth) > len("Bearer ") && strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(auth[len("Bearer "):])
+	}
+	return ""
+}
+
+func firstMetadata(ctx context.Context, key string) string {
+	values := metadata.ValueFromIncomingContext(ctx, key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// splitMethod splits a full method name such as
+// /moneytransfer.v1.TransferService/GetBalance into service and method.
+func splitMethod(fullMethod string) (string, string) {
+	service, method := path.Split(fullMethod)
+	return strings.Trim(service, "/"), method
+}
@@ -0,0 +1,191 @@
+// Package rpc serves the gRPC API defined in transferv1/transfer.proto. It
+// calls the same services as the REST handlers and maps their results and
+// errors the same way, so both APIs stay consistent.
+//
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative transferv1/transfer.proto
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"moneyTransfer/api"
+	"moneyTransfer/api/rpc/transferv1"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/pkg/logger"
+	"time"
+)
+
+// watchInterval bounds each wait of WatchTransfer, after which it checks the
+// transfer again.
+const watchInterval = 30 * time.Second
+
+// methodScopes is the API key scope each method requires.
+var methodScopes = map[string]string{
+	transferv1.TransferService_CreateTransfer_FullMethodName:   model.ScopeTransfersWrite,
+	transferv1.TransferService_GetTransaction_FullMethodName:   model.ScopeTransfersRead,
+	transferv1.TransferService_ListTransactions_FullMethodName: model.ScopeTransfersRead,
+	transferv1.TransferService_GetBalance_FullMethodName:       model.ScopeBalancesRead,
+	transferv1.TransferService_WatchTransfer_FullMethodName:    model.ScopeTransfersRead,
+}
+
+// methodRoutes is the REST route whose rate limits each method shares.
+var methodRoutes = map[string]string{
+	transferv1.TransferService_CreateTransfer_FullMethodName:   api.RouteCreateTransfer,
+	transferv1.TransferService_GetTransaction_FullMethodName:   api.RouteListTransfers,
+	transferv1.TransferService_ListTransactions_FullMethodName: api.RouteListTransfers,
+	transferv1.TransferService_GetBalance_FullMethodName:       api.RouteGetBalance,
+	transferv1.TransferService_WatchTransfer_FullMethodName:    api.RouteUserEvents,
+}
+
+type Server struct {
+	transferv1.UnimplementedTransferServiceServer
+	transferService service.TransferService
+	userService     service.UserService
+	// maxWait caps the wait of CreateTransfer.
+	maxWait time.Duration
+	log     logger.Logger
+}
+
+func NewServer(transferService service.TransferService, userService service.UserService, maxWait time.Duration, logger logger.Logger) *Server {
+	return &Server{transferService: transferService, userService: userService, maxWait: maxWait, log: logger}
+}
+
+func (s *Server) CreateTransfer(ctx context.Context, req *transferv1.CreateTransferRequest) (*transferv1.CreateTransferResponse, error) {
+	method := transferv1.TransferService_CreateTransfer_FullMethodName
+	wait := req.GetWait().AsDuration()
+	if wait < 0 || wait > s.maxWait {
+		return nil, toStatus(ctx, s.log, method, apperrors.Validation(apperrors.CodeValidationFailed, fmt.Sprintf("wait must be between 0s and %s", s.maxWait)), "")
+	}
+
+	id, err := s.transferService.CreateTransfer(ctx, req.GetFrom(), req.GetTo(), req.GetAmount())
+	if err != nil {
+		return nil, toStatus(ctx, s.log, method, err, "Failed to create transfer")
+	}
+
+	status := model.StatusPending
+	if wait > 0 {
+		tx, err := s.transferService.WaitForTransfer(ctx, id, wait)
+		if err != nil {
+			return nil, toStatus(ctx, s.log, method, err, "Failed to wait for transfer")
+		}
+		status = tx.Status
+	}
+
+	response := dtos.NewCreateTransactionResponse(id, status)
+	return &transferv1.CreateTransferResponse{
+		TransactionId: response.TransactionId.String(),
+		Status:        toProtoStatus(response.Status),
+		Message:       response.Message,
+	}, nil
+}
+
+func (s *Server) GetTransaction(ctx context.Context, req *transferv1.GetTransactionRequest) (*transferv1.Transaction, error) {
+	method := transferv1.TransferService_GetTransaction_FullMethodName
+	id, err := parseTransactionId(req.GetId())
+	if err != nil {
+		return nil, toStatus(ctx, s.log, method, err, "")
+	}
+
+	tx, err := s.transferService.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, toStatus(ctx, s.log, method, err, "Error fetching transaction")
+	}
+	return toProtoTransaction(tx), nil
+}
+
+func (s *Server) ListTransactions(ctx context.Context, req *transferv1.ListTransactionsRequest) (*transferv1.ListTransactionsResponse, error) {
+	method := transferv1.TransferService_ListTransactions_FullMethodName
+	if req.GetUserId() == "" {
+		return nil, toStatus(ctx, s.log, method, apperrors.Validation(apperrors.CodeMissingParameter, "User Id is required"), "")
+	}
+
+	transactions, err := s.transferService.GetTransactionsByUserId(ctx, req.GetUserId())
+	if err != nil {
+		return nil, toStatus(ctx, s.log, method, err, "Error fetching transactions")
+	}
+
+	response := &transferv1.ListTransactionsResponse{Transactions: make([]*transferv1.Transaction, len(transactions))}
+	for i, tx := range transactions {
+		response.Transactions[i] = toProtoTransaction(tx)
+	}
+	return response, nil
+}
+
+func (s *Server) GetBalance(ctx context.Context, req *transferv1.GetBalanceRequest) (*transferv1.GetBalanceResponse, error) {
+	method := transferv1.TransferService_GetBalance_FullMethodName
+	if req.GetUserId() == "" {
+		return nil, toStatus(ctx, s.log, method, apperrors.Validation(apperrors.CodeMissingParameter, "User Id is required"), "")
+	}
+
+	balance, err := s.userService.GetBalance(ctx, req.GetUserId())
+	if err != nil {
+		return nil, toStatus(ctx, s.log, method, err, "Error fetching balance")
+	}
+	return &transferv1.GetBalanceResponse{Balance: balance}, nil
+}
+
+func (s *Server) WatchTransfer(req *transferv1.WatchTransferRequest, stream grpc.ServerStreamingServer[transferv1.Transaction]) error {
+	ctx := stream.Context()
+	method := transferv1.TransferService_WatchTransfer_FullMethodName
+	id, err := parseTransactionId(req.GetId())
+	if err != nil {
+		return toStatus(ctx, s.log, method, err, "")
+	}
+
+	tx, err := s.transferService.GetTransaction(ctx, id)
+	if err != nil {
+		return toStatus(ctx, s.log, method, err, "Error fetching transaction")
+	}
+	if err := stream.Send(toProtoTransaction(tx)); err != nil || tx.Status != model.StatusPending {
+		return err
+	}
+
+	for tx.Status == model.StatusPending {
+		tx, err = s.transferService.WaitForTransfer(ctx, id, watchInterval)
+		if err != nil {
+			return toStatus(ctx, s.log, method, err, "Failed to wait for transfer")
+		}
+	}
+	return stream.Send(toProtoTransaction(tx))
+}
+
+func parseTransactionId(value string) (uuid.UUID, error) {
+	if value == "" {
+		return uuid.Nil, apperrors.Validation(apperrors.CodeMissingParameter, "Transaction Id is required")
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, apperrors.Validation(apperrors.CodeValidationFailed, "Transaction Id must be a valid UUID")
+	}
+	return id, nil
+}
+
+func toProtoTransaction(tx model.Transaction) *transferv1.Transaction {
+	return &transferv1.Transaction{
+		Id:         tx.Id.String(),
+		SenderId:   tx.SenderId.String(),
+		ReceiverId: tx.ReceiverId.String(),
+		Amount:     tx.Amount,
+		Status:     toProtoStatus(tx.Status),
+		CreatedAt:  timestamppb.New(tx.CreatedAt),
+	}
+}
+
+func toProtoStatus(status string) transferv1.TransactionStatus {
+	switch status {
+	case model.StatusPending:
+		return transferv1.TransactionStatus_TRANSACTION_STATUS_PENDING
+	case model.StatusSuccess:
+		return transferv1.TransactionStatus_TRANSACTION_STATUS_SUCCESS
+	case model.StatusFailed:
+		return transferv1.TransactionStatus_TRANSACTION_STATUS_FAILED
+	default:
+		return transferv1.TransactionStatus_TRANSACTION_STATUS_UNSPECIFIED
+	}
+}
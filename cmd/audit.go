@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/pkg/logger"
+	"os"
+)
+
+// runAudit handles `audit verify`. It prints the verification result as JSON
+// and exits 1 when the chain is broken, so it can gate scheduled jobs.
+func runAudit(cfg config.Config, args []string) int {
+	if len(args) != 1 || args[0] != "verify" {
+		fmt.Fprintln(os.Stderr, "usage: server audit verify")
+		return 2
+	}
+
+	repos, err := openStorage(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer repos.close()
+
+	auditService := service.NewAuditService(repos.audit, logger.Log)
+
+	result, err := auditService.Verify(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "audit verification failed:", err)
+		return 2
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(result)
+
+	if !result.Valid {
+		return 1
+	}
+	return 0
+}
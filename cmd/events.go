@@ -0,0 +1,24 @@
+package main
+
+import (
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/outbox"
+	"os"
+	"time"
+)
+
+// brokerSlowTimeout is how long the in-process broker waits for a subscriber
+// before dropping it.
+const brokerSlowTimeout = time.Second
+
+// openSink opens the destination selected by outbox.sink.
+func openSink(cfg config.OutboxConfig) (outbox.Sink, error) {
+	switch cfg.Sink {
+	case config.SinkStdout:
+		return outbox.NewWriterSink(os.Stdout), nil
+	case config.SinkFile:
+		return outbox.NewFileSink(cfg.FilePath)
+	default:
+		return outbox.NewBroker(brokerSlowTimeout), nil
+	}
+}
@@ -3,58 +3,211 @@ package main
 import (
 	"context"
 	"errors"
-	"github.com/joho/godotenv"
+	"fmt"
+	"google.golang.org/grpc"
 	"log"
 	"moneyTransfer/api"
 	"moneyTransfer/api/handler"
-	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/api/middleware"
+	"moneyTransfer/api/rpc"
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/domain/model"
 	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/internal/health"
+	"moneyTransfer/internal/outbox"
 	"moneyTransfer/internal/queue"
-	"moneyTransfer/internal/repository"
-	"moneyTransfer/internal/repository/postgres"
+	"moneyTransfer/internal/stream"
+	"moneyTransfer/internal/tracing"
+	"moneyTransfer/internal/webhook"
 	"moneyTransfer/pkg/logger"
+	"moneyTransfer/pkg/metrics"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	_ "moneyTransfer/docs"
 )
 
+const usage = `usage: server [command] [flags]
+
+commands:
+  serve          run the HTTP API and transfer worker (default)
+  config         print the effective configuration with secrets redacted
+  audit verify   verify the hash chain of the audit log
+  reconcile      recompute balances from transactions and report discrepancies
+  migrate        apply (up), revert (down) or list (status) schema migrations`
+
 // @title Money Transfer API
 // @version 1.0
 // @description REST API for transferring money between users
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
-	logger.Init()
+	// Flags without a command belong to serve, e.g. `server -port 9000`.
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+
+	// Only serve takes configuration flags; the other commands have their own.
+	var configArgs []string
+	if command == "serve" {
+		configArgs = args
+	}
+
+	cfg, err := config.Load(configArgs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	logOptions, err := cfg.Log.Options()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := logger.Init(logOptions); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
 	logger.Log.Info("Logger initialized")
 
-	err := godotenv.Load()
+	switch command {
+	case "serve":
+		serve(cfg)
+	case "config":
+		fmt.Print(cfg.Dump())
+	case "audit":
+		os.Exit(runAudit(cfg, args))
+	case "reconcile":
+		os.Exit(runReconcile(cfg, args))
+	case "migrate":
+		os.Exit(runMigrate(cfg, args))
+	default:
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
+
+func serve(cfg config.Config) {
+	logger.Log.Info("configuration loaded", "config", cfg.Redacted())
+
+	// Tracing comes first so the database client is instrumented.
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
 	if err != nil {
-		log.Fatal("Error loading .env file")
+		log.Fatal(err)
 	}
-	port := os.Getenv("SERVER_PORT")
 
-	db, err := postgres.NewPostgresClient()
+	repos, err := openStorage(cfg)
 	if err != nil {
-		log.Fatal("failed to connect to database:", err)
+		log.Fatal(err)
 	}
-	defer db.Close()
+	defer repos.close()
 
-	var transferRepo contracts.TransferRepository = repository.NewTransferRepository(db)
-	var userRepo contracts.UserRepository = repository.NewUserRepository(db)
+	// Auto-migration applies pending migrations before serving, which is what
+	// docker-compose uses; otherwise run `server migrate up` separately.
+	if cfg.Database.AutoMigrate && repos.migrator != nil {
+		if _, err := repos.migrator.Up(context.Background()); err != nil {
+			log.Fatal("failed to apply migrations:", err)
+		}
+	}
+
+	jobs, err := openQueue(context.Background(), cfg.Queue, repos)
+	if err != nil {
+		log.Fatal("failed to open queue:", err)
+	}
+	defer jobs.Close()
+	registry := metrics.NewRegistry()
+	metrics.RegisterQueueDepth(registry, jobs.Depth)
 
-	transferService := service.NewTransferService(transferRepo, userRepo, logger.Log)
-	userService := service.NewUserService(userRepo, logger.Log)
+	completions := queue.NewCompletions()
+	transferService := service.NewTransferService(repos.transfers, repos.users, jobs, completions, logger.Log)
+	userService := service.NewUserService(repos.users, logger.Log)
+	apiKeyService := service.NewAPIKeyService(repos.apiKeys, repos.webhooks, repos.txManager, logger.Log)
+	reconciliationService := service.NewReconciliationService(repos.ledger, logger.Log)
+	webhookService := service.NewWebhookService(repos.webhooks, repos.deliveries, webhook.NewGuard(cfg.Webhooks.AllowedHosts, net.DefaultResolver), logger.Log)
 
-	transferController := handler.NewTransferController(transferService, logger.Log)
+	// The admin key lets operators bootstrap the first admin key, which can then issue scoped keys.
+	if cfg.Auth.AdminAPIKey != "" {
+		err := apiKeyService.EnsureKey(context.Background(), "bootstrap-admin", cfg.Auth.AdminAPIKey, []string{model.ScopeAdmin})
+		if err != nil {
+			log.Fatal("failed to register admin api key:", err)
+		}
+	}
+
+	transferController := handler.NewTransferController(transferService, cfg.Server.MaxTransferWait, logger.Log)
 	userController := handler.NewUserController(userService, logger.Log)
+	apiKeyController := handler.NewAPIKeyController(apiKeyService, logger.Log)
+	reconciliationController := handler.NewReconciliationController(reconciliationService, logger.Log)
+	webhookController := handler.NewWebhookController(webhookService, logger.Log)
+	hub := stream.NewHub(cfg.Stream.HistorySize, cfg.Stream.SubscriberBuffer)
+	eventStreamController := handler.NewEventStreamController(userService, hub, cfg.Stream.Heartbeat, logger.Log)
+	worker := queue.NewWorker(jobs, repos.users, repos.transfers, repos.txManager, completions, cfg.Worker.ProcessingDelay, logger.Log)
+	checker := health.NewChecker(cfg.Health.CheckTimeout)
+	checker.AddLiveness("workers", health.WorkerHeartbeat(worker, cfg.Health.MaxJobDuration))
+	if repos.db != nil {
+		checker.AddReadiness("database", health.Ping(repos.db))
+	}
+	if cfg.Health.MaxQueueDepth > 0 {
+		checker.AddReadiness("queue", health.QueueBacklog(jobs.Depth, cfg.Health.MaxQueueDepth))
+	}
+	healthController := handler.NewHealthController(checker, logger.Log)
+	auditService := service.NewAuditService(repos.audit, logger.Log)
+	logLevelController := handler.NewLogLevelController(logger.Level, auditService, logger.Log)
+	authenticator := middleware.NewAuthenticator(apiKeyService, logger.Log)
+	rateLimiter := api.NewDefaultRateLimiter(logger.Log)
+
+	router := api.InitRouter(transferController, userController, apiKeyController, reconciliationController, webhookController, eventStreamController, healthController, logLevelController, authenticator, rateLimiter, registry, logger.Log)
 
-	router := api.InitRouter(transferController, userController)
+	eventSink, err := openSink(cfg.Outbox)
+	if err != nil {
+		log.Fatal("failed to open event sink:", err)
+	}
+	// Webhook deliveries are queued ahead of the configured sink; open event
+	// streams are fed last.
+	sink := outbox.NewMultiSink(webhook.NewFanout(repos.webhooks, repos.deliveries, repos.apiKeys), eventSink, hub)
+	defer sink.Close()
+
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	relay := outbox.NewRelay(repos.outbox, repos.txManager, sink, cfg.Outbox.BatchSize, cfg.Outbox.PollInterval, cfg.Outbox.ClaimTimeout, logger.Log)
+	go func() {
+		defer close(relayDone)
+		if err := relay.Run(relayCtx); err != nil {
+			logger.Log.Error("outbox relay stopped", "error", err)
+		}
+	}()
+
+	dispatcherCtx, stopDispatcher := context.WithCancel(context.Background())
+	dispatcherDone := make(chan struct{})
+	dispatcher := webhook.NewDispatcher(repos.webhooks, repos.deliveries, cfg.Webhooks, logger.Log)
+	go func() {
+		defer close(dispatcherDone)
+		if err := dispatcher.Run(dispatcherCtx); err != nil {
+			logger.Log.Error("webhook dispatcher stopped", "error", err)
+		}
+	}()
 
-	queue.StartWorker(userRepo, transferRepo, logger.Log)
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	for i := 0; i < cfg.Worker.Count; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			if err := worker.Run(workerCtx); err != nil {
+				logger.Log.Error("worker stopped", "error", err)
+			}
+		}()
+	}
 
 	/** Graceful shutdown
 	/- syscall.SIGTERM (kill -15, the default signal for docker stop)
@@ -63,10 +216,17 @@ func main() {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	port := strconv.Itoa(cfg.Server.Port)
 	httpServer := &http.Server{
-		Addr:    ":" + port,
-		Handler: router,
+		Addr:         ":" + port,
+		Handler:      router,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
+	// Shutdown waits for handlers to return, which event streams never do on
+	// their own.
+	httpServer.RegisterOnShutdown(func() { hub.Close() })
 	go func() {
 		logger.Log.Info("HTTP server started on port: " + port)
 		if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
@@ -74,15 +234,75 @@ func main() {
 		}
 	}()
 
+	// The gRPC API shares the services, authentication, rate limits and
+	// metrics registry of the REST API.
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCPort > 0 {
+		grpcPort := strconv.Itoa(cfg.Server.GRPCPort)
+		listener, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			log.Fatal("failed to listen for gRPC:", err)
+		}
+		rpcServer := rpc.NewServer(transferService, userService, cfg.Server.MaxTransferWait, logger.Log)
+		grpcServer = rpc.NewGRPCServer(rpcServer, authenticator, rateLimiter, metrics.NewGRPCMetrics(registry), logger.Log)
+		go func() {
+			logger.Log.Info("gRPC server started on port: " + grpcPort)
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatalf("gRPC server failed: %v", err)
+			}
+		}()
+	}
+
 	<-sigChan // wait till we get a signal from the channel (CTRL + C, docker stop, etc.)
 	logger.Log.Info("Received shutdown signal, terminating...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	// Fail readiness first so load balancers stop routing here while the
+	// server still answers.
+	checker.Shutdown()
+	time.Sleep(cfg.Health.ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	if err := httpServer.Shutdown(ctx); err != nil {
 		log.Fatalf("Graceful shutdown failed: %v", err)
 	}
+	if grpcServer != nil {
+		stopGRPC(ctx, grpcServer)
+	}
+
+	// No more jobs can be published; let the workers finish the job in hand.
+	stopWorkers()
+	workers.Wait()
+
+	// The relay makes a last pass for the events of the final jobs.
+	stopRelay()
+	<-relayDone
+
+	// Deliveries in flight are abandoned and retried on the next start.
+	stopDispatcher()
+	<-dispatcherDone
+
+	// Flush the spans of the final requests and jobs.
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Log.Error("failed to flush traces", "error", err)
+	}
 
 	logger.Log.Info("Server shutdown gracefully")
 }
+
+// stopGRPC lets calls in progress finish, like http.Server.Shutdown, and
+// cancels those still running when ctx is done, such as WatchTransfer streams.
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
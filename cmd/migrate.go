@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/migrate"
+	"moneyTransfer/migrations"
+	"moneyTransfer/pkg/logger"
+	"os"
+)
+
+const migrateUsage = "usage: server migrate up | down [-steps n] | status"
+
+// runMigrate handles `migrate up`, `migrate down [-steps n]` and
+// `migrate status`. Status is printed as JSON.
+func runMigrate(cfg config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	steps := 1
+	if args[0] == "down" {
+		flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		flags.IntVar(&steps, "steps", 1, "number of migrations to revert")
+		if err := flags.Parse(args[1:]); err != nil || steps < 1 {
+			fmt.Fprintln(os.Stderr, migrateUsage)
+			return 2
+		}
+	} else if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	repos, err := openStorage(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer repos.close()
+
+	migrator := repos.migrator
+	if migrator == nil {
+		fmt.Fprintf(os.Stderr, "storage driver %q has no migrations\n", cfg.Storage.Driver)
+		return 2
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "migrate up failed:", err)
+			return 1
+		}
+		fmt.Printf("applied %d migration(s)\n", len(applied))
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "migrate down failed:", err)
+			return 1
+		}
+		fmt.Printf("reverted %d migration(s)\n", len(reverted))
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "migrate status failed:", err)
+			return 1
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(statuses)
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	return 0
+}
+
+func newMigrator(db *sql.DB) (*migrate.Migrator, error) {
+	all, err := migrate.Load(migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewMigrator(db, all, logger.Log), nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/queue"
+	"moneyTransfer/pkg/logger"
+)
+
+// openQueue connects the transport selected by queue.driver. The postgres
+// driver shares the storage database.
+func openQueue(ctx context.Context, cfg config.QueueConfig, store storage) (queue.Queue, error) {
+	switch cfg.Driver {
+	case config.QueuePostgres:
+		return queue.NewPostgresQueue(store.db, cfg.Size, cfg.PollInterval, cfg.VisibilityTimeout, cfg.MaxAttempts, logger.Log), nil
+	case config.QueueNATS:
+		return queue.NewNATSQueue(ctx, cfg.NATS.URL, cfg.NATS.Stream, cfg.Size, cfg.VisibilityTimeout, cfg.MaxAttempts, logger.Log)
+	default:
+		return queue.NewChannelQueue(cfg.Size, cfg.EnqueueTimeout), nil
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/pkg/logger"
+	"os"
+)
+
+// runReconcile handles `reconcile [-o file]`. The JSON report goes to stdout
+// unless -o is given; the exit code is 1 when the books do not balance.
+func runReconcile(cfg config.Config, args []string) int {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	output := flags.String("o", "", "write the JSON report to this file instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	repos, err := openStorage(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	defer repos.close()
+
+	reconciliationService := service.NewReconciliationService(repos.ledger, logger.Log)
+
+	report, err := reconciliationService.Reconcile(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "reconciliation failed:", err)
+		return 2
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "failed to create report file:", err)
+			return 2
+		}
+		defer file.Close()
+		out = file
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to write report:", err)
+		return 2
+	}
+
+	if !report.Balanced {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/migrate"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/internal/repository/memory"
+	"moneyTransfer/internal/repository/postgres"
+	"moneyTransfer/internal/repository/sqlite"
+	"moneyTransfer/pkg/logger"
+)
+
+type repositories struct {
+	transfers  contracts.TransferRepository
+	users      contracts.UserRepository
+	apiKeys    contracts.APIKeyRepository
+	audit      contracts.AuditRepository
+	ledger     contracts.LedgerRepository
+	outbox     contracts.OutboxRepository
+	webhooks   contracts.WebhookRepository
+	deliveries contracts.WebhookDeliveryRepository
+	txManager  contracts.TxManager
+}
+
+// storage is the repository backend selected by storage.driver.
+type storage struct {
+	repositories
+	// db and migrator are nil for backends without a database.
+	db       *sql.DB
+	migrator *migrate.Migrator
+	close    func()
+}
+
+func openStorage(cfg config.Config) (storage, error) {
+	switch cfg.Storage.Driver {
+	case config.StorageMemory:
+		store := memory.NewStore()
+		if err := memory.SeedDemoData(store); err != nil {
+			return storage{}, fmt.Errorf("failed to seed memory storage: %w", err)
+		}
+		return storage{repositories: newMemoryRepositories(store), close: func() {}}, nil
+	case config.StorageSQLite:
+		db, err := sqlite.NewSQLiteClient(cfg.Storage.SQLite)
+		if err != nil {
+			return storage{}, fmt.Errorf("failed to open sqlite database: %w", err)
+		}
+		migrator, err := sqlite.NewMigrator(db, logger.Log)
+		if err != nil {
+			db.Close()
+			return storage{}, fmt.Errorf("failed to load migrations: %w", err)
+		}
+		// SQLite transactions take the write lock up front, so the audit chain,
+		// the users and the delivery claims need no row or advisory locks.
+		repos := newRepositories(db)
+		repos.users = repository.NewLocklessUserRepository(db)
+		repos.audit = repository.NewLocklessAuditRepository(db)
+		repos.deliveries = repository.NewLocklessWebhookDeliveryRepository(db)
+		return storage{repositories: decorate(repos), db: db, migrator: migrator, close: func() { db.Close() }}, nil
+	default:
+		db, err := postgres.NewPostgresClient(cfg.Database)
+		if err != nil {
+			return storage{}, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		migrator, err := newMigrator(db)
+		if err != nil {
+			db.Close()
+			return storage{}, fmt.Errorf("failed to load migrations: %w", err)
+		}
+		return storage{repositories: decorate(newRepositories(db)), db: db, migrator: migrator, close: func() { db.Close() }}, nil
+	}
+}
+
+// newRepositories creates the SQL repositories, with the Postgres locking
+// variants, before decoration.
+func newRepositories(db *sql.DB) repositories {
+	return repositories{
+		transfers:  repository.NewTransferRepository(db),
+		users:      repository.NewUserRepository(db),
+		apiKeys:    repository.NewAPIKeyRepository(db),
+		audit:      repository.NewAuditRepository(db),
+		ledger:     repository.NewLedgerRepository(db),
+		outbox:     repository.NewOutboxRepository(db),
+		webhooks:   repository.NewWebhookRepository(db),
+		deliveries: repository.NewWebhookDeliveryRepository(db),
+		txManager:  repository.NewTxManager(db),
+	}
+}
+
+// newMemoryRepositories wires the in-memory repositories with the same
+// decorators as the SQL backends.
+func newMemoryRepositories(store *memory.Store) repositories {
+	return decorate(repositories{
+		transfers:  memory.NewTransferRepository(store),
+		users:      memory.NewUserRepository(store),
+		apiKeys:    memory.NewAPIKeyRepository(store),
+		audit:      memory.NewAuditRepository(store),
+		ledger:     memory.NewLedgerRepository(store),
+		outbox:     memory.NewOutboxRepository(store),
+		webhooks:   memory.NewWebhookRepository(store),
+		deliveries: memory.NewWebhookDeliveryRepository(store),
+		txManager:  memory.NewTxManager(store),
+	})
+}
+
+// decorate wraps the writes that move money so they emit domain events, and
+// every write that moves money, changes credentials or registers a webhook so
+// it is recorded in the audit log, each in the same transaction as the change.
+func decorate(repos repositories) repositories {
+	transfers := repository.NewEventedTransferRepository(repos.transfers, repos.outbox, repos.txManager)
+	users := repository.NewEventedUserRepository(repos.users, repos.outbox, repos.txManager)
+
+	repos.transfers = repository.NewAuditedTransferRepository(transfers, repos.audit, repos.txManager)
+	repos.users = repository.NewAuditedUserRepository(users, repos.audit, repos.txManager)
+	repos.apiKeys = repository.NewAuditedAPIKeyRepository(repos.apiKeys, repos.audit, repos.txManager)
+	repos.webhooks = repository.NewAuditedWebhookRepository(repos.webhooks, repos.audit, repos.txManager)
+	return repos
+}
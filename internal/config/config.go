@@ -0,0 +1,671 @@
+// Package config loads the service configuration. Values are layered, each
+// source overriding the previous one: built-in defaults, an optional YAML file,
+// environment variables (including an optional .env file) and command-line
+// flags.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
+	"log/slog"
+	"moneyTransfer/pkg/logger"
+	"os"
+	"time"
+)
+
+const redactedValue = "******"
+
+// Storage drivers.
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+	StorageSQLite   = "sqlite"
+)
+
+// Queue drivers.
+const (
+	QueueChannel  = "channel"
+	QueuePostgres = "postgres"
+	QueueNATS     = "nats"
+)
+
+// Outbox sinks.
+const (
+	SinkStdout = "stdout"
+	SinkFile   = "file"
+	SinkBroker = "broker"
+)
+
+// Trace exporters.
+const (
+	TraceExporterNone   = "none"
+	TraceExporterStdout = "stdout"
+	TraceExporterOTLP   = "otlp"
+)
+
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Storage  StorageConfig  `yaml:"storage"`
+	Database DatabaseConfig `yaml:"database"`
+	Worker   WorkerConfig   `yaml:"worker"`
+	Queue    QueueConfig    `yaml:"queue"`
+	Outbox   OutboxConfig   `yaml:"outbox"`
+	Webhooks WebhookConfig  `yaml:"webhooks"`
+	Stream   StreamConfig   `yaml:"stream"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+	Health   HealthConfig   `yaml:"health"`
+	Log      LogConfig      `yaml:"log"`
+	Auth     AuthConfig     `yaml:"auth"`
+}
+
+type ServerConfig struct {
+	Port int `yaml:"port"`
+	// GRPCPort serves the gRPC API; 0 disables it.
+	GRPCPort        int           `yaml:"grpc_port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// MaxTransferWait caps the wait parameter of POST /transfers. It must be
+	// shorter than WriteTimeout so the response can still be written.
+	MaxTransferWait time.Duration `yaml:"max_transfer_wait"`
+}
+
+type StorageConfig struct {
+	// Driver selects the repository backend: postgres, sqlite, or memory for
+	// demos and tests (seeded with demo data, lost on exit).
+	Driver string       `yaml:"driver"`
+	SQLite SQLiteConfig `yaml:"sqlite"`
+}
+
+type SQLiteConfig struct {
+	// Path is the database file, created on first use.
+	Path        string        `yaml:"path"`
+	BusyTimeout time.Duration `yaml:"busy_timeout"`
+}
+
+type DatabaseConfig struct {
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	Name            string        `yaml:"name"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	SSLMode         string        `yaml:"sslmode"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	AutoMigrate     bool          `yaml:"auto_migrate"`
+}
+
+type WorkerConfig struct {
+	Count int `yaml:"count"`
+	// ProcessingDelay emulates slow downstream processing after each job.
+	ProcessingDelay time.Duration `yaml:"processing_delay"`
+}
+
+type QueueConfig struct {
+	// Driver selects the transport: channel (in-process), postgres (the
+	// transfer_jobs table) or nats (a JetStream stream).
+	Driver string `yaml:"driver"`
+	// Size is how many jobs may wait in the queue before publishing fails
+	// with ErrQueueFull, whichever the driver.
+	Size           int           `yaml:"size"`
+	EnqueueTimeout time.Duration `yaml:"enqueue_timeout"`
+	// PollInterval is how often the postgres driver looks for new jobs.
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// VisibilityTimeout is how long a delivered job may stay unacknowledged
+	// before the postgres and nats drivers redeliver it.
+	VisibilityTimeout time.Duration `yaml:"visibility_timeout"`
+	// MaxAttempts caps the deliveries of a job on the postgres and nats
+	// drivers; a job that fails them all is dead-lettered.
+	MaxAttempts int        `yaml:"max_attempts"`
+	NATS        NATSConfig `yaml:"nats"`
+}
+
+type NATSConfig struct {
+	URL    string `yaml:"url"`
+	Stream string `yaml:"stream"`
+}
+
+type OutboxConfig struct {
+	// Sink receives the relayed domain events: stdout, file (NDJSON at
+	// FilePath) or broker, an in-process stand-in for a message broker.
+	Sink         string        `yaml:"sink"`
+	FilePath     string        `yaml:"file_path"`
+	BatchSize    int           `yaml:"batch_size"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// ClaimTimeout is how long a relay holds the accounts it claimed before
+	// another instance may take them over.
+	ClaimTimeout time.Duration `yaml:"claim_timeout"`
+}
+
+type WebhookConfig struct {
+	// Timeout bounds each delivery attempt, including reading the response.
+	Timeout     time.Duration `yaml:"timeout"`
+	MaxAttempts int           `yaml:"max_attempts"`
+	// Failed attempts are retried after InitialBackoff, doubling each time up
+	// to MaxBackoff.
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	BatchSize      int           `yaml:"batch_size"`
+	PollInterval   time.Duration `yaml:"poll_interval"`
+	// AllowedHosts may receive webhooks even though they resolve to loopback
+	// or private addresses, e.g. localhost in development.
+	AllowedHosts []string `yaml:"allowed_hosts"`
+}
+
+type StreamConfig struct {
+	// Heartbeat is how often an idle event stream is pinged so proxies keep
+	// it open and dead clients are noticed.
+	Heartbeat time.Duration `yaml:"heartbeat"`
+	// HistorySize is how many recent events are kept for clients resuming
+	// with Last-Event-ID.
+	HistorySize int `yaml:"history_size"`
+	// SubscriberBuffer is how many events a client may fall behind before it
+	// is disconnected.
+	SubscriberBuffer int `yaml:"subscriber_buffer"`
+}
+
+type TracingConfig struct {
+	// Exporter selects where spans go: none, stdout (pretty-printed JSON,
+	// for local debugging) or otlp (OTLP over HTTP to Endpoint).
+	Exporter string `yaml:"exporter"`
+	// Endpoint is the collector's host:port.
+	Endpoint string `yaml:"endpoint"`
+	// Insecure sends OTLP over plain HTTP.
+	Insecure    bool   `yaml:"insecure"`
+	ServiceName string `yaml:"service_name"`
+	// SampleRatio is the fraction of new traces recorded; traces started by
+	// a caller follow the caller's sampling decision.
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type HealthConfig struct {
+	// CheckTimeout bounds each run of the liveness or readiness checks.
+	CheckTimeout time.Duration `yaml:"check_timeout"`
+	// MaxQueueDepth makes readiness fail while more jobs are waiting; 0
+	// disables the check.
+	MaxQueueDepth int `yaml:"max_queue_depth"`
+	// MaxJobDuration makes liveness fail when a worker has been on one job
+	// for longer, including the processing delay.
+	MaxJobDuration time.Duration `yaml:"max_job_duration"`
+	// ShutdownDelay is how long readiness reports shutting_down before the
+	// server stops accepting connections, giving load balancers time to
+	// notice.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level"`
+	// Format is json or text.
+	Format   string            `yaml:"format"`
+	File     LogFileConfig     `yaml:"file"`
+	Sampling LogSamplingConfig `yaml:"sampling"`
+	Redact   RedactConfig      `yaml:"redact"`
+}
+
+// LogFileConfig adds a file the logs are also written to, rotated by size.
+type LogFileConfig struct {
+	// Path enables the file sink when set.
+	Path       string `yaml:"path"`
+	MaxSizeMB  int    `yaml:"max_size_mb"`
+	MaxBackups int    `yaml:"max_backups"`
+}
+
+// LogSamplingConfig limits repeated debug and info messages: within each
+// interval the first `first` lines with the same message are written, then
+// every `thereafter`-th. A zero interval disables sampling.
+type LogSamplingConfig struct {
+	Interval   time.Duration `yaml:"interval"`
+	First      int           `yaml:"first"`
+	Thereafter int           `yaml:"thereafter"`
+}
+
+// RedactConfig says how personal data is written to the logs: kept as is,
+// masked, or replaced by a short hash that still lets lines about the same
+// value be matched.
+type RedactConfig struct {
+	Email  string `yaml:"email"`
+	Name   string `yaml:"name"`
+	Amount string `yaml:"amount"`
+	// HashKey keys the hashes so they cannot be reversed by guessing.
+	HashKey string `yaml:"hash_key"`
+}
+
+type AuthConfig struct {
+	// AdminAPIKey registers a bootstrap admin key at startup when set.
+	AdminAPIKey string `yaml:"admin_api_key"`
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            8080,
+			GRPCPort:        50051,
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+			MaxTransferWait: 10 * time.Second,
+		},
+		Storage: StorageConfig{
+			Driver: StoragePostgres,
+			SQLite: SQLiteConfig{
+				Path:        "money_transfer.db",
+				BusyTimeout: 5 * time.Second,
+			},
+		},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			Name:            "money_transfer",
+			User:            "postgres",
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Worker: WorkerConfig{
+			Count:           1,
+			ProcessingDelay: 500 * time.Millisecond,
+		},
+		Queue: QueueConfig{
+			Driver:            QueueChannel,
+			Size:              100,
+			EnqueueTimeout:    100 * time.Millisecond,
+			PollInterval:      time.Second,
+			VisibilityTimeout: 30 * time.Second,
+			MaxAttempts:       5,
+			NATS: NATSConfig{
+				URL:    "nats://localhost:4222",
+				Stream: "TRANSFERS",
+			},
+		},
+		Outbox: OutboxConfig{
+			Sink:         SinkBroker,
+			FilePath:     "events.ndjson",
+			BatchSize:    100,
+			PollInterval: 500 * time.Millisecond,
+			ClaimTimeout: 30 * time.Second,
+		},
+		Webhooks: WebhookConfig{
+			Timeout:        5 * time.Second,
+			MaxAttempts:    8,
+			InitialBackoff: 10 * time.Second,
+			MaxBackoff:     time.Hour,
+			BatchSize:      20,
+			PollInterval:   time.Second,
+		},
+		Stream: StreamConfig{
+			Heartbeat:        15 * time.Second,
+			HistorySize:      1000,
+			SubscriberBuffer: 64,
+		},
+		Tracing: TracingConfig{
+			Exporter:    TraceExporterNone,
+			Endpoint:    "localhost:4318",
+			Insecure:    true,
+			ServiceName: "money-transfer",
+			SampleRatio: 1,
+		},
+		Health: HealthConfig{
+			CheckTimeout:   2 * time.Second,
+			MaxQueueDepth:  1000,
+			MaxJobDuration: time.Minute,
+		},
+		Log: LogConfig{
+			Level:    "info",
+			Format:   logger.FormatJSON,
+			File:     LogFileConfig{MaxSizeMB: 100, MaxBackups: 3},
+			Sampling: LogSamplingConfig{First: 100, Thereafter: 100},
+			Redact:   RedactConfig{Email: logger.RedactHash, Name: logger.RedactMask, Amount: logger.RedactMask},
+		},
+	}
+}
+
+// Load builds the configuration from all sources. args are the serve flags;
+// -config (or CONFIG_FILE) names the YAML file.
+func Load(args []string) (Config, error) {
+	// A missing .env is normal in containers that get real environment variables.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("failed to load .env: %w", err)
+	}
+
+	flags := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file")
+	port := flags.Int("port", 0, "HTTP port")
+	grpcPort := flags.Int("grpc-port", 0, "gRPC port, 0 to disable")
+	storage := flags.String("storage", "", "storage driver: postgres, sqlite or memory")
+	queueDriver := flags.String("queue", "", "queue driver: channel, postgres or nats")
+	logLevel := flags.String("log-level", "", "log level: debug, info, warn or error")
+	logFormat := flags.String("log-format", "", "log format: json or text")
+	workers := flags.Int("workers", 0, "number of transfer workers")
+	autoMigrate := flags.Bool("auto-migrate", false, "apply pending migrations on startup")
+	traceExporter := flags.String("trace-exporter", "", "trace exporter: none, stdout or otlp")
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+
+	cfg := Default()
+
+	if *configFile != "" {
+		if err := cfg.loadFile(*configFile); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return Config{}, err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			cfg.Server.Port = *port
+		case "grpc-port":
+			cfg.Server.GRPCPort = *grpcPort
+		case "storage":
+			cfg.Storage.Driver = *storage
+		case "queue":
+			cfg.Queue.Driver = *queueDriver
+		case "log-level":
+			cfg.Log.Level = *logLevel
+		case "log-format":
+			cfg.Log.Format = *logFormat
+		case "workers":
+			cfg.Worker.Count = *workers
+		case "auto-migrate":
+			cfg.Database.AutoMigrate = *autoMigrate
+		case "trace-exporter":
+			cfg.Tracing.Exporter = *traceExporter
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	// An empty file decodes to io.EOF and leaves the defaults in place.
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	env := envReader{}
+
+	env.int("SERVER_PORT", &c.Server.Port)
+	env.int("SERVER_GRPC_PORT", &c.Server.GRPCPort)
+	env.duration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	env.duration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	env.duration("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	env.duration("SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	env.duration("SERVER_MAX_TRANSFER_WAIT", &c.Server.MaxTransferWait)
+
+	env.string("STORAGE_DRIVER", &c.Storage.Driver)
+	env.string("SQLITE_PATH", &c.Storage.SQLite.Path)
+	env.duration("SQLITE_BUSY_TIMEOUT", &c.Storage.SQLite.BusyTimeout)
+
+	env.string("POSTGRES_HOST", &c.Database.Host)
+	env.int("POSTGRES_PORT", &c.Database.Port)
+	env.string("POSTGRES_DB", &c.Database.Name)
+	env.string("POSTGRES_USER", &c.Database.User)
+	env.string("POSTGRES_PASSWORD", &c.Database.Password)
+	env.string("POSTGRES_SSLMODE", &c.Database.SSLMode)
+	env.int("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	env.int("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	env.duration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	env.duration("DB_CONN_MAX_IDLE_TIME", &c.Database.ConnMaxIdleTime)
+	env.bool("AUTO_MIGRATE", &c.Database.AutoMigrate)
+
+	env.int("WORKER_COUNT", &c.Worker.Count)
+	env.duration("WORKER_PROCESSING_DELAY", &c.Worker.ProcessingDelay)
+	env.string("QUEUE_DRIVER", &c.Queue.Driver)
+	env.int("QUEUE_SIZE", &c.Queue.Size)
+	env.duration("QUEUE_ENQUEUE_TIMEOUT", &c.Queue.EnqueueTimeout)
+	env.duration("QUEUE_POLL_INTERVAL", &c.Queue.PollInterval)
+	env.duration("QUEUE_VISIBILITY_TIMEOUT", &c.Queue.VisibilityTimeout)
+	env.int("QUEUE_MAX_ATTEMPTS", &c.Queue.MaxAttempts)
+	env.string("NATS_URL", &c.Queue.NATS.URL)
+	env.string("NATS_STREAM", &c.Queue.NATS.Stream)
+
+	env.string("OUTBOX_SINK", &c.Outbox.Sink)
+	env.string("OUTBOX_FILE", &c.Outbox.FilePath)
+	env.int("OUTBOX_BATCH_SIZE", &c.Outbox.BatchSize)
+	env.duration("OUTBOX_POLL_INTERVAL", &c.Outbox.PollInterval)
+	env.duration("OUTBOX_CLAIM_TIMEOUT", &c.Outbox.ClaimTimeout)
+
+	env.duration("WEBHOOK_TIMEOUT", &c.Webhooks.Timeout)
+	env.int("WEBHOOK_MAX_ATTEMPTS", &c.Webhooks.MaxAttempts)
+	env.duration("WEBHOOK_INITIAL_BACKOFF", &c.Webhooks.InitialBackoff)
+	env.duration("WEBHOOK_MAX_BACKOFF", &c.Webhooks.MaxBackoff)
+	env.int("WEBHOOK_BATCH_SIZE", &c.Webhooks.BatchSize)
+	env.duration("WEBHOOK_POLL_INTERVAL", &c.Webhooks.PollInterval)
+	env.list("WEBHOOK_ALLOWED_HOSTS", &c.Webhooks.AllowedHosts)
+
+	env.duration("STREAM_HEARTBEAT", &c.Stream.Heartbeat)
+	env.int("STREAM_HISTORY_SIZE", &c.Stream.HistorySize)
+	env.int("STREAM_SUBSCRIBER_BUFFER", &c.Stream.SubscriberBuffer)
+
+	env.string("TRACING_EXPORTER", &c.Tracing.Exporter)
+	env.string("TRACING_ENDPOINT", &c.Tracing.Endpoint)
+	env.bool("TRACING_INSECURE", &c.Tracing.Insecure)
+	env.string("TRACING_SERVICE_NAME", &c.Tracing.ServiceName)
+	env.float("TRACING_SAMPLE_RATIO", &c.Tracing.SampleRatio)
+
+	env.duration("HEALTH_CHECK_TIMEOUT", &c.Health.CheckTimeout)
+	env.int("HEALTH_MAX_QUEUE_DEPTH", &c.Health.MaxQueueDepth)
+	env.duration("HEALTH_MAX_JOB_DURATION", &c.Health.MaxJobDuration)
+	env.duration("HEALTH_SHUTDOWN_DELAY", &c.Health.ShutdownDelay)
+
+	env.string("LOG_LEVEL", &c.Log.Level)
+	env.string("LOG_FORMAT", &c.Log.Format)
+	env.string("LOG_FILE", &c.Log.File.Path)
+	env.int("LOG_FILE_MAX_SIZE_MB", &c.Log.File.MaxSizeMB)
+	env.int("LOG_FILE_MAX_BACKUPS", &c.Log.File.MaxBackups)
+	env.duration("LOG_SAMPLING_INTERVAL", &c.Log.Sampling.Interval)
+	env.int("LOG_SAMPLING_FIRST", &c.Log.Sampling.First)
+	env.int("LOG_SAMPLING_THEREAFTER", &c.Log.Sampling.Thereafter)
+	env.string("LOG_REDACT_EMAIL", &c.Log.Redact.Email)
+	env.string("LOG_REDACT_NAME", &c.Log.Redact.Name)
+	env.string("LOG_REDACT_AMOUNT", &c.Log.Redact.Amount)
+	env.string("LOG_REDACT_HASH_KEY", &c.Log.Redact.HashKey)
+	env.string("ADMIN_API_KEY", &c.Auth.AdminAPIKey)
+
+	return errors.Join(env.errs...)
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.Server.GRPCPort >= 0 && c.Server.GRPCPort <= 65535, "server.grpc_port must be between 0 and 65535, got %d", c.Server.GRPCPort)
+	check(c.Server.GRPCPort != c.Server.Port, "server.grpc_port must differ from server.port")
+	check(c.Server.ReadTimeout > 0, "server.read_timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server.write_timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server.idle_timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(c.Server.MaxTransferWait > 0 && c.Server.MaxTransferWait < c.Server.WriteTimeout,
+		"server.max_transfer_wait must be positive and shorter than server.write_timeout")
+
+	check(c.Storage.Driver == StoragePostgres || c.Storage.Driver == StorageSQLite || c.Storage.Driver == StorageMemory,
+		"storage.driver must be one of postgres, sqlite, memory, got %q", c.Storage.Driver)
+
+	if c.Storage.Driver == StorageSQLite {
+		check(c.Storage.SQLite.Path != "", "storage.sqlite.path is required")
+		check(c.Storage.SQLite.BusyTimeout >= 0, "storage.sqlite.busy_timeout must not be negative")
+	}
+
+	// The database settings only matter when Postgres is used.
+	if c.Storage.Driver == StoragePostgres {
+		check(c.Database.Host != "", "database.host is required")
+		check(c.Database.Port > 0 && c.Database.Port <= 65535, "database.port must be between 1 and 65535, got %d", c.Database.Port)
+		check(c.Database.Name != "", "database.name is required")
+		check(c.Database.User != "", "database.user is required")
+		check(c.Database.MaxOpenConns > 0, "database.max_open_conns must be positive")
+		check(c.Database.MaxIdleConns >= 0 && c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+			"database.max_idle_conns must be between 0 and max_open_conns")
+		check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime must not be negative")
+		check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time must not be negative")
+	}
+
+	check(c.Worker.Count > 0, "worker.count must be positive")
+	check(c.Worker.ProcessingDelay >= 0, "worker.processing_delay must not be negative")
+	check(c.Queue.Size > 0, "queue.size must be positive")
+	check(c.Queue.EnqueueTimeout > 0, "queue.enqueue_timeout must be positive")
+	switch c.Queue.Driver {
+	case QueueChannel:
+	case QueuePostgres:
+		check(c.Storage.Driver == StoragePostgres, "queue.driver postgres needs storage.driver postgres, got %q", c.Storage.Driver)
+		check(c.Queue.PollInterval > 0, "queue.poll_interval must be positive")
+		check(c.Queue.VisibilityTimeout > 0, "queue.visibility_timeout must be positive")
+		check(c.Queue.MaxAttempts > 0, "queue.max_attempts must be positive")
+	case QueueNATS:
+		check(c.Queue.NATS.URL != "", "queue.nats.url is required")
+		check(c.Queue.NATS.Stream != "", "queue.nats.stream is required")
+		check(c.Queue.VisibilityTimeout > 0, "queue.visibility_timeout must be positive")
+		check(c.Queue.MaxAttempts > 0, "queue.max_attempts must be positive")
+	default:
+		check(false, "queue.driver must be one of channel, postgres, nats, got %q", c.Queue.Driver)
+	}
+
+	check(c.Outbox.Sink == SinkStdout || c.Outbox.Sink == SinkFile || c.Outbox.Sink == SinkBroker,
+		"outbox.sink must be one of stdout, file, broker, got %q", c.Outbox.Sink)
+	check(c.Outbox.Sink != SinkFile || c.Outbox.FilePath != "", "outbox.file_path is required for the file sink")
+	check(c.Outbox.BatchSize > 0, "outbox.batch_size must be positive")
+	check(c.Outbox.PollInterval > 0, "outbox.poll_interval must be positive")
+	check(c.Outbox.ClaimTimeout > 0, "outbox.claim_timeout must be positive")
+
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout must be positive")
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts must be positive")
+	check(c.Webhooks.InitialBackoff > 0, "webhooks.initial_backoff must be positive")
+	check(c.Webhooks.MaxBackoff >= c.Webhooks.InitialBackoff, "webhooks.max_backoff must not be less than initial_backoff")
+	check(c.Webhooks.BatchSize > 0, "webhooks.batch_size must be positive")
+	check(c.Webhooks.PollInterval > 0, "webhooks.poll_interval must be positive")
+
+	check(c.Stream.Heartbeat > 0, "stream.heartbeat must be positive")
+	check(c.Stream.HistorySize > 0, "stream.history_size must be positive")
+	check(c.Stream.SubscriberBuffer > 0, "stream.subscriber_buffer must be positive")
+
+	check(c.Tracing.Exporter == TraceExporterNone || c.Tracing.Exporter == TraceExporterStdout || c.Tracing.Exporter == TraceExporterOTLP,
+		"tracing.exporter must be one of none, stdout, otlp, got %q", c.Tracing.Exporter)
+	check(c.Tracing.Exporter != TraceExporterOTLP || c.Tracing.Endpoint != "", "tracing.endpoint is required for the otlp exporter")
+	check(c.Tracing.ServiceName != "", "tracing.service_name is required")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	check(c.Health.CheckTimeout > 0, "health.check_timeout must be positive")
+	check(c.Health.MaxQueueDepth >= 0, "health.max_queue_depth must not be negative")
+	check(c.Health.MaxJobDuration > c.Worker.ProcessingDelay, "health.max_job_duration must be longer than worker.processing_delay")
+	check(c.Health.ShutdownDelay >= 0, "health.shutdown_delay must not be negative")
+
+	_, err := c.Log.SlogLevel()
+	check(err == nil, "log.level must be one of debug, info, warn, error, got %q", c.Log.Level)
+	check(c.Log.Format == logger.FormatJSON || c.Log.Format == logger.FormatText, "log.format must be json or text, got %q", c.Log.Format)
+	check(c.Log.File.MaxSizeMB >= 0, "log.file.max_size_mb must not be negative")
+	check(c.Log.File.MaxBackups >= 0, "log.file.max_backups must not be negative")
+	check(c.Log.Sampling.Interval >= 0, "log.sampling.interval must not be negative")
+	check(c.Log.Sampling.First >= 0 && c.Log.Sampling.Thereafter >= 0, "log.sampling.first and log.sampling.thereafter must not be negative")
+	check(validRedactAction(c.Log.Redact.Email), "log.redact.email must be one of keep, mask, hash, got %q", c.Log.Redact.Email)
+	check(validRedactAction(c.Log.Redact.Name), "log.redact.name must be one of keep, mask, hash, got %q", c.Log.Redact.Name)
+	check(validRedactAction(c.Log.Redact.Amount), "log.redact.amount must be one of keep, mask, hash, got %q", c.Log.Redact.Amount)
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return nil
+}
+
+func (l LogConfig) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(l.Level))
+	return level, err
+}
+
+// Options is the logger's view of the log settings.
+func (l LogConfig) Options() (logger.Options, error) {
+	level, err := l.SlogLevel()
+	if err != nil {
+		return logger.Options{}, err
+	}
+	return logger.Options{
+		Level:     level,
+		Format:    l.Format,
+		File:      logger.FileOptions{Path: l.File.Path, MaxSizeMB: l.File.MaxSizeMB, MaxBackups: l.File.MaxBackups},
+		Sampling:  logger.Sampling{Interval: l.Sampling.Interval, First: l.Sampling.First, Thereafter: l.Sampling.Thereafter},
+		Redaction: l.Redaction(),
+	}, nil
+}
+
+// Redaction is the logger's view of the redact settings.
+func (l LogConfig) Redaction() logger.Redaction {
+	return logger.Redaction{
+		Actions: map[string]string{
+			logger.ClassEmail:  l.Redact.Email,
+			logger.ClassName:   l.Redact.Name,
+			logger.ClassAmount: l.Redact.Amount,
+		},
+		HashKey: l.Redact.HashKey,
+	}
+}
+
+func validRedactAction(action string) bool {
+	switch action {
+	case logger.RedactKeep, logger.RedactMask, logger.RedactHash:
+		return true
+	}
+	return false
+}
+
+// DSN is the lib/pq connection string.
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		d.Host, d.Port, d.User, d.Password, d.Name, d.SSLMode)
+}
+
+// Redacted returns a copy safe to print, with secrets masked.
+func (c Config) Redacted() Config {
+	if c.Database.Password != "" {
+		c.Database.Password = redactedValue
+	}
+	if c.Log.Redact.HashKey != "" {
+		c.Log.Redact.HashKey = redactedValue
+	}
+	if c.Auth.AdminAPIKey != "" {
+		c.Auth.AdminAPIKey = redactedValue
+	}
+	return c
+}
+
+// Dump renders the redacted configuration as YAML.
+func (c Config) Dump() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("failed to render configuration: %v", err)
+	}
+	return string(out)
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envReader overrides fields from environment variables that are set and
+// collects parse errors instead of stopping at the first one.
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) string(name string, dst *string) {
+	if value, ok := os.LookupEnv(name); ok {
+		*dst = value
+	}
+}
+
+func (e *envReader) int(name string, dst *int) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %q is not an integer", name, value))
+		return
+	}
+	*dst = parsed
+}
+
+func (e *envReader) bool(name string, dst *bool) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %q is not a boolean", name, value))
+		return
+	}
+	*dst = parsed
+}
+
+func (e *envReader) float(name string, dst *float64) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %q is not a number", name, value))
+		return
+	}
+	*dst = parsed
+}
+
+func (e *envReader) duration(name string, dst *time.Duration) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %q is not a duration", name, value))
+		return
+	}
+	*dst = parsed
+}
+
+// list reads a comma-separated value; an empty variable clears the list.
+func (e *envReader) list(name string, dst *[]string) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return
+	}
+	*dst = nil
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*dst = append(*dst, item)
+		}
+	}
+}
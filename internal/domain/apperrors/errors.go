@@ -0,0 +1,177 @@
+// Package apperrors defines the typed errors returned by services and
+// repositories. The handler layer maps each Kind to an HTTP status and the rpc
+// layer to a gRPC code, and Code is a stable identifier clients can branch on.
+package apperrors
+
+import (
+	"errors"
+	"time"
+)
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindValidation
+	KindInsufficientFunds
+	KindConflict
+	KindForbidden
+	KindUnauthorized
+	KindUnavailable
+	KindRateLimited
+)
+
+const (
+	CodeInternal              = "internal_error"
+	CodeInvalidRequestBody    = "invalid_request_body"
+	CodeMissingParameter      = "missing_parameter"
+	CodeValidationFailed      = "validation_failed"
+	CodeInvalidAmount         = "invalid_amount"
+	CodeUserNotFound          = "user_not_found"
+	CodeTransactionNotFound   = "transaction_not_found"
+	CodeTransactionSettled    = "transaction_settled"
+	CodeAPIKeyNotFound        = "api_key_not_found"
+	CodeInvalidAPIKeyRequest  = "invalid_api_key_request"
+	CodeInsufficientFunds     = "insufficient_funds"
+	CodeAuthenticationMissing = "authentication_required"
+	CodeInvalidAPIKey         = "invalid_api_key"
+	CodeAPIKeyRevoked         = "api_key_revoked"
+	CodeInsufficientScope     = "insufficient_scope"
+	CodeRateLimited           = "rate_limited"
+	CodeQueueFull             = "queue_full"
+	CodeWebhookNotFound       = "webhook_not_found"
+	CodeDeliveryNotFound      = "delivery_not_found"
+	CodeInvalidWebhookRequest = "invalid_webhook_request"
+	CodeInvalidLogLevel       = "invalid_log_level"
+	CodeConcurrentUpdate      = "concurrent_update"
+)
+
+// Field-level codes reported in Error.Fields.
+const (
+	CodeRequired        = "required"
+	CodeInvalidUUID     = "invalid_uuid"
+	CodeSelfTransfer    = "self_transfer"
+	CodeTooManyDecimals = "too_many_decimals"
+	CodeAccountNotFound = "account_not_found"
+)
+
+// FieldError describes what is wrong with a single request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	// RetryAfter is a hint for KindUnavailable and KindRateLimited errors.
+	RetryAfter time.Duration
+	Err        error
+}
+
+// Sentinels for errors.Is checks on the kind alone.
+var (
+	ErrNotFound          = &Error{Kind: KindNotFound}
+	ErrValidation        = &Error{Kind: KindValidation}
+	ErrInsufficientFunds = &Error{Kind: KindInsufficientFunds}
+	ErrConflict          = &Error{Kind: KindConflict}
+	ErrForbidden         = &Error{Kind: KindForbidden}
+	ErrUnauthorized      = &Error{Kind: KindUnauthorized}
+	ErrUnavailable       = &Error{Kind: KindUnavailable}
+	ErrRateLimited       = &Error{Kind: KindRateLimited}
+)
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Validation(code, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+func InsufficientFunds(message string) *Error {
+	return New(KindInsufficientFunds, CodeInsufficientFunds, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Unavailable(code, message string, retryAfter time.Duration) *Error {
+	err := New(KindUnavailable, code, message)
+	err.RetryAfter = retryAfter
+	return err
+}
+
+func RateLimited(message string, retryAfter time.Duration) *Error {
+	err := New(KindRateLimited, CodeRateLimited, message)
+	err.RetryAfter = retryAfter
+	return err
+}
+
+// WithCause returns a copy of e wrapping err.
+func (e *Error) WithCause(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// WithFields returns a copy of e carrying field-level details.
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	c := *e
+	c.Fields = fields
+	return &c
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches another *Error of the same kind, and of the same code when the
+// target has one.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Kind == e.Kind && (t.Code == "" || t.Code == e.Code)
+}
+
+// As returns the first *Error in err's chain.
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+func KindOf(err error) Kind {
+	if appErr, ok := As(err); ok {
+		return appErr.Kind
+	}
+	return KindInternal
+}
@@ -3,16 +3,102 @@ package contracts
 import (
 	"context"
 	"moneyTransfer/internal/domain/model"
+	"time"
 )
 
 type TransferRepository interface {
 	GetTransactionsByUserId(ctx context.Context, userId string) ([]model.Transaction, error)
+	GetTransactionById(ctx context.Context, txId string) (model.Transaction, error)
 	CreateTransfer(ctx context.Context, tx model.Transaction) error
-	UpdateTransactionStatus(ctx context.Context, txId, status string) error
+	// FinishTransaction moves a PENDING transaction to its final status. It
+	// fails with ErrConflict when the transaction is no longer pending.
+	FinishTransaction(ctx context.Context, txId, status string) error
+	DeletePendingTransaction(ctx context.Context, txId string) error
 }
 
 type UserRepository interface {
 	GetBalance(ctx context.Context, userId string) (float64, error)
 	GetById(ctx context.Context, userId string) (model.User, error)
 	UpdateBalance(ctx context.Context, userId string, newBalance float64) error
+	// Debit takes amount from the balance atomically and returns the balance
+	// before and after. It fails with ErrInsufficientFunds, changing nothing,
+	// when the balance does not cover amount.
+	Debit(ctx context.Context, userId string, amount float64) (float64, float64, error)
+	// Credit adds amount to the balance atomically and returns the balance
+	// before and after.
+	Credit(ctx context.Context, userId string, amount float64) (float64, float64, error)
+	// Lock holds the users' rows until the transaction ends. The rows are
+	// locked in id order, so transactions locking the same users wait for
+	// each other instead of deadlocking.
+	Lock(ctx context.Context, userIds ...string) error
+}
+
+type LedgerRepository interface {
+	GetAccountLedgers(ctx context.Context) ([]model.AccountLedger, error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key model.APIKey) error
+	GetById(ctx context.Context, id string) (model.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (model.APIKey, error)
+	List(ctx context.Context) ([]model.APIKey, error)
+	// Revoke revokes an active key. It fails with ErrConflict, changing
+	// nothing, when the key is already revoked.
+	Revoke(ctx context.Context, id string, revokedAt time.Time) error
+	UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error
+}
+
+type AuditRepository interface {
+	// Append links entry to the end of the chain and returns it with Id, PrevHash and Hash set.
+	Append(ctx context.Context, entry model.AuditEntry) (model.AuditEntry, error)
+	// List returns up to limit entries with an id greater than afterId, in chain order.
+	List(ctx context.Context, afterId int64, limit int) ([]model.AuditEntry, error)
+}
+
+type OutboxRepository interface {
+	// Append stores event for the relay and returns it with Sequence and
+	// AccountSequence set. It holds the account's sequence until the
+	// transaction ends, so events of one account commit in order.
+	Append(ctx context.Context, event model.DomainEvent) (model.DomainEvent, error)
+	// Claim leases up to limit accounts with unpublished events until
+	// leaseUntil and returns those events, in AccountSequence order per
+	// account. Accounts leased or held back until after now are skipped.
+	Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.DomainEvent, error)
+	// Pending returns up to limit unpublished events in Sequence order.
+	Pending(ctx context.Context, limit int) ([]model.DomainEvent, error)
+	MarkPublished(ctx context.Context, sequence int64, publishedAt time.Time) error
+	// Release ends the lease on an account. Its events can be claimed again
+	// from notBefore.
+	Release(ctx context.Context, accountId string, notBefore time.Time) error
+}
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook model.Webhook) error
+	GetById(ctx context.Context, id string) (model.Webhook, error)
+	List(ctx context.Context) ([]model.Webhook, error)
+	ListByOwner(ctx context.Context, ownerId string) ([]model.Webhook, error)
+	// Reassign moves every webhook of one owner to another.
+	Reassign(ctx context.Context, fromOwnerId, toOwnerId string) error
+	// Delete removes the webhook together with its deliveries.
+	Delete(ctx context.Context, id string) error
+}
+
+type WebhookDeliveryRepository interface {
+	// Create stores delivery unless the webhook already has one for the same
+	// event, so relaying an event twice does not notify twice.
+	Create(ctx context.Context, delivery model.WebhookDelivery) error
+	GetById(ctx context.Context, id string) (model.WebhookDelivery, error)
+	// ListByWebhook returns up to limit deliveries of a webhook, newest first.
+	ListByWebhook(ctx context.Context, webhookId string, limit int) ([]model.WebhookDelivery, error)
+	// Claim returns up to limit pending deliveries due at now and moves their
+	// next attempt to leaseUntil, so other dispatchers skip them meanwhile.
+	Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.WebhookDelivery, error)
+	// Update records the outcome of an attempt or a reset for redelivery.
+	Update(ctx context.Context, delivery model.WebhookDelivery) error
+}
+
+// TxManager runs fn inside a database transaction. Repository calls made with
+// the context passed to fn join that transaction; nested calls reuse it.
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
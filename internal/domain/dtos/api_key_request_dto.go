@@ -0,0 +1,6 @@
+package dtos
+
+type CreateAPIKeyRequestDto struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
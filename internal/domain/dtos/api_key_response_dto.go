@@ -0,0 +1,13 @@
+package dtos
+
+import "moneyTransfer/internal/domain/model"
+
+type APIKeyResponseDto struct {
+	APIKey model.APIKey `json:"api_key"`
+	// Key is the plaintext secret. It is only returned when a key is issued or rotated.
+	Key string `json:"key,omitempty"`
+}
+
+type APIKeyListResponseDto struct {
+	APIKeys []model.APIKey `json:"api_keys"`
+}
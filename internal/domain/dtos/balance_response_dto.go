@@ -1,5 +1,5 @@
 package dtos
 
 type BalanceResponseDto struct {
-	Balance float64 `json:"balance"`
+	Balance float64 `json:"balance" redact:"amount"`
 }
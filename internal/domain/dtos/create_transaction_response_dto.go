@@ -1,9 +1,27 @@
 package dtos
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"moneyTransfer/internal/domain/model"
+)
 
 type CreateTransactionResponseDto struct {
 	TransactionId uuid.UUID `json:"transaction_id"`
 	Status        string    `json:"status"`
 	Message       string    `json:"message"`
 }
+
+// NewCreateTransactionResponse describes a transfer in the given status. The
+// REST and gRPC APIs both answer with it.
+func NewCreateTransactionResponse(id uuid.UUID, status string) CreateTransactionResponseDto {
+	response := CreateTransactionResponseDto{TransactionId: id, Status: status}
+	switch status {
+	case model.StatusSuccess:
+		response.Message = "Transaction was successful"
+	case model.StatusFailed:
+		response.Message = "Transaction failed"
+	default:
+		response.Message = "Transaction is still being processed"
+	}
+	return response
+}
@@ -0,0 +1,5 @@
+package dtos
+
+type LogLevelDto struct {
+	Level string `json:"level" example:"debug"`
+}
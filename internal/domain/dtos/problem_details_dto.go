@@ -0,0 +1,44 @@
+package dtos
+
+import (
+	"encoding/json"
+	"moneyTransfer/internal/domain/apperrors"
+	"net/http"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypePrefix is prepended to the error code to build the problem type URI.
+const ProblemTypePrefix = "urn:moneytransfer:problem:"
+
+// ProblemDetails is an RFC 7807 error body. ErrorCode, CorrelationId and
+// Fields are extension members.
+type ProblemDetails struct {
+	Type          string                 `json:"type" example:"urn:moneytransfer:problem:user_not_found"`
+	Title         string                 `json:"title" example:"Not Found"`
+	Status        int                    `json:"status" example:"404"`
+	Detail        string                 `json:"detail,omitempty" example:"user not found"`
+	Instance      string                 `json:"instance,omitempty" example:"/balance/7141b92f-a8c8-471e-83e5-7fc72da61cb9"`
+	CorrelationId string                 `json:"correlation_id" example:"3f1c2a9e-5b7d-4c1e-9a0b-2d6e8f4a1c3b"`
+	ErrorCode     string                 `json:"error_code" example:"user_not_found"`
+	Fields        []apperrors.FieldError `json:"fields,omitempty"`
+}
+
+func NewProblemDetails(status int, errorCode, detail, instance, correlationId string) ProblemDetails {
+	return ProblemDetails{
+		Type:          ProblemTypePrefix + errorCode,
+		Title:         http.StatusText(status),
+		Status:        status,
+		Detail:        detail,
+		Instance:      instance,
+		CorrelationId: correlationId,
+		ErrorCode:     errorCode,
+	}
+}
+
+func WriteProblem(w http.ResponseWriter, problem ProblemDetails) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+
+	json.NewEncoder(w).Encode(problem)
+}
@@ -1,9 +1,7 @@
 package dtos
 
-import "github.com/google/uuid"
-
 type TransactionRequestDto struct {
-	From   uuid.UUID `json:"from"`
-	To     uuid.UUID `json:"to"`
-	Amount float64   `json:"amount"`
+	From   string  `json:"from" example:"7141b92f-a8c8-471e-83e5-7fc72da61cb9"`
+	To     string  `json:"to" example:"9d02adbc-27ca-4695-9d92-10cb35db67f4"`
+	Amount float64 `json:"amount" example:"100.50" redact:"amount"`
 }
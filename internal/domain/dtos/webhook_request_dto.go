@@ -0,0 +1,8 @@
+package dtos
+
+type CreateWebhookRequestDto struct {
+	URL    string   `json:"url" example:"https://example.com/hooks/transfers"`
+	Events []string `json:"events" example:"TransferSucceeded,TransferFailed"`
+	// Accounts narrows the webhook to transfers involving these accounts.
+	Accounts []string `json:"accounts,omitempty" example:"7141b92f-a8c8-471e-83e5-7fc72da61cb9"`
+}
@@ -0,0 +1,21 @@
+package dtos
+
+import "moneyTransfer/internal/domain/model"
+
+type WebhookResponseDto struct {
+	Webhook model.Webhook `json:"webhook"`
+	// Secret signs the deliveries. It is only returned when the webhook is registered.
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookListResponseDto struct {
+	Webhooks []model.Webhook `json:"webhooks"`
+}
+
+type WebhookDeliveryResponseDto struct {
+	Delivery model.WebhookDelivery `json:"delivery"`
+}
+
+type WebhookDeliveryListResponseDto struct {
+	Deliveries []model.WebhookDelivery `json:"deliveries"`
+}
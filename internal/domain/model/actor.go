@@ -0,0 +1,22 @@
+package model
+
+import "context"
+
+const (
+	ActorSystem = "system"
+	ActorWorker = "system:worker"
+)
+
+type actorContextKey struct{}
+
+// WithActor records who is acting on behalf of the request, for the audit log.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorContextKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return ActorSystem
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditActionTransactionCreated       = "transaction.created"
+	AuditActionTransactionStatusChanged = "transaction.status_changed"
+	AuditActionTransactionDeleted       = "transaction.deleted"
+	AuditActionBalanceUpdated           = "balance.updated"
+	AuditActionAPIKeyCreated            = "api_key.created"
+	AuditActionAPIKeyRevoked            = "api_key.revoked"
+	AuditActionWebhookRegistered        = "webhook.registered"
+	AuditActionWebhookDeleted           = "webhook.deleted"
+	AuditActionLogLevelChanged          = "log_level.changed"
+)
+
+const (
+	AuditEntityTransaction = "transaction"
+	AuditEntityUser        = "user"
+	AuditEntityAPIKey      = "api_key"
+	AuditEntityWebhook     = "webhook"
+	AuditEntitySetting     = "setting"
+)
+
+// AuditEntry is one link of the append-only audit chain. Hash covers every
+// other field plus PrevHash, so editing or removing an entry breaks the chain.
+type AuditEntry struct {
+	Id         int64           `json:"id"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityId   string          `json:"entity_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	PrevHash   string          `json:"prev_hash"`
+	Hash       string          `json:"hash"`
+}
+
+// ComputeHash returns the SHA-256 of the entry contents chained to PrevHash.
+// CreatedAt is normalised to UTC microseconds, the precision the database keeps.
+func (e AuditEntry) ComputeHash() string {
+	payload, _ := json.Marshal(struct {
+		PrevHash   string `json:"prev_hash"`
+		Actor      string `json:"actor"`
+		Action     string `json:"action"`
+		EntityType string `json:"entity_type"`
+		EntityId   string `json:"entity_id"`
+		Before     string `json:"before"`
+		After      string `json:"after"`
+		CreatedAt  string `json:"created_at"`
+	}{
+		PrevHash:   e.PrevHash,
+		Actor:      e.Actor,
+		Action:     e.Action,
+		EntityType: e.EntityType,
+		EntityId:   e.EntityId,
+		Before:     string(e.Before),
+		After:      string(e.After),
+		CreatedAt:  e.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+	})
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+type AuditVerification struct {
+	Valid          bool   `json:"valid"`
+	EntriesChecked int64  `json:"entries_checked"`
+	BrokenAtId     *int64 `json:"broken_at_id,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	EventTransferCreated   = "TransferCreated"
+	EventTransferSucceeded = "TransferSucceeded"
+	EventTransferFailed    = "TransferFailed"
+	EventBalanceChanged    = "BalanceChanged"
+)
+
+// DomainEvent is a fact written to the outbox together with the state change
+// it describes. AccountId is the partition key: events of one account are
+// numbered by AccountSequence, without gaps, and published in that order.
+// Sequence is the event's position in the whole outbox. Transfer events
+// belong to the sending account.
+type DomainEvent struct {
+	Sequence        int64           `json:"sequence"`
+	Id              uuid.UUID       `json:"id"`
+	Type            string          `json:"type"`
+	AccountId       string          `json:"account_id"`
+	AccountSequence int64           `json:"account_sequence"`
+	Payload         json.RawMessage `json:"payload"`
+	OccurredAt      time.Time       `json:"occurred_at"`
+}
+
+type TransferEventPayload struct {
+	TransactionId uuid.UUID `json:"transaction_id"`
+	SenderId      uuid.UUID `json:"sender_id"`
+	ReceiverId    uuid.UUID `json:"receiver_id"`
+	Amount        float64   `json:"amount"`
+	Status        string    `json:"status"`
+}
+
+type BalanceChangedPayload struct {
+	AccountId       string  `json:"account_id"`
+	PreviousBalance float64 `json:"previous_balance"`
+	Balance         float64 `json:"balance"`
+}
+
+func NewDomainEvent(eventType, accountId string, payload any) (DomainEvent, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return DomainEvent{}, err
+	}
+
+	return DomainEvent{
+		Id:         uuid.New(),
+		Type:       eventType,
+		AccountId:  accountId,
+		Payload:    data,
+		OccurredAt: time.Now().UTC().Truncate(time.Microsecond),
+	}, nil
+}
+
+// NewTransferEvent describes tx, keyed by its sending account.
+func NewTransferEvent(eventType string, tx Transaction) (DomainEvent, error) {
+	return NewDomainEvent(eventType, tx.SenderId.String(), TransferEventPayload{
+		TransactionId: tx.Id,
+		SenderId:      tx.SenderId,
+		ReceiverId:    tx.ReceiverId,
+		Amount:        tx.Amount,
+		Status:        tx.Status,
+	})
+}
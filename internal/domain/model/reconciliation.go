@@ -0,0 +1,37 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// AccountLedger is what the books say about one account: its stored balance,
+// its initial funding and the totals of successful transfers in and out.
+type AccountLedger struct {
+	UserId         uuid.UUID
+	Balance        float64
+	InitialBalance float64
+	Incoming       float64
+	Outgoing       float64
+}
+
+func (l AccountLedger) ExpectedBalance() float64 {
+	return l.InitialBalance + l.Incoming - l.Outgoing
+}
+
+type AccountDiscrepancy struct {
+	UserId          uuid.UUID `json:"user_id"`
+	StoredBalance   float64   `json:"stored_balance"`
+	ExpectedBalance float64   `json:"expected_balance"`
+	Difference      float64   `json:"difference"`
+}
+
+type ReconciliationReport struct {
+	GeneratedAt         time.Time            `json:"generated_at"`
+	AccountsChecked     int                  `json:"accounts_checked"`
+	Discrepancies       []AccountDiscrepancy `json:"discrepancies"`
+	TotalBalance        float64              `json:"total_balance"`
+	TotalInitialBalance float64              `json:"total_initial_balance"`
+	MoneyConserved      bool                 `json:"money_conserved"`
+	Balanced            bool                 `json:"balanced"`
+}
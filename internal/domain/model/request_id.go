@@ -0,0 +1,32 @@
+package model
+
+import "context"
+
+type requestIdContextKey struct{}
+
+// WithRequestId attaches the id that ties a request's error response, log
+// lines and transfer job together.
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdContextKey{}, id)
+}
+
+const maxRequestIdLength = 128
+
+// ValidRequestId accepts a caller's request id when it is printable ASCII of
+// reasonable length, so a caller cannot forge log lines or headers through it.
+func ValidRequestId(id string) bool {
+	if id == "" || len(id) > maxRequestIdLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func RequestIdFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdContextKey{}).(string)
+	return id
+}
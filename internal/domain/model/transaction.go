@@ -9,7 +9,7 @@ type Transaction struct {
 	Id         uuid.UUID `json:"id"`
 	SenderId   uuid.UUID `json:"sender_id"`
 	ReceiverId uuid.UUID `json:"receiver_id"`
-	Amount     float64   `json:"amount"`
+	Amount     float64   `json:"amount" redact:"amount"`
 	Status     string    `json:"status"`
 	CreatedAt  time.Time `json:"created_at"`
 }
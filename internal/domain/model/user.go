@@ -6,8 +6,8 @@ import (
 
 type User struct {
 	Id        uuid.UUID `json:"id"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Email     string    `json:"email"`
-	Balance   float64   `json:"balance"`
+	FirstName string    `json:"first_name" redact:"name"`
+	LastName  string    `json:"last_name" redact:"name"`
+	Email     string    `json:"email" redact:"email"`
+	Balance   float64   `json:"balance" redact:"amount"`
 }
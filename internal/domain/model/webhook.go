@@ -0,0 +1,75 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+	"slices"
+	"time"
+)
+
+// Delivery statuses.
+const (
+	DeliveryPending   = "PENDING"
+	DeliverySucceeded = "SUCCEEDED"
+	DeliveryFailed    = "FAILED"
+)
+
+// WebhookEventTypes are the events a webhook can subscribe to: the changes in
+// a transfer's status.
+var WebhookEventTypes = []string{EventTransferCreated, EventTransferSucceeded, EventTransferFailed}
+
+type Webhook struct {
+	Id uuid.UUID `json:"id"`
+	// OwnerId is the API key that registered the webhook. Only that key, or
+	// an admin key, can manage it, and it only receives what the key may read.
+	OwnerId uuid.UUID `json:"owner_id"`
+	URL     string    `json:"url"`
+	Events  []string  `json:"events"`
+	// Accounts narrows the webhook to transfers involving these accounts;
+	// empty means every account.
+	Accounts []uuid.UUID `json:"accounts,omitempty"`
+	// Secret signs deliveries. Unlike API keys it is stored as is, since the
+	// server needs it to compute signatures.
+	Secret    string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscribes reports whether the webhook wants events of eventType.
+func (w Webhook) Subscribes(eventType string) bool {
+	return slices.Contains(w.Events, eventType)
+}
+
+// Watches reports whether the webhook wants events about any of accounts.
+func (w Webhook) Watches(accounts ...uuid.UUID) bool {
+	if len(w.Accounts) == 0 {
+		return true
+	}
+	for _, account := range accounts {
+		if slices.Contains(w.Accounts, account) {
+			return true
+		}
+	}
+	return false
+}
+
+func IsWebhookEventType(eventType string) bool {
+	return slices.Contains(WebhookEventTypes, eventType)
+}
+
+// WebhookDelivery is one event sent to one webhook, with the outcome of its
+// latest attempt.
+type WebhookDelivery struct {
+	Id        uuid.UUID `json:"id"`
+	WebhookId uuid.UUID `json:"webhook_id"`
+	EventId   uuid.UUID `json:"event_id"`
+	EventType string    `json:"event_type"`
+	// Payload is the request body, the event as published by the outbox.
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
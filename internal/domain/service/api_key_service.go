@@ -0,0 +1,248 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyPrefix       = "mtk_"
+	apiKeyDisplayLen   = 12
+	apiKeyMinLength    = 32
+	lastUsedResolution = time.Minute
+)
+
+var (
+	ErrInvalidAPIKey        = apperrors.Unauthorized(apperrors.CodeInvalidAPIKey, "invalid api key")
+	ErrAPIKeyRevoked        = apperrors.Unauthorized(apperrors.CodeAPIKeyRevoked, "api key revoked")
+	ErrAPIKeyAlreadyRevoked = apperrors.Conflict(apperrors.CodeAPIKeyRevoked, "api key already revoked")
+	ErrAPIKeyNotFound       = apperrors.NotFound(apperrors.CodeAPIKeyNotFound, "api key not found")
+	ErrInvalidAPIKeyRequest = apperrors.Validation(apperrors.CodeInvalidAPIKeyRequest, "invalid api key request")
+)
+
+type APIKeyService interface {
+	Issue(ctx context.Context, name string, scopes []string) (model.APIKey, string, error)
+	EnsureKey(ctx context.Context, name, rawKey string, scopes []string) error
+	Authenticate(ctx context.Context, rawKey string) (model.APIKey, error)
+	List(ctx context.Context) ([]model.APIKey, error)
+	Revoke(ctx context.Context, id string) error
+	Rotate(ctx context.Context, id string) (model.APIKey, string, error)
+}
+
+type apiKeyService struct {
+	apiKeyRepo  contracts.APIKeyRepository
+	webhookRepo contracts.WebhookRepository
+	txManager   contracts.TxManager
+	log         logger.Logger
+}
+
+func NewAPIKeyService(apiKeyRepo contracts.APIKeyRepository, webhookRepo contracts.WebhookRepository, txManager contracts.TxManager, logger logger.Logger) APIKeyService {
+	return &apiKeyService{apiKeyRepo: apiKeyRepo, webhookRepo: webhookRepo, txManager: txManager, log: logger}
+}
+
+// HashAPIKey returns the digest stored in place of the raw key. Keys are
+// random and long enough that a plain SHA-256 is sufficient.
+func HashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *apiKeyService) Issue(ctx context.Context, name string, scopes []string) (model.APIKey, string, error) {
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to generate api key", "error", err)
+		return model.APIKey{}, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	key, err := s.store(ctx, name, rawKey, scopes)
+	if err != nil {
+		return model.APIKey{}, "", err
+	}
+
+	logger.WithContext(ctx, s.log).Info("api key issued", "apiKeyId", key.Id, "name", key.Name, "scopes", key.Scopes)
+	return key, rawKey, nil
+}
+
+func (s *apiKeyService) EnsureKey(ctx context.Context, name, rawKey string, scopes []string) error {
+	if len(rawKey) < apiKeyMinLength {
+		return apperrors.Validation(apperrors.CodeInvalidAPIKeyRequest, fmt.Sprintf("api key must be at least %d characters", apiKeyMinLength))
+	}
+
+	_, err := s.apiKeyRepo.GetByHash(ctx, HashAPIKey(rawKey))
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, apperrors.ErrNotFound) {
+		logger.WithContext(ctx, s.log).Error("failed to look up api key", "name", name, "error", err)
+		return fmt.Errorf("failed to look up api key: %w", err)
+	}
+
+	key, err := s.store(ctx, name, rawKey, scopes)
+	if err != nil {
+		return err
+	}
+
+	logger.WithContext(ctx, s.log).Info("api key registered", "apiKeyId", key.Id, "name", key.Name, "scopes", key.Scopes)
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (model.APIKey, error) {
+	if rawKey == "" {
+		return model.APIKey{}, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetByHash(ctx, HashAPIKey(rawKey))
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return model.APIKey{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to look up api key", "error", err)
+		return model.APIKey{}, fmt.Errorf("failed to look up api key: %w", err)
+	}
+
+	if key.IsRevoked() {
+		logger.WithContext(ctx, s.log).Warn("revoked api key used", "apiKeyId", key.Id)
+		return model.APIKey{}, ErrAPIKeyRevoked
+	}
+
+	// last_used_at is only refreshed once per resolution window so that
+	// authenticated reads do not turn into a write on every request.
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.apiKeyRepo.UpdateLastUsed(ctx, key.Id.String(), now); err != nil {
+			logger.WithContext(ctx, s.log).Warn("failed to update api key last used", "apiKeyId", key.Id, "error", err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+
+	return key, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context) ([]model.APIKey, error) {
+	keys, err := s.apiKeyRepo.List(ctx)
+	if err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to list api keys", "error", err)
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, id string) error {
+	if err := s.revoke(ctx, id); err != nil {
+		return err
+	}
+
+	logger.WithContext(ctx, s.log).Info("api key revoked", "apiKeyId", id)
+	return nil
+}
+
+// Rotate issues a replacement key with the same name and scopes, hands it the
+// old key's webhooks and revokes the old key, which stops working immediately.
+// All of it happens in one transaction, and the revoke only applies to a key
+// that is still active: of two concurrent rotations of a key, the second one
+// fails with ErrAPIKeyAlreadyRevoked and leaves no extra key behind.
+func (s *apiKeyService) Rotate(ctx context.Context, id string) (model.APIKey, string, error) {
+	rawKey, err := generateAPIKey()
+	if err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to generate api key", "error", err)
+		return model.APIKey{}, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	var key model.APIKey
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		old, err := s.apiKeyRepo.GetById(ctx, id)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		if err != nil {
+			logger.WithContext(ctx, s.log).Error("failed to get api key", "apiKeyId", id, "error", err)
+			return fmt.Errorf("failed to get api key: %w", err)
+		}
+		if old.IsRevoked() {
+			return ErrAPIKeyAlreadyRevoked
+		}
+
+		if key, err = s.store(ctx, old.Name, rawKey, old.Scopes); err != nil {
+			return err
+		}
+
+		if err := s.webhookRepo.Reassign(ctx, id, key.Id.String()); err != nil {
+			logger.WithContext(ctx, s.log).Error("failed to reassign webhooks", "oldApiKeyId", id, "apiKeyId", key.Id, "error", err)
+			return fmt.Errorf("failed to reassign webhooks: %w", err)
+		}
+
+		return s.revoke(ctx, id)
+	})
+	if err != nil {
+		return model.APIKey{}, "", err
+	}
+
+	logger.WithContext(ctx, s.log).Info("api key rotated", "oldApiKeyId", id, "apiKeyId", key.Id, "name", key.Name, "scopes", key.Scopes)
+	return key, rawKey, nil
+}
+
+func (s *apiKeyService) revoke(ctx context.Context, id string) error {
+	err := s.apiKeyRepo.Revoke(ctx, id, time.Now())
+	switch {
+	case errors.Is(err, apperrors.ErrNotFound):
+		return ErrAPIKeyNotFound
+	case errors.Is(err, apperrors.ErrConflict):
+		return ErrAPIKeyAlreadyRevoked
+	case err != nil:
+		logger.WithContext(ctx, s.log).Error("failed to revoke api key", "apiKeyId", id, "error", err)
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return nil
+}
+
+func (s *apiKeyService) store(ctx context.Context, name, rawKey string, scopes []string) (model.APIKey, error) {
+	if strings.TrimSpace(name) == "" {
+		return model.APIKey{}, apperrors.Validation(apperrors.CodeInvalidAPIKeyRequest, "name is required")
+	}
+	if len(scopes) == 0 {
+		return model.APIKey{}, apperrors.Validation(apperrors.CodeInvalidAPIKeyRequest, "at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !model.IsKnownScope(scope) {
+			return model.APIKey{}, apperrors.Validation(apperrors.CodeInvalidAPIKeyRequest, fmt.Sprintf("unknown scope %q", scope))
+		}
+	}
+
+	key := model.APIKey{
+		Id:        uuid.New(),
+		Name:      name,
+		Prefix:    rawKey[:min(apiKeyDisplayLen, len(rawKey))],
+		KeyHash:   HashAPIKey(rawKey),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to create api key", "name", name, "error", err)
+		return model.APIKey{}, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return key, nil
+}
+
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+	"time"
+)
+
+const auditVerifyBatchSize = 500
+
+type AuditService interface {
+	// Record appends an entry for a change that is not stored in a repository,
+	// such as a runtime setting, on behalf of the actor in ctx.
+	Record(ctx context.Context, action, entityType, entityId string, before, after any) error
+	Verify(ctx context.Context) (model.AuditVerification, error)
+}
+
+type auditService struct {
+	auditRepo contracts.AuditRepository
+	log       logger.Logger
+}
+
+func NewAuditService(auditRepo contracts.AuditRepository, logger logger.Logger) AuditService {
+	return &auditService{auditRepo: auditRepo, log: logger}
+}
+
+func (a *auditService) Record(ctx context.Context, action, entityType, entityId string, before, after any) error {
+	entry := model.AuditEntry{
+		Actor:      model.ActorFromContext(ctx),
+		Action:     action,
+		EntityType: entityType,
+		EntityId:   entityId,
+		CreatedAt:  time.Now(),
+	}
+
+	var err error
+	if entry.Before, err = auditSnapshot(before); err != nil {
+		return err
+	}
+	if entry.After, err = auditSnapshot(after); err != nil {
+		return err
+	}
+
+	if _, err := a.auditRepo.Append(ctx, entry); err != nil {
+		logger.WithContext(ctx, a.log).Error("failed to append audit entry", "action", action, "error", err)
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+	return nil
+}
+
+func auditSnapshot(value any) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	snapshot, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// Verify walks the chain from the first entry and stops at the first entry
+// whose stored hash or link to its predecessor does not match.
+func (a *auditService) Verify(ctx context.Context) (model.AuditVerification, error) {
+	result := model.AuditVerification{Valid: true}
+	prevHash := ""
+	var afterId int64
+
+	for {
+		entries, err := a.auditRepo.List(ctx, afterId, auditVerifyBatchSize)
+		if err != nil {
+			logger.WithContext(ctx, a.log).Error("failed to list audit entries", "afterId", afterId, "error", err)
+			return result, fmt.Errorf("failed to list audit entries: %w", err)
+		}
+
+		for _, entry := range entries {
+			reason := ""
+			switch {
+			case entry.PrevHash != prevHash:
+				reason = "prev_hash does not match the hash of the previous entry"
+			case entry.ComputeHash() != entry.Hash:
+				reason = "hash does not match entry contents"
+			}
+			if reason != "" {
+				logger.WithContext(ctx, a.log).Error("audit chain broken", "id", entry.Id, "reason", reason)
+				result.Valid = false
+				result.BrokenAtId = &entry.Id
+				result.Reason = reason
+				return result, nil
+			}
+
+			prevHash = entry.Hash
+			afterId = entry.Id
+			result.EntriesChecked++
+		}
+
+		if len(entries) < auditVerifyBatchSize {
+			break
+		}
+	}
+
+	logger.WithContext(ctx, a.log).Info("audit chain verified", "entries", result.EntriesChecked)
+	return result, nil
+}
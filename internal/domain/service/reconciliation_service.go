@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+	"time"
+)
+
+// reconciliationTolerance absorbs float rounding when comparing balances.
+const reconciliationTolerance = 1e-6
+
+type ReconciliationService interface {
+	Reconcile(ctx context.Context) (model.ReconciliationReport, error)
+}
+
+type reconciliationService struct {
+	ledgerRepo contracts.LedgerRepository
+	log        logger.Logger
+}
+
+func NewReconciliationService(ledgerRepo contracts.LedgerRepository, logger logger.Logger) ReconciliationService {
+	return &reconciliationService{ledgerRepo: ledgerRepo, log: logger}
+}
+
+// Reconcile recomputes every balance as initial funding plus successful
+// incoming minus successful outgoing transfers, and checks that the total
+// amount of money in the system equals the total initial funding.
+func (s *reconciliationService) Reconcile(ctx context.Context) (model.ReconciliationReport, error) {
+	ledgers, err := s.ledgerRepo.GetAccountLedgers(ctx)
+	if err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to get account ledgers", "error", err)
+		return model.ReconciliationReport{}, fmt.Errorf("failed to get account ledgers: %w", err)
+	}
+
+	report := model.ReconciliationReport{
+		GeneratedAt:     time.Now().UTC(),
+		AccountsChecked: len(ledgers),
+		Discrepancies:   []model.AccountDiscrepancy{},
+	}
+
+	for _, ledger := range ledgers {
+		report.TotalBalance += ledger.Balance
+		report.TotalInitialBalance += ledger.InitialBalance
+
+		expected := ledger.ExpectedBalance()
+		if math.Abs(ledger.Balance-expected) > reconciliationTolerance {
+			report.Discrepancies = append(report.Discrepancies, model.AccountDiscrepancy{
+				UserId:          ledger.UserId,
+				StoredBalance:   ledger.Balance,
+				ExpectedBalance: expected,
+				Difference:      ledger.Balance - expected,
+			})
+		}
+	}
+
+	report.MoneyConserved = math.Abs(report.TotalBalance-report.TotalInitialBalance) <= reconciliationTolerance
+	report.Balanced = report.MoneyConserved && len(report.Discrepancies) == 0
+
+	if report.Balanced {
+		logger.WithContext(ctx, s.log).Info("reconciliation passed", "accounts", report.AccountsChecked)
+	} else {
+		logger.WithContext(ctx, s.log).Warn("reconciliation found discrepancies",
+			"accounts", report.AccountsChecked,
+			"discrepancies", len(report.Discrepancies),
+			"moneyConserved", report.MoneyConserved)
+	}
+
+	return report, nil
+}
@@ -4,9 +4,13 @@ import (
 	"context"
 	"fmt"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"moneyTransfer/internal/domain/contracts"
 	"moneyTransfer/internal/domain/model"
 	"moneyTransfer/internal/queue"
+	"moneyTransfer/internal/tracing"
 	"moneyTransfer/pkg/logger"
 	"time"
 )
@@ -14,61 +18,176 @@ import (
 type TransferService interface {
 	CreateTransfer(ctx context.Context, from, to string, amount float64) (uuid.UUID, error)
 	GetTransactionsByUserId(ctx context.Context, userId string) ([]model.Transaction, error)
+	GetTransaction(ctx context.Context, id uuid.UUID) (model.Transaction, error)
+	// WaitForTransfer waits up to timeout for the transfer to leave PENDING
+	// and returns it as it stands then.
+	WaitForTransfer(ctx context.Context, id uuid.UUID, timeout time.Duration) (model.Transaction, error)
 }
 
+// tracerName identifies the spans started in this package.
+const tracerName = "moneyTransfer/internal/domain/service"
+
+// waitPollInterval bounds how late a waiter notices a transfer completed by a
+// worker in another instance, which does not signal this one.
+const waitPollInterval = 500 * time.Millisecond
+
 type transferService struct {
 	transferRepo contracts.TransferRepository
 	userRepo     contracts.UserRepository
+	publisher    queue.Publisher
+	completions  *queue.Completions
 	log          logger.Logger
 }
 
-func NewTransferService(transferRepo contracts.TransferRepository, userRepo contracts.UserRepository, logger logger.Logger) TransferService {
-	return &transferService{transferRepo: transferRepo, userRepo: userRepo, log: logger}
+func NewTransferService(transferRepo contracts.TransferRepository, userRepo contracts.UserRepository, publisher queue.Publisher, completions *queue.Completions, logger logger.Logger) TransferService {
+	return &transferService{transferRepo: transferRepo, userRepo: userRepo, publisher: publisher, completions: completions, log: logger}
 }
 
 func (t *transferService) GetTransactionsByUserId(ctx context.Context, userId string) ([]model.Transaction, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "TransferService.GetTransactionsByUserId")
+	defer span.End()
+	ctx = logger.WithFields(ctx, "user_id", userId)
+
 	transactions, err := t.transferRepo.GetTransactionsByUserId(ctx, userId)
 	if err != nil {
-		t.log.Error("failed to get transactions by user id", "userId", userId, "error", err)
-		return nil, fmt.Errorf("failed to get transactions: %w", err)
+		logger.WithContext(ctx, t.log).Error("failed to get transactions by user id", "error", err)
+		err = fmt.Errorf("failed to get transactions: %w", err)
+		tracing.End(span, err)
+		return nil, err
 	}
 
-	t.log.Info("transactions retrieved", "transactions", transactions)
+	logger.WithContext(ctx, t.log).Info("transactions retrieved", "transactions", transactions)
 	return transactions, nil
 }
 
+func (t *transferService) GetTransaction(ctx context.Context, id uuid.UUID) (model.Transaction, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "TransferService.GetTransaction", trace.WithAttributes(attribute.String("transfer.id", id.String())))
+	defer span.End()
+	ctx = logger.WithFields(ctx, "transaction_id", id)
+
+	tx, err := t.transferRepo.GetTransactionById(ctx, id.String())
+	if err != nil {
+		logger.WithContext(ctx, t.log).Error("failed to get transaction", "error", err)
+		err = fmt.Errorf("failed to get transaction: %w", err)
+		tracing.End(span, err)
+		return model.Transaction{}, err
+	}
+
+	return tx, nil
+}
+
 func (t *transferService) CreateTransfer(ctx context.Context, from, to string, amount float64) (uuid.UUID, error) {
-	if amount <= 0 {
-		t.log.Warn("invalid transfer amount", "amount", amount, "from", from, "to", to)
-		return uuid.Nil, fmt.Errorf("amount must be greater than zero")
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "TransferService.CreateTransfer", trace.WithAttributes(attribute.Float64("transfer.amount", amount)))
+	id, err := t.createTransfer(ctx, from, to, amount)
+	if err == nil {
+		span.SetAttributes(attribute.String("transfer.id", id.String()))
+	}
+	tracing.End(span, err)
+	return id, err
+}
+
+func (t *transferService) createTransfer(ctx context.Context, from, to string, amount float64) (uuid.UUID, error) {
+	senderId, receiverId, err := t.validateTransfer(ctx, from, to, amount)
+	if err != nil {
+		return uuid.Nil, err
 	}
 
 	tx := model.Transaction{
 		Id:         uuid.New(),
-		SenderId:   uuid.MustParse(from),
-		ReceiverId: uuid.MustParse(to),
+		SenderId:   senderId,
+		ReceiverId: receiverId,
 		Amount:     amount,
 		Status:     model.StatusPending,
 		CreatedAt:  time.Now(),
 	}
+	ctx = logger.WithFields(ctx, "user_id", tx.SenderId, "transaction_id", tx.Id)
 
-	err := t.transferRepo.CreateTransfer(ctx, tx)
+	err = t.transferRepo.CreateTransfer(ctx, tx)
 	if err != nil {
-		t.log.Error("failed to create transfer", "tx", tx, "error", err)
+		logger.WithContext(ctx, t.log).Error("failed to create transfer", "tx", tx, "error", err)
 		return uuid.Nil, fmt.Errorf("failed to create transfer: %w", err)
 	}
 
-	t.log.Info("transfer created", "tx", tx)
+	logger.WithContext(ctx, t.log).Info("transfer created", "tx", tx)
 
 	job := queue.TransferJob{
 		SenderId:      tx.SenderId,
 		ReceiverId:    tx.ReceiverId,
 		Amount:        tx.Amount,
 		TransactionId: tx.Id,
+		RequestId:     model.RequestIdFromContext(ctx),
 	}
 
-	t.log.Info("enqueuing transfer job", "job", job)
+	logger.WithContext(ctx, t.log).Info("enqueuing transfer job", "job", job)
+
+	if err := t.publish(ctx, job); err != nil {
+		logger.WithContext(ctx, t.log).Error("failed to enqueue transfer job", "job", job, "error", err)
+
+		// The request may already be cancelled, but the orphaned row still has to go.
+		cleanupCtx := context.WithoutCancel(ctx)
+		if delErr := t.transferRepo.DeletePendingTransaction(cleanupCtx, tx.Id.String()); delErr != nil {
+			logger.WithContext(ctx, t.log).Error("failed to delete orphaned pending transaction", "error", delErr)
+		}
+
+		return uuid.Nil, fmt.Errorf("failed to enqueue transfer: %w", err)
+	}
 
-	queue.Enqueue(job)
 	return tx.Id, nil
 }
+
+// publish hands job to the workers with the current trace attached.
+func (t *transferService) publish(ctx context.Context, job queue.TransferJob) error {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "TransferJob publish", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("transfer.id", job.TransactionId.String())))
+	job.TraceContext = tracing.Inject(ctx)
+	err := t.publisher.Publish(ctx, job)
+	tracing.End(span, err)
+	return err
+}
+
+func (t *transferService) WaitForTransfer(ctx context.Context, id uuid.UUID, timeout time.Duration) (model.Transaction, error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "TransferService.WaitForTransfer", trace.WithAttributes(
+		attribute.String("transfer.id", id.String()), attribute.String("transfer.wait", timeout.String())))
+	tx, err := t.waitForTransfer(ctx, id, timeout)
+	if err == nil {
+		span.SetAttributes(attribute.String("transfer.status", tx.Status))
+	}
+	tracing.End(span, err)
+	return tx, err
+}
+
+func (t *transferService) waitForTransfer(ctx context.Context, id uuid.UUID, timeout time.Duration) (model.Transaction, error) {
+	ctx = logger.WithFields(ctx, "transaction_id", id)
+	// Subscribe first: a job finishing before the first read is then either
+	// seen by the read or signalled.
+	done, stop := t.completions.Wait(id)
+	defer stop()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(waitPollInterval)
+	defer poll.Stop()
+
+	for {
+		tx, err := t.transferRepo.GetTransactionById(ctx, id.String())
+		if err != nil {
+			logger.WithContext(ctx, t.log).Error("failed to get transaction while waiting", "error", err)
+			return model.Transaction{}, fmt.Errorf("failed to get transaction: %w", err)
+		}
+		if tx.Status != model.StatusPending {
+			return tx, nil
+		}
+
+		select {
+		case <-done:
+			// Closed channels stay ready; rely on polling from here on.
+			done = nil
+		case <-poll.C:
+		case <-deadline.C:
+			logger.WithContext(ctx, t.log).Info("transfer still pending after wait", "timeout", timeout)
+			return tx, nil
+		case <-ctx.Done():
+			return model.Transaction{}, ctx.Err()
+		}
+	}
+}
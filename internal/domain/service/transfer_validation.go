@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"math"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/pkg/logger"
+)
+
+const maxAmountDecimals = 2
+
+// validateTransfer rejects a transfer before anything is written. Problems
+// with the request itself are reported together; the account and funds checks
+// only run once the request is well formed. The worker checks funds again when
+// it moves the money, so this is a fast failure rather than a guarantee.
+func (t *transferService) validateTransfer(ctx context.Context, from, to string, amount float64) (uuid.UUID, uuid.UUID, error) {
+	var fields []apperrors.FieldError
+
+	senderId, fieldErr := parseAccountId("from", from)
+	if fieldErr != nil {
+		fields = append(fields, *fieldErr)
+	}
+	receiverId, fieldErr := parseAccountId("to", to)
+	if fieldErr != nil {
+		fields = append(fields, *fieldErr)
+	}
+	if len(fields) == 0 && senderId == receiverId {
+		fields = append(fields, apperrors.FieldError{Field: "to", Code: apperrors.CodeSelfTransfer, Message: "cannot transfer to the sending account"})
+	}
+
+	switch {
+	case math.IsNaN(amount) || math.IsInf(amount, 0) || amount <= 0:
+		fields = append(fields, apperrors.FieldError{Field: "amount", Code: apperrors.CodeInvalidAmount, Message: "amount must be greater than zero"})
+	case !hasAtMostDecimals(amount, maxAmountDecimals):
+		fields = append(fields, apperrors.FieldError{Field: "amount", Code: apperrors.CodeTooManyDecimals, Message: fmt.Sprintf("amount must have at most %d decimal places", maxAmountDecimals)})
+	}
+
+	if len(fields) > 0 {
+		logger.WithContext(ctx, t.log).Warn("invalid transfer request", "from", from, "to", to, "amount", amount, "fields", fields)
+		return uuid.Nil, uuid.Nil, invalidTransfer(fields)
+	}
+
+	sender, err := t.userRepo.GetById(ctx, senderId.String())
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		logger.WithContext(ctx, t.log).Error("failed to get sender", "from", from, "error", err)
+		return uuid.Nil, uuid.Nil, fmt.Errorf("failed to get sender: %w", err)
+	}
+	if err != nil {
+		fields = append(fields, apperrors.FieldError{Field: "from", Code: apperrors.CodeAccountNotFound, Message: "sending account does not exist"})
+	}
+
+	_, err = t.userRepo.GetById(ctx, receiverId.String())
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		logger.WithContext(ctx, t.log).Error("failed to get receiver", "to", to, "error", err)
+		return uuid.Nil, uuid.Nil, fmt.Errorf("failed to get receiver: %w", err)
+	}
+	if err != nil {
+		fields = append(fields, apperrors.FieldError{Field: "to", Code: apperrors.CodeAccountNotFound, Message: "receiving account does not exist"})
+	}
+
+	if len(fields) > 0 {
+		logger.WithContext(ctx, t.log).Warn("transfer references unknown accounts", "from", from, "to", to, "fields", fields)
+		return uuid.Nil, uuid.Nil, invalidTransfer(fields)
+	}
+
+	if sender.Balance < amount {
+		logger.WithContext(ctx, t.log).Warn("insufficient funds for transfer", "from", from, "amount", amount)
+		return uuid.Nil, uuid.Nil, apperrors.InsufficientFunds("insufficient funds").WithFields(
+			apperrors.FieldError{Field: "amount", Code: apperrors.CodeInsufficientFunds, Message: "amount exceeds the available balance"})
+	}
+
+	return senderId, receiverId, nil
+}
+
+func invalidTransfer(fields []apperrors.FieldError) error {
+	return apperrors.Validation(apperrors.CodeValidationFailed, "transfer request is invalid").WithFields(fields...)
+}
+
+func parseAccountId(field, value string) (uuid.UUID, *apperrors.FieldError) {
+	if value == "" {
+		return uuid.Nil, &apperrors.FieldError{Field: field, Code: apperrors.CodeRequired, Message: field + " is required"}
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil || id == uuid.Nil {
+		return uuid.Nil, &apperrors.FieldError{Field: field, Code: apperrors.CodeInvalidUUID, Message: field + " must be a non-nil UUID"}
+	}
+
+	return id, nil
+}
+
+func hasAtMostDecimals(amount float64, decimals int) bool {
+	scaled := amount * math.Pow10(decimals)
+	return math.Abs(scaled-math.Round(scaled)) < 1e-6
+}
@@ -25,21 +25,21 @@ func NewUserService(userRepo contracts.UserRepository, logger logger.Logger) Use
 func (u *userService) GetBalance(ctx context.Context, userId string) (float64, error) {
 	balance, err := u.userRepo.GetBalance(ctx, userId)
 	if err != nil {
-		u.log.Error("failed to get balance", "userId", userId, "error", err)
+		logger.WithContext(ctx, u.log).Error("failed to get balance", "userId", userId, "error", err)
 		return 0.0, fmt.Errorf("failed to get balance: %w", err)
 	}
 
-	u.log.Info("balance retrieved", "userId", userId, "balance", balance)
+	logger.WithContext(ctx, u.log).Info("balance retrieved", "userId", userId, "balance", balance)
 	return balance, nil
 }
 
 func (u *userService) GetById(ctx context.Context, userId string) (model.User, error) {
 	user, err := u.userRepo.GetById(ctx, userId)
 	if err != nil {
-		u.log.Error("failed to get user", "userId", userId, "error", err)
+		logger.WithContext(ctx, u.log).Error("failed to get user", "userId", userId, "error", err)
 		return model.User{}, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	u.log.Info("user retrieved", "userId", user.Id)
+	logger.WithContext(ctx, u.log).Info("user retrieved", "userId", user.Id)
 	return user, nil
 }
@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/webhook"
+	"moneyTransfer/pkg/logger"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	// deliveryLogLimit is how many of a webhook's latest deliveries are listed.
+	deliveryLogLimit = 100
+)
+
+var (
+	ErrWebhookNotFound  = apperrors.NotFound(apperrors.CodeWebhookNotFound, "webhook not found")
+	ErrDeliveryNotFound = apperrors.NotFound(apperrors.CodeDeliveryNotFound, "delivery not found")
+)
+
+// WebhookService manages the webhooks of the API key in ctx. A key sees and
+// manages only the webhooks it registered; an admin key sees all of them.
+type WebhookService interface {
+	// Register stores a webhook for the given events, owned by the calling
+	// key and optionally narrowed to some accounts. The returned webhook
+	// carries the signing secret, which is not shown again.
+	Register(ctx context.Context, endpoint string, events, accounts []string) (model.Webhook, error)
+	List(ctx context.Context) ([]model.Webhook, error)
+	Delete(ctx context.Context, id string) error
+	ListDeliveries(ctx context.Context, webhookId string) ([]model.WebhookDelivery, error)
+	// Redeliver queues a delivery to be sent again right away, with a fresh
+	// set of attempts, whatever its current status.
+	Redeliver(ctx context.Context, webhookId, deliveryId string) (model.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	webhookRepo  contracts.WebhookRepository
+	deliveryRepo contracts.WebhookDeliveryRepository
+	guard        *webhook.Guard
+	log          logger.Logger
+}
+
+func NewWebhookService(webhookRepo contracts.WebhookRepository, deliveryRepo contracts.WebhookDeliveryRepository, guard *webhook.Guard, logger logger.Logger) WebhookService {
+	return &webhookService{webhookRepo: webhookRepo, deliveryRepo: deliveryRepo, guard: guard, log: logger}
+}
+
+func (s *webhookService) Register(ctx context.Context, endpoint string, events, accounts []string) (model.Webhook, error) {
+	owner, err := caller(ctx)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+	if err := s.validateURL(ctx, endpoint); err != nil {
+		return model.Webhook{}, err
+	}
+	if len(events) == 0 {
+		return model.Webhook{}, apperrors.Validation(apperrors.CodeInvalidWebhookRequest, "at least one event is required")
+	}
+	for _, event := range events {
+		if !model.IsWebhookEventType(event) {
+			return model.Webhook{}, apperrors.Validation(apperrors.CodeInvalidWebhookRequest, fmt.Sprintf("unknown event %q", event))
+		}
+	}
+	accountIds := make([]uuid.UUID, 0, len(accounts))
+	for _, account := range accounts {
+		id, err := uuid.Parse(account)
+		if err != nil {
+			return model.Webhook{}, apperrors.Validation(apperrors.CodeInvalidWebhookRequest, fmt.Sprintf("invalid account id %q", account))
+		}
+		accountIds = append(accountIds, id)
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to generate webhook secret", "error", err)
+		return model.Webhook{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	webhook := model.Webhook{
+		Id:        uuid.New(),
+		OwnerId:   owner.Id,
+		URL:       endpoint,
+		Events:    slices.Compact(slices.Sorted(slices.Values(events))),
+		Secret:    secret,
+		CreatedAt: time.Now().UTC(),
+	}
+	if len(accountIds) > 0 {
+		webhook.Accounts = slices.Compact(slices.SortedFunc(slices.Values(accountIds), func(a, b uuid.UUID) int {
+			return strings.Compare(a.String(), b.String())
+		}))
+	}
+
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to create webhook", "url", endpoint, "error", err)
+		return model.Webhook{}, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	logger.WithContext(ctx, s.log).Info("webhook registered", "webhookId", webhook.Id, "ownerId", owner.Id, "url", webhook.URL, "events", webhook.Events)
+	return webhook, nil
+}
+
+func (s *webhookService) List(ctx context.Context) ([]model.Webhook, error) {
+	owner, err := caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var webhooks []model.Webhook
+	if owner.HasScope(model.ScopeAdmin) {
+		webhooks, err = s.webhookRepo.List(ctx)
+	} else {
+		webhooks, err = s.webhookRepo.ListByOwner(ctx, owner.Id.String())
+	}
+	if err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to list webhooks", "error", err)
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (s *webhookService) Delete(ctx context.Context, id string) error {
+	if _, err := s.getWebhook(ctx, id); err != nil {
+		return err
+	}
+
+	err := s.webhookRepo.Delete(ctx, id)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return ErrWebhookNotFound
+	}
+	if err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to delete webhook", "webhookId", id, "error", err)
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	logger.WithContext(ctx, s.log).Info("webhook deleted", "webhookId", id)
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, webhookId string) ([]model.WebhookDelivery, error) {
+	if _, err := s.getWebhook(ctx, webhookId); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := s.deliveryRepo.ListByWebhook(ctx, webhookId, deliveryLogLimit)
+	if err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to list webhook deliveries", "webhookId", webhookId, "error", err)
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, webhookId, deliveryId string) (model.WebhookDelivery, error) {
+	webhook, err := s.getWebhook(ctx, webhookId)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	delivery, err := s.deliveryRepo.GetById(ctx, deliveryId)
+	if errors.Is(err, apperrors.ErrNotFound) || (err == nil && delivery.WebhookId != webhook.Id) {
+		return model.WebhookDelivery{}, ErrDeliveryNotFound
+	}
+	if err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to get webhook delivery", "deliveryId", deliveryId, "error", err)
+		return model.WebhookDelivery{}, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	delivery.Status = model.DeliveryPending
+	delivery.Attempts = 0
+	delivery.ResponseStatus = 0
+	delivery.LastError = ""
+	delivery.NextAttemptAt = time.Now().UTC()
+	delivery.DeliveredAt = nil
+
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to queue webhook redelivery", "deliveryId", deliveryId, "error", err)
+		return model.WebhookDelivery{}, fmt.Errorf("failed to queue webhook redelivery: %w", err)
+	}
+
+	logger.WithContext(ctx, s.log).Info("webhook redelivery queued", "webhookId", webhookId, "deliveryId", deliveryId)
+	return delivery, nil
+}
+
+// getWebhook returns the webhook if the calling key may manage it. Other
+// keys' webhooks are reported as not found, so their ids are not confirmed.
+func (s *webhookService) getWebhook(ctx context.Context, id string) (model.Webhook, error) {
+	owner, err := caller(ctx)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	webhook, err := s.webhookRepo.GetById(ctx, id)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return model.Webhook{}, ErrWebhookNotFound
+	}
+	if err != nil {
+		logger.WithContext(ctx, s.log).Error("failed to get webhook", "webhookId", id, "error", err)
+		return model.Webhook{}, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	if webhook.OwnerId != owner.Id && !owner.HasScope(model.ScopeAdmin) {
+		return model.Webhook{}, ErrWebhookNotFound
+	}
+
+	return webhook, nil
+}
+
+// caller returns the API key the request authenticated with.
+func caller(ctx context.Context) (model.APIKey, error) {
+	key, ok := model.APIKeyFromContext(ctx)
+	if !ok {
+		return model.APIKey{}, apperrors.Unauthorized(apperrors.CodeAuthenticationMissing, "an API key is required")
+	}
+	return key, nil
+}
+
+// validateURL accepts absolute http and https URLs whose host the guard lets
+// through.
+func (s *webhookService) validateURL(ctx context.Context, endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return apperrors.Validation(apperrors.CodeInvalidWebhookRequest, "url must be an absolute http or https URL")
+	}
+	return s.guard.CheckHost(ctx, parsed.Hostname())
+}
+
+func generateWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
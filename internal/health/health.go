@@ -0,0 +1,154 @@
+// Package health runs the checks behind /livez and /readyz. Liveness says
+// whether the process should be restarted, readiness whether it should get
+// traffic; each is the combined result of named checks that run
+// concurrently under a timeout.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK           = "ok"
+	StatusFailing      = "failing"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Check reports a problem, or nil when healthy. It should return once ctx is
+// done.
+type Check func(ctx context.Context) error
+
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker holds the liveness and readiness checks. Checks are added during
+// startup, before the endpoints are served.
+type Checker struct {
+	timeout      time.Duration
+	liveness     []namedCheck
+	readiness    []namedCheck
+	shuttingDown atomic.Bool
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+func (c *Checker) AddLiveness(name string, check Check) {
+	c.liveness = append(c.liveness, namedCheck{name, check})
+}
+
+func (c *Checker) AddReadiness(name string, check Check) {
+	c.readiness = append(c.readiness, namedCheck{name, check})
+}
+
+// Shutdown makes readiness fail from now on, so load balancers stop sending
+// requests while the server drains.
+func (c *Checker) Shutdown() {
+	c.shuttingDown.Store(true)
+}
+
+func (c *Checker) Live(ctx context.Context) Report {
+	return c.run(ctx, c.liveness)
+}
+
+func (c *Checker) Ready(ctx context.Context) Report {
+	if c.shuttingDown.Load() {
+		return Report{Status: StatusShuttingDown}
+	}
+	return c.run(ctx, c.readiness)
+}
+
+func (c *Checker) run(ctx context.Context, checks []namedCheck) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := nc.check(ctx)
+			result := Result{Status: StatusOK, Duration: time.Since(start).String()}
+			if err != nil {
+				result.Status, result.Error = StatusFailing, err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			if err != nil {
+				report.Status = StatusFailing
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// Ping checks that the database answers.
+func Ping(db *sql.DB) Check {
+	return db.PingContext
+}
+
+// QueueBacklog fails when more than max jobs are waiting, so an instance
+// that has fallen behind stops taking new transfers.
+func QueueBacklog(depth func(ctx context.Context) (int, error), max int) Check {
+	return func(ctx context.Context) error {
+		n, err := depth(ctx)
+		if err != nil {
+			return err
+		}
+		if n > max {
+			return fmt.Errorf("%d jobs waiting, more than %d", n, max)
+		}
+		return nil
+	}
+}
+
+// Heartbeater reports on running workers: how many there are and when the
+// oldest job still in hand was picked up, zero when they are idle.
+type Heartbeater interface {
+	Heartbeat() (running int, oldestJob time.Time)
+}
+
+// WorkerHeartbeat fails when no worker is running or one has been on the
+// same job for longer than maxJobDuration.
+func WorkerHeartbeat(workers Heartbeater, maxJobDuration time.Duration) Check {
+	return func(context.Context) error {
+		running, oldestJob := workers.Heartbeat()
+		if running == 0 {
+			return errors.New("no worker is running")
+		}
+		if !oldestJob.IsZero() && time.Since(oldestJob) > maxJobDuration {
+			return fmt.Errorf("a job has been in progress for %s", time.Since(oldestJob).Round(time.Second))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,285 @@
+// Package migrate applies the numbered SQL migrations and records them in the
+// schema_migrations table.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"moneyTransfer/pkg/logger"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// migrationLockKey is the advisory lock that keeps two instances starting at
+// the same time from applying the same migration twice.
+const migrationLockKey = 7_294_002
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Load reads NNNN_name.up.sql / NNNN_name.down.sql pairs from fsys, sorted by
+// version. Every version needs both files.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Dialect holds the database-specific statements of the Migrator.
+type Dialect struct {
+	// CreateTable creates schema_migrations if it does not exist.
+	CreateTable string
+	// Lock runs first in every migration transaction with migrationLockKey as
+	// its argument. Empty when transactions already exclude each other.
+	Lock string
+}
+
+var Postgres = Dialect{
+	CreateTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL
+)`,
+	Lock: `SELECT pg_advisory_xact_lock($1)`,
+}
+
+// SQLite relies on the database being opened with immediate transactions,
+// which take the write lock up front.
+var SQLite = Dialect{
+	CreateTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`,
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+	log        logger.Logger
+}
+
+func NewMigrator(db *sql.DB, migrations []Migration, logger logger.Logger) *Migrator {
+	return NewDialectMigrator(db, Postgres, migrations, logger)
+}
+
+func NewDialectMigrator(db *sql.DB, dialect Dialect, migrations []Migration, logger logger.Logger) *Migrator {
+	return &Migrator{db: db, dialect: dialect, migrations: migrations, log: logger}
+}
+
+// Up applies every pending migration in version order, each in its own
+// transaction, and returns the ones it applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range m.migrations {
+		done, err := m.inLockedTx(ctx, func(tx *sql.Tx) (bool, error) {
+			isApplied, err := versionApplied(ctx, tx, migration.Version)
+			if err != nil || isApplied {
+				return false, err
+			}
+
+			if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+				return false, fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+			}
+
+			_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES ($1, $2, $3)`,
+				migration.Version, migration.Name, time.Now())
+			return err == nil, err
+		})
+		if err != nil {
+			return applied, err
+		}
+		if done {
+			m.log.Info("migration applied", "version", migration.Version, "name", migration.Name)
+			applied = append(applied, migration)
+		}
+	}
+
+	return applied, nil
+}
+
+// Down reverts the latest steps applied migrations, newest first.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+		migration := m.migrations[i]
+
+		done, err := m.inLockedTx(ctx, func(tx *sql.Tx) (bool, error) {
+			isApplied, err := versionApplied(ctx, tx, migration.Version)
+			if err != nil || !isApplied {
+				return false, err
+			}
+
+			if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+				return false, fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+			}
+
+			_, err = tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+			return err == nil, err
+		})
+		if err != nil {
+			return reverted, err
+		}
+		if done {
+			m.log.Info("migration reverted", "version", migration.Version, "name", migration.Name)
+			reverted = append(reverted, migration)
+		}
+	}
+
+	return reverted, nil
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	appliedAt := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if at, ok := appliedAt[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, m.dialect.CreateTable)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// inLockedTx runs fn in a transaction holding the migration lock. The applied
+// check happens inside fn so a migration another instance finished while we
+// waited is skipped.
+func (m *Migrator) inLockedTx(ctx context.Context, fn func(tx *sql.Tx) (bool, error)) (bool, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+
+	if m.dialect.Lock != "" {
+		if _, err := tx.ExecContext(ctx, m.dialect.Lock, migrationLockKey); err != nil {
+			tx.Rollback()
+			return false, err
+		}
+	}
+
+	done, err := fn(tx)
+	if err != nil {
+		tx.Rollback()
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return done, nil
+}
+
+func versionApplied(ctx context.Context, tx *sql.Tx, version int64) (bool, error) {
+	var found int64
+	err := tx.QueryRowContext(ctx, `SELECT version FROM schema_migrations WHERE version = $1`, version).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,90 @@
+package outbox
+
+import (
+	"context"
+	"moneyTransfer/internal/domain/model"
+	"sync"
+	"time"
+)
+
+// Broker is an in-process stand-in for a message broker: every published
+// event is fanned out to the current subscribers. A subscriber that does not
+// accept an event within slowTimeout is dropped and its channel closed, so one
+// stalled reader cannot hold up the relay.
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[chan model.DomainEvent]struct{}
+	slowTimeout time.Duration
+}
+
+var _ Sink = (*Broker)(nil)
+
+func NewBroker(slowTimeout time.Duration) *Broker {
+	return &Broker{subscribers: make(map[chan model.DomainEvent]struct{}), slowTimeout: slowTimeout}
+}
+
+// Subscribe returns a channel receiving events published from now on, and a
+// function that ends the subscription. The channel is closed when the
+// subscription ends.
+func (b *Broker) Subscribe(buffer int) (<-chan model.DomainEvent, func()) {
+	ch := make(chan model.DomainEvent, buffer)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() { b.drop(ch) }
+}
+
+func (b *Broker) Publish(ctx context.Context, event model.DomainEvent) error {
+	var slow []chan model.DomainEvent
+
+	b.mu.RLock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+			continue
+		default:
+		}
+
+		timer := time.NewTimer(b.slowTimeout)
+		select {
+		case ch <- event:
+		case <-timer.C:
+			slow = append(slow, ch)
+		case <-ctx.Done():
+			timer.Stop()
+			b.mu.RUnlock()
+			return ctx.Err()
+		}
+		timer.Stop()
+	}
+	b.mu.RUnlock()
+
+	for _, ch := range slow {
+		b.drop(ch)
+	}
+	return nil
+}
+
+// Close ends every subscription.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+	return nil
+}
+
+func (b *Broker) drop(ch chan model.DomainEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[ch]; ok {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
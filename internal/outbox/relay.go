@@ -0,0 +1,116 @@
+// Package outbox relays the domain events written to the outbox table to a
+// sink. Delivery is at least once: an event is marked published only after
+// the sink accepted it, so a crash in between publishes it again. Consumers
+// deduplicate on the event id.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+	"slices"
+	"time"
+)
+
+// Relay moves events from the outbox to a sink in AccountSequence order per
+// account. It leases the accounts it publishes for, so relays in several
+// instances never publish the events of one account side by side. When an
+// event fails, the account is held back for a poll interval, later events
+// included, while other accounts carry on.
+type Relay struct {
+	outbox       contracts.OutboxRepository
+	txManager    contracts.TxManager
+	sink         Sink
+	batchSize    int
+	pollInterval time.Duration
+	// claimTimeout is how long a claim lasts, after which the accounts of a
+	// relay that stopped are claimed by another. It must outlast a batch.
+	claimTimeout time.Duration
+	log          logger.Logger
+}
+
+func NewRelay(outbox contracts.OutboxRepository, txManager contracts.TxManager, sink Sink, batchSize int, pollInterval, claimTimeout time.Duration, logger logger.Logger) *Relay {
+	return &Relay{outbox: outbox, txManager: txManager, sink: sink, batchSize: batchSize, pollInterval: pollInterval, claimTimeout: claimTimeout, log: logger}
+}
+
+// Run relays batches until ctx is done, then makes a last pass so events
+// written during shutdown are not left waiting for the next start.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		published, err := r.RelayBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.log.Error("failed to relay outbox events", "error", err)
+		}
+		if ctx.Err() != nil {
+			_, err := r.RelayBatch(context.WithoutCancel(ctx))
+			return err
+		}
+		if published > 0 {
+			continue
+		}
+
+		select {
+		case <-time.After(r.pollInterval):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// RelayBatch publishes the pending events of up to batchSize accounts and
+// returns how many went out. The claim commits before anything is published,
+// so no transaction stays open while the sink works. Every claimed account is
+// released, whatever fails on the way.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	var events []model.DomainEvent
+	now := time.Now().UTC()
+	err := r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		events, err = r.outbox.Claim(ctx, now, now.Add(r.claimTimeout), r.batchSize)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	published := 0
+	var accounts []string
+	var errs []error
+	blocked := make(map[string]bool)
+	for _, event := range events {
+		if !slices.Contains(accounts, event.AccountId) {
+			accounts = append(accounts, event.AccountId)
+		}
+		if blocked[event.AccountId] {
+			continue
+		}
+
+		if err := r.sink.Publish(ctx, event); err != nil {
+			r.log.Warn("failed to publish event, it will be retried",
+				"sequence", event.Sequence, "type", event.Type, "account_id", event.AccountId, "account_sequence", event.AccountSequence, "error", err)
+			blocked[event.AccountId] = true
+			continue
+		}
+
+		// The sink has the event, but it will be published again; holding the
+		// account back keeps its later events behind that repeat.
+		if err := r.outbox.MarkPublished(ctx, event.Sequence, time.Now().UTC()); err != nil {
+			blocked[event.AccountId] = true
+			errs = append(errs, err)
+			continue
+		}
+		published++
+	}
+
+	for _, account := range accounts {
+		notBefore := time.Now().UTC()
+		if blocked[account] {
+			notBefore = notBefore.Add(r.pollInterval)
+		}
+		if err := r.outbox.Release(ctx, account, notBefore); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return published, errors.Join(errs...)
+}
@@ -0,0 +1,96 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"moneyTransfer/internal/domain/model"
+	"os"
+	"sync"
+)
+
+// Sink receives relayed events. Publish returns once the event is accepted;
+// an error makes the relay retry it later.
+type Sink interface {
+	Publish(ctx context.Context, event model.DomainEvent) error
+	Close() error
+}
+
+// MultiSink publishes each event to every sink in turn. An event is accepted
+// only when all of them accept it, and a failure makes the relay retry it on
+// all of them, so each sink must tolerate duplicates.
+type MultiSink []Sink
+
+var _ Sink = MultiSink(nil)
+
+func NewMultiSink(sinks ...Sink) MultiSink {
+	return sinks
+}
+
+func (m MultiSink) Publish(ctx context.Context, event model.DomainEvent) error {
+	for _, sink := range m {
+		if err := sink.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m MultiSink) Close() error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// WriterSink writes each event as one JSON line (NDJSON).
+type WriterSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+	sync   func() error
+}
+
+var _ Sink = (*WriterSink)(nil)
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// NewFileSink appends events to the NDJSON file at path, syncing each line to
+// disk before the event counts as published.
+func NewFileSink(path string) (*WriterSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	return &WriterSink{w: file, closer: file, sync: file.Sync}, nil
+}
+
+func (s *WriterSink) Publish(_ context.Context, event model.DomainEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if s.sync != nil {
+		return s.sync()
+	}
+	return nil
+}
+
+func (s *WriterSink) Close() error {
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
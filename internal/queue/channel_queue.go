@@ -0,0 +1,65 @@
+package queue
+
+import (
+	"context"
+	"time"
+)
+
+// ChannelQueue is an in-process buffered queue. Jobs are lost when the
+// process exits and a failed job is not redelivered but dead-lettered.
+type ChannelQueue struct {
+	jobs chan TransferJob
+	// enqueueTimeout bounds how long Publish waits for room before giving up
+	// with ErrQueueFull.
+	enqueueTimeout time.Duration
+}
+
+var _ Queue = (*ChannelQueue)(nil)
+
+func NewChannelQueue(size int, enqueueTimeout time.Duration) *ChannelQueue {
+	return &ChannelQueue{jobs: make(chan TransferJob, size), enqueueTimeout: enqueueTimeout}
+}
+
+func (q *ChannelQueue) Publish(ctx context.Context, job TransferJob) error {
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+	}
+
+	timer := time.NewTimer(q.enqueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case q.jobs <- job:
+		return nil
+	case <-timer.C:
+		return ErrQueueFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *ChannelQueue) Consume(ctx context.Context, handler Handler, deadLetter DeadLetterHandler) error {
+	for {
+		select {
+		case job := <-q.jobs:
+			// There is nothing to redeliver to; both handlers log their own failures.
+			if err := handler(ctx, job); err != nil {
+				_ = deadLetter(ctx, job, err.Error())
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (q *ChannelQueue) Depth(context.Context) (int, error) {
+	return len(q.jobs), nil
+}
+
+// Close leaves the channel open so late publishers fail with ErrQueueFull
+// instead of panicking.
+func (q *ChannelQueue) Close() error {
+	return nil
+}
@@ -0,0 +1,53 @@
+package queue
+
+import (
+	"github.com/google/uuid"
+	"sync"
+)
+
+// Completions tells callers waiting on a transfer that a worker in this
+// process has finished its job. It carries no result: waiters read the
+// transaction to learn its final status.
+type Completions struct {
+	mu      sync.Mutex
+	waiters map[uuid.UUID]map[chan struct{}]struct{}
+}
+
+func NewCompletions() *Completions {
+	return &Completions{waiters: make(map[uuid.UUID]map[chan struct{}]struct{})}
+}
+
+// Wait returns a channel that is closed when the job for transactionId
+// completes, and a function that stops waiting. Call Wait before checking
+// the transaction so a completion in between is not missed.
+func (c *Completions) Wait(transactionId uuid.UUID) (<-chan struct{}, func()) {
+	done := make(chan struct{})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.waiters[transactionId] == nil {
+		c.waiters[transactionId] = make(map[chan struct{}]struct{})
+	}
+	c.waiters[transactionId][done] = struct{}{}
+
+	return done, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if waiters, ok := c.waiters[transactionId]; ok {
+			delete(waiters, done)
+			if len(waiters) == 0 {
+				delete(c.waiters, transactionId)
+			}
+		}
+	}
+}
+
+// Complete wakes everyone waiting on transactionId.
+func (c *Completions) Complete(transactionId uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for done := range c.waiters[transactionId] {
+		close(done)
+	}
+	delete(c.waiters, transactionId)
+}
@@ -3,8 +3,14 @@ package queue
 import "github.com/google/uuid"
 
 type TransferJob struct {
-	SenderId      uuid.UUID
-	ReceiverId    uuid.UUID
-	Amount        float64
-	TransactionId uuid.UUID
+	SenderId      uuid.UUID `json:"sender_id"`
+	ReceiverId    uuid.UUID `json:"receiver_id"`
+	Amount        float64   `json:"amount" redact:"amount"`
+	TransactionId uuid.UUID `json:"transaction_id"`
+	// TraceContext carries the publisher's trace across the queue so the
+	// worker's spans join the request that created the transfer.
+	TraceContext map[string]string `json:"trace_context,omitempty"`
+	// RequestId is the id of the request that created the transfer, so the
+	// worker's log lines can be found with the request's.
+	RequestId string `json:"request_id,omitempty"`
 }
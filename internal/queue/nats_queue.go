@@ -0,0 +1,145 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"moneyTransfer/pkg/logger"
+	"moneyTransfer/pkg/metrics"
+	"time"
+)
+
+// natsConsumerName is the durable consumer shared by all workers, so each job
+// is delivered to one of them.
+const natsConsumerName = "transfer-workers"
+
+// natsErrStreamFull is the JetStream store error returned when a DiscardNew
+// stream has reached MaxMsgs.
+const natsErrStreamFull jetstream.ErrorCode = 10077
+
+// NATSQueue publishes jobs to a JetStream work-queue stream. Jobs survive
+// restarts of the service; a job that is not acknowledged within ackWait, or
+// whose handler failed, is redelivered, up to maxAttempts deliveries. A job
+// that fails its last attempt is terminated, which JetStream reports with a
+// MSG_TERMINATED advisory.
+type NATSQueue struct {
+	conn        *nats.Conn
+	stream      jetstream.Stream
+	js          jetstream.JetStream
+	subject     string
+	ackWait     time.Duration
+	maxAttempts int
+	log         logger.Logger
+}
+
+var _ Queue = (*NATSQueue)(nil)
+
+// NewNATSQueue connects to url and creates or updates the stream. size caps
+// the number of waiting jobs; publishing beyond it fails with ErrQueueFull.
+func NewNATSQueue(ctx context.Context, url, stream string, size int, ackWait time.Duration, maxAttempts int, logger logger.Logger) (*NATSQueue, error) {
+	conn, err := nats.Connect(url, nats.Name("money-transfer"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to nats: %w", err)
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	subject := stream + ".jobs"
+	s, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:      stream,
+		Subjects:  []string{subject},
+		Retention: jetstream.WorkQueuePolicy,
+		MaxMsgs:   int64(size),
+		Discard:   jetstream.DiscardNew,
+		Storage:   jetstream.FileStorage,
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create stream %s: %w", stream, err)
+	}
+
+	return &NATSQueue{conn: conn, stream: s, js: js, subject: subject, ackWait: ackWait, maxAttempts: maxAttempts, log: logger}, nil
+}
+
+func (q *NATSQueue) Publish(ctx context.Context, job TransferJob) error {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.js.Publish(ctx, q.subject, payload, jetstream.WithMsgID(job.TransactionId.String()))
+	var apiErr *jetstream.APIError
+	if errors.As(err, &apiErr) && apiErr.ErrorCode == natsErrStreamFull {
+		return ErrQueueFull
+	}
+	return err
+}
+
+func (q *NATSQueue) Consume(ctx context.Context, handler Handler, deadLetter DeadLetterHandler) error {
+	consumer, err := q.stream.CreateOrUpdateConsumer(ctx, jetstream.ConsumerConfig{
+		Durable:    natsConsumerName,
+		AckPolicy:  jetstream.AckExplicitPolicy,
+		AckWait:    q.ackWait,
+		MaxDeliver: q.maxAttempts,
+	})
+	if err != nil {
+		return err
+	}
+
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		var job TransferJob
+		if err := json.Unmarshal(msg.Data(), &job); err != nil {
+			q.log.Error("dropping malformed transfer job", "error", err)
+			msg.Term()
+			return
+		}
+
+		if err := handler(ctx, job); err != nil {
+			if meta, metaErr := msg.Metadata(); metaErr == nil && meta.NumDelivered >= uint64(q.maxAttempts) {
+				q.log.Error("transfer job exhausted its attempts, dead-lettering it", "transaction_id", job.TransactionId,
+					"attempts", meta.NumDelivered, "error", err)
+				// JetStream would not deliver the job again anyway, so it is
+				// terminated even when deadLetter fails.
+				if err := deadLetter(ctx, job, err.Error()); err != nil {
+					q.log.Error("failed to dead-letter transfer job", "transaction_id", job.TransactionId, "error", err)
+				}
+				metrics.JobsDeadLettered.WithLabelValues("nats").Inc()
+				msg.Term()
+				return
+			}
+			q.log.Warn("transfer job failed, it will be retried", "transaction_id", job.TransactionId, "error", err)
+			metrics.JobRetries.WithLabelValues("nats").Inc()
+			msg.Nak()
+			return
+		}
+		msg.Ack()
+	})
+	if err != nil {
+		return err
+	}
+
+	<-ctx.Done()
+	consumeCtx.Stop()
+	return nil
+}
+
+// Depth counts the stream's messages; the work-queue stream deletes a job
+// once it is acknowledged.
+func (q *NATSQueue) Depth(ctx context.Context) (int, error) {
+	info, err := q.stream.Info(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return int(info.State.Msgs), nil
+}
+
+func (q *NATSQueue) Close() error {
+	return q.conn.Drain()
+}
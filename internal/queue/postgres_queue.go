@@ -0,0 +1,168 @@
+package queue
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"moneyTransfer/pkg/logger"
+	"moneyTransfer/pkg/metrics"
+	"time"
+)
+
+// publishLockKey is the advisory lock serialising publishes, so two of them
+// cannot both take the last free slot.
+const publishLockKey = 7_294_003
+
+// PostgresQueue keeps jobs in the transfer_jobs table, at most size of them. A
+// consumer claims the oldest visible job with SKIP LOCKED and hides it for
+// visibilityTimeout; the row is deleted once handled, so a job whose worker
+// failed or died becomes visible again and is redelivered. After maxAttempts
+// deliveries the job is moved to transfer_jobs_dead instead.
+type PostgresQueue struct {
+	db                *sql.DB
+	size              int
+	pollInterval      time.Duration
+	visibilityTimeout time.Duration
+	maxAttempts       int
+	log               logger.Logger
+}
+
+var _ Queue = (*PostgresQueue)(nil)
+
+func NewPostgresQueue(db *sql.DB, size int, pollInterval, visibilityTimeout time.Duration, maxAttempts int, logger logger.Logger) *PostgresQueue {
+	return &PostgresQueue{db: db, size: size, pollInterval: pollInterval, visibilityTimeout: visibilityTimeout, maxAttempts: maxAttempts, log: logger}
+}
+
+// Publish stores job unless size jobs are already waiting, in which case it
+// fails with ErrQueueFull.
+func (q *PostgresQueue) Publish(ctx context.Context, job TransferJob) error {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, publishLockKey); err != nil {
+		return err
+	}
+
+	var depth int
+	if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM transfer_jobs`).Scan(&depth); err != nil {
+		return err
+	}
+	if depth >= q.size {
+		return ErrQueueFull
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO transfer_jobs (payload) VALUES ($1)`, string(payload)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Consume polls for jobs, waiting pollInterval whenever the table is empty.
+func (q *PostgresQueue) Consume(ctx context.Context, handler Handler, deadLetter DeadLetterHandler) error {
+	for {
+		claimed, err := q.processNext(ctx, handler, deadLetter)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			q.log.Error("failed to claim transfer job", "error", err)
+		}
+		if claimed {
+			continue
+		}
+
+		select {
+		case <-time.After(q.pollInterval):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (q *PostgresQueue) Depth(ctx context.Context) (int, error) {
+	var depth int
+	err := q.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM transfer_jobs`).Scan(&depth)
+	return depth, err
+}
+
+func (q *PostgresQueue) Close() error {
+	return nil
+}
+
+// processNext claims and handles one job. It reports whether a job was found.
+func (q *PostgresQueue) processNext(ctx context.Context, handler Handler, deadLetter DeadLetterHandler) (bool, error) {
+	query := `UPDATE transfer_jobs SET attempts = attempts + 1, available_at = NOW() + $1 * INTERVAL '1 millisecond'
+              WHERE id = (SELECT id FROM transfer_jobs WHERE available_at <= NOW() ORDER BY id LIMIT 1 FOR UPDATE SKIP LOCKED)
+              RETURNING id, payload, attempts`
+
+	var id int64
+	var payload string
+	var attempts int
+	err := q.db.QueryRowContext(ctx, query, q.visibilityTimeout.Milliseconds()).Scan(&id, &payload, &attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// The handler's outcome must be recorded even when shutdown cancels ctx.
+	ackCtx := context.WithoutCancel(ctx)
+
+	var job TransferJob
+	if err := json.Unmarshal([]byte(payload), &job); err != nil {
+		q.log.Error("dropping malformed transfer job", "job_id", id, "error", err)
+		return true, q.delete(ackCtx, id)
+	}
+
+	if attempts > q.maxAttempts {
+		// The previous delivery never reported back, e.g. its worker died.
+		q.log.Error("transfer job exhausted its attempts, dead-lettering it", "job_id", id, "attempts", attempts-1)
+		return true, q.deadLetter(ackCtx, id, job, "worker did not report back", deadLetter)
+	}
+
+	if err := handler(ctx, job); err != nil {
+		if attempts >= q.maxAttempts {
+			q.log.Error("transfer job exhausted its attempts, dead-lettering it", "job_id", id, "attempts", attempts, "error", err)
+			return true, q.deadLetter(ackCtx, id, job, err.Error(), deadLetter)
+		}
+		q.log.Warn("transfer job failed, it will be retried", "job_id", id, "attempts", attempts, "error", err)
+		metrics.JobRetries.WithLabelValues("postgres").Inc()
+		return true, nil
+	}
+
+	return true, q.delete(ackCtx, id)
+}
+
+// deadLetter hands job to handler, then moves it to transfer_jobs_dead, where
+// it waits for an operator instead of being retried. When handler fails the
+// job stays, to be dead-lettered again once it is visible.
+func (q *PostgresQueue) deadLetter(ctx context.Context, id int64, job TransferJob, lastError string, handler DeadLetterHandler) error {
+	if err := handler(ctx, job, lastError); err != nil {
+		return err
+	}
+
+	query := `WITH job AS (DELETE FROM transfer_jobs WHERE id = $1 RETURNING id, payload, attempts, created_at)
+              INSERT INTO transfer_jobs_dead (id, payload, attempts, last_error, created_at)
+              SELECT id, payload, attempts, $2, created_at FROM job`
+
+	if _, err := q.db.ExecContext(ctx, query, id, lastError); err != nil {
+		return err
+	}
+	metrics.JobsDeadLettered.WithLabelValues("postgres").Inc()
+	return nil
+}
+
+func (q *PostgresQueue) delete(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, `DELETE FROM transfer_jobs WHERE id = $1`, id)
+	return err
+}
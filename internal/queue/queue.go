@@ -1,7 +1,45 @@
+// Package queue carries transfer jobs from the API to the workers. The
+// transport is pluggable: an in-process channel, a Postgres table or a NATS
+// JetStream stream.
 package queue
 
-var JobsChan = make(chan TransferJob, 100)
+import (
+	"context"
+	"moneyTransfer/internal/domain/apperrors"
+	"time"
+)
 
-func Enqueue(job TransferJob) {
-	JobsChan <- job
+// Publisher hands a transfer job to the workers.
+type Publisher interface {
+	Publish(ctx context.Context, job TransferJob) error
 }
+
+// Handler processes one delivered job. Backends with acknowledgements
+// redeliver a job whose handler returned an error.
+type Handler func(ctx context.Context, job TransferJob) error
+
+// DeadLetterHandler is told about a job that will not be delivered again,
+// with the reason it was given up on.
+type DeadLetterHandler func(ctx context.Context, job TransferJob, reason string) error
+
+// Consumer delivers jobs to handler until ctx is done, and hands the jobs it
+// gives up on to deadLetter. Several Consume calls may run at once; each job
+// goes to one of them.
+type Consumer interface {
+	Consume(ctx context.Context, handler Handler, deadLetter DeadLetterHandler) error
+}
+
+// Queue is a transport for transfer jobs.
+type Queue interface {
+	Publisher
+	Consumer
+	// Depth reports how many jobs are waiting, including any a worker holds
+	// but has not yet acknowledged.
+	Depth(ctx context.Context) (int, error)
+	Close() error
+}
+
+var ErrQueueFull = apperrors.Unavailable(apperrors.CodeQueueFull, "transfer queue is full, try again later", RetryAfter)
+
+// RetryAfter is the delay suggested to clients whose job was rejected.
+const RetryAfter = 2 * time.Second
@@ -2,66 +2,291 @@ package queue
 
 import (
 	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"moneyTransfer/internal/domain/apperrors"
 	"moneyTransfer/internal/domain/contracts"
 	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/tracing"
 	"moneyTransfer/pkg/logger"
+	"moneyTransfer/pkg/metrics"
+	"sync"
 	"time"
 )
 
-func ProcessJob(ctx context.Context, job TransferJob, userRepo contracts.UserRepository, transferRepo contracts.TransferRepository, log logger.Logger) error {
+// tracerName identifies the spans started in this package.
+const tracerName = "moneyTransfer/internal/queue"
+
+// Failure reasons recorded in transfers_total.
+const (
+	reasonInvalidAmount              = "invalid_amount"
+	reasonSenderBalanceUnavailable   = "sender_balance_unavailable"
+	reasonInsufficientFunds          = "insufficient_funds"
+	reasonReceiverBalanceUnavailable = "receiver_balance_unavailable"
+	reasonDebitFailed                = "debit_failed"
+	reasonCreditFailed               = "credit_failed"
+	reasonDeadLettered               = "dead_lettered"
+)
+
+// ProcessJob applies job in a single transaction: the debit, the credit and
+// the SUCCESS status commit together or not at all. The status only changes
+// from PENDING, so a job that was already applied, or is being applied by
+// another delivery, rolls back and is skipped. The debit is a guarded atomic
+// update, so concurrent jobs on the same account can neither lose an update
+// nor overdraw it. A job that cannot be applied is marked FAILED, except when
+// the database aborted the transaction over a conflict: that error is
+// returned so the job is delivered again.
+func ProcessJob(ctx context.Context, job TransferJob, userRepo contracts.UserRepository, transferRepo contracts.TransferRepository, txManager contracts.TxManager, log logger.Logger) error {
+	fail := func(reason string) error {
+		err := finish(ctx, job, transferRepo, model.StatusFailed, reason)
+		if errors.Is(err, apperrors.ErrConflict) {
+			log.Info("transfer already processed", "transaction_id", job.TransactionId)
+			return nil
+		}
+		return err
+	}
+
 	if job.Amount <= 0 {
 		log.Error("amount must be greater than zero", "amount", job.Amount)
-		return transferRepo.UpdateTransactionStatus(ctx, job.TransactionId.String(), model.StatusFailed)
+		return fail(reasonInvalidAmount)
 	}
 
-	senderBalance, err := userRepo.GetBalance(ctx, job.SenderId.String())
+	var reason string
+	err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if reason, err = moveFunds(ctx, job, userRepo, log); err != nil {
+			return err
+		}
+		return transferRepo.FinishTransaction(ctx, job.TransactionId.String(), model.StatusSuccess)
+	})
+	if errors.Is(err, apperrors.ErrUnavailable) {
+		log.Warn("transfer aborted by a concurrent update, retrying", "transaction_id", job.TransactionId, "error", err)
+		return err
+	}
+	if reason != "" {
+		return fail(reason)
+	}
+	if errors.Is(err, apperrors.ErrConflict) {
+		log.Info("transfer already processed", "transaction_id", job.TransactionId)
+		return nil
+	}
 	if err != nil {
-		log.Error("failed to get sender balance", "error", err)
-		return transferRepo.UpdateTransactionStatus(ctx, job.TransactionId.String(), model.StatusFailed)
+		log.Error("failed to update transaction status", "error", err)
+		return err
 	}
 
-	if senderBalance < job.Amount {
-		log.Error("insufficient funds", "balance", senderBalance, "amount", job.Amount)
-		return transferRepo.UpdateTransactionStatus(ctx, job.TransactionId.String(), model.StatusFailed)
+	count(job, model.StatusSuccess, "")
+	log.Info("transfer completed", "transaction_id", job.TransactionId)
+	return nil
+}
+
+// moveFunds debits the sender and credits the receiver. When either fails it
+// also returns the reason to record against the transfer. Both accounts are
+// locked before the first write, because each write also takes the audit
+// chain lock: two opposite transfers that locked one row each and then queued
+// on the chain would deadlock.
+func moveFunds(ctx context.Context, job TransferJob, userRepo contracts.UserRepository, log logger.Logger) (string, error) {
+	if err := userRepo.Lock(ctx, job.SenderId.String(), job.ReceiverId.String()); err != nil {
+		log.Error("failed to lock accounts", "error", err)
+		return reasonDebitFailed, err
 	}
 
-	receiverBalance, err := userRepo.GetBalance(ctx, job.ReceiverId.String())
-	if err != nil {
-		log.Error("failed to get receiver balance", "error", err)
-		return transferRepo.UpdateTransactionStatus(ctx, job.TransactionId.String(), model.StatusFailed)
+	if reason, err := debit(ctx, job, userRepo, log); err != nil {
+		return reason, err
 	}
+	return credit(ctx, job, userRepo, log)
+}
 
-	err = userRepo.UpdateBalance(ctx, job.SenderId.String(), senderBalance-job.Amount)
-	if err != nil {
+func debit(ctx context.Context, job TransferJob, userRepo contracts.UserRepository, log logger.Logger) (string, error) {
+	if _, _, err := userRepo.Debit(ctx, job.SenderId.String(), job.Amount); err != nil {
+		switch {
+		case errors.Is(err, apperrors.ErrInsufficientFunds):
+			log.Error("insufficient funds", "amount", job.Amount)
+			return reasonInsufficientFunds, err
+		case errors.Is(err, apperrors.ErrNotFound):
+			log.Error("failed to get sender balance", "error", err)
+			return reasonSenderBalanceUnavailable, err
+		}
 		log.Error("failed to update sender balance", "error", err)
-		return transferRepo.UpdateTransactionStatus(ctx, job.TransactionId.String(), model.StatusFailed)
+		return reasonDebitFailed, err
 	}
+	return "", nil
+}
 
-	err = userRepo.UpdateBalance(ctx, job.ReceiverId.String(), receiverBalance+job.Amount)
-	if err != nil {
+func credit(ctx context.Context, job TransferJob, userRepo contracts.UserRepository, log logger.Logger) (string, error) {
+	if _, _, err := userRepo.Credit(ctx, job.ReceiverId.String(), job.Amount); err != nil {
+		if errors.Is(err, apperrors.ErrNotFound) {
+			log.Error("failed to get receiver balance", "error", err)
+			return reasonReceiverBalanceUnavailable, err
+		}
 		log.Error("failed to update receiver balance", "error", err)
-		return transferRepo.UpdateTransactionStatus(ctx, job.TransactionId.String(), model.StatusFailed)
+		return reasonCreditFailed, err
+	}
+
+	return "", nil
+}
+
+// finish records the final status of the job's transaction and, once it is
+// stored, counts the transfer under that status.
+func finish(ctx context.Context, job TransferJob, transferRepo contracts.TransferRepository, status, reason string) error {
+	if err := transferRepo.FinishTransaction(ctx, job.TransactionId.String(), status); err != nil {
+		return err
+	}
+	count(job, status, reason)
+	return nil
+}
+
+func count(job TransferJob, status, reason string) {
+	metrics.TransfersTotal.WithLabelValues(status, reason).Inc()
+	metrics.TransferAmount.WithLabelValues(status).Observe(job.Amount)
+}
+
+// Worker applies the transfer jobs delivered by a Consumer.
+type Worker struct {
+	consumer     Consumer
+	userRepo     contracts.UserRepository
+	transferRepo contracts.TransferRepository
+	txManager    contracts.TxManager
+	completions  *Completions
+	// processingDelay emulates slow downstream processing after each job.
+	processingDelay time.Duration
+	log             logger.Logger
+
+	// running counts the active Run loops and started holds when each job in
+	// hand was picked up, keyed by a sequence number. Both feed Heartbeat.
+	mu      sync.Mutex
+	running int
+	nextJob uint64
+	started map[uint64]time.Time
+}
+
+func NewWorker(consumer Consumer, userRepo contracts.UserRepository, transferRepo contracts.TransferRepository, txManager contracts.TxManager, completions *Completions, processingDelay time.Duration, logger logger.Logger) *Worker {
+	return &Worker{consumer: consumer, userRepo: userRepo, transferRepo: transferRepo, txManager: txManager, completions: completions, processingDelay: processingDelay, log: logger, started: map[uint64]time.Time{}}
+}
+
+// Run consumes jobs until ctx is done. A Worker may be run several times
+// concurrently.
+func (w *Worker) Run(ctx context.Context) error {
+	w.mu.Lock()
+	w.running++
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		w.running--
+		w.mu.Unlock()
+	}()
+
+	return w.consumer.Consume(ctx, w.Handle, w.DeadLetter)
+}
+
+// Heartbeat reports how many Run loops are active and when the oldest job in
+// hand was picked up, or the zero time when none is.
+func (w *Worker) Heartbeat() (running int, oldestJob time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, start := range w.started {
+		if oldestJob.IsZero() || start.Before(oldestJob) {
+			oldestJob = start
+		}
 	}
+	return w.running, oldestJob
+}
 
-	err = transferRepo.UpdateTransactionStatus(ctx, job.TransactionId.String(), model.StatusSuccess)
+func (w *Worker) begin() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nextJob++
+	w.started[w.nextJob] = time.Now()
+	return w.nextJob
+}
+
+func (w *Worker) end(job uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.started, job)
+}
+
+// Handle processes one job. Jobs whose transaction is no longer pending are
+// skipped up front; ProcessJob settles the transaction in the same database
+// transaction as the balance updates, so a redelivered job that gets past this
+// check is still not applied twice.
+func (w *Worker) Handle(ctx context.Context, job TransferJob) error {
+	ctx, span := otel.Tracer(tracerName).Start(tracing.Extract(ctx, job.TraceContext), "TransferJob process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("transfer.id", job.TransactionId.String())))
+	metrics.JobsInFlight.Inc()
+	slot := w.begin()
+	err := w.handle(ctx, job)
+	w.end(slot)
+	metrics.JobsInFlight.Dec()
+	tracing.End(span, err)
+	return err
+}
+
+// DeadLetter marks the transaction of a job the queue gave up on FAILED, so it
+// does not stay PENDING. A transaction that was settled meanwhile is left
+// as it is.
+func (w *Worker) DeadLetter(ctx context.Context, job TransferJob, reason string) error {
+	ctx = jobContext(ctx, job)
+	log := logger.WithContext(ctx, w.log)
+
+	err := finish(ctx, job, w.transferRepo, model.StatusFailed, reasonDeadLettered)
+	if errors.Is(err, apperrors.ErrConflict) || errors.Is(err, apperrors.ErrNotFound) {
+		return nil
+	}
 	if err != nil {
-		log.Error("failed to update transaction status", "error", err)
+		log.Error("failed to mark dead-lettered transfer failed", "transaction_id", job.TransactionId, "error", err)
 		return err
 	}
 
-	log.Info("transfer completed", "transaction_id", job.TransactionId)
+	log.Warn("transfer failed after its job was dead-lettered", "transaction_id", job.TransactionId, "reason", reason)
+	w.completions.Complete(job.TransactionId)
 	return nil
 }
 
-func StartWorker(userRepo contracts.UserRepository, transferRepo contracts.TransferRepository, log logger.Logger) {
-	go func() {
-		for job := range JobsChan {
-			ctx := context.Background()
-			err := ProcessJob(ctx, job, userRepo, transferRepo, log)
-			if err != nil {
-				log.Error("failed to process job", "error", err)
-			}
-			time.Sleep(500 * time.Millisecond) //emulate long processing
-		}
-	}()
+// jobContext carries the job's actor, request id and log fields.
+func jobContext(ctx context.Context, job TransferJob) context.Context {
+	ctx = model.WithActor(ctx, model.ActorWorker)
+	ctx = logger.WithFields(ctx, "user_id", job.SenderId, "transaction_id", job.TransactionId)
+	if job.RequestId != "" {
+		ctx = model.WithRequestId(ctx, job.RequestId)
+		ctx = logger.WithFields(ctx, "request_id", job.RequestId)
+	}
+	return ctx
+}
+
+func (w *Worker) handle(ctx context.Context, job TransferJob) error {
+	ctx = jobContext(ctx, job)
+	log := logger.WithContext(ctx, w.log)
+
+	tx, err := w.transferRepo.GetTransactionById(ctx, job.TransactionId.String())
+	if errors.Is(err, apperrors.ErrNotFound) {
+		log.Warn("skipping job for unknown transaction", "transaction_id", job.TransactionId)
+		return nil
+	}
+	if err != nil {
+		log.Error("failed to load transaction for job", "transaction_id", job.TransactionId, "error", err)
+		return err
+	}
+	if tx.Status != model.StatusPending {
+		log.Info("skipping already processed job", "transaction_id", job.TransactionId, "status", tx.Status)
+		return nil
+	}
+
+	err = ProcessJob(ctx, job, w.userRepo, w.transferRepo, w.txManager, log)
+	if err != nil {
+		log.Error("failed to process job", "error", err)
+	} else {
+		metrics.TransferProcessingDuration.Observe(time.Since(tx.CreatedAt).Seconds())
+	}
+	w.completions.Complete(job.TransactionId)
+
+	select {
+	case <-time.After(w.processingDelay): //emulate long processing
+	case <-ctx.Done():
+	}
+
+	return err
 }
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"strings"
+	"time"
+)
+
+type APIKeyRepo struct {
+	db *sql.DB
+}
+
+var _ contracts.APIKeyRepository = (*APIKeyRepo)(nil)
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepo {
+	return &APIKeyRepo{db}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at`
+
+func (r *APIKeyRepo) Create(ctx context.Context, key model.APIKey) error {
+	query := `INSERT INTO api_keys (id, name, prefix, key_hash, scopes, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		key.Id, key.Name, key.Prefix, key.KeyHash, strings.Join(key.Scopes, " "), key.CreatedAt)
+	return err
+}
+
+func (r *APIKeyRepo) GetById(ctx context.Context, id string) (model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+	key, err := scanAPIKey(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	return key, notFound(err, apperrors.CodeAPIKeyNotFound, "api key not found")
+}
+
+func (r *APIKeyRepo) GetByHash(ctx context.Context, keyHash string) (model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+	key, err := scanAPIKey(conn(ctx, r.db).QueryRowContext(ctx, query, keyHash))
+	return key, notFound(err, apperrors.CodeAPIKeyNotFound, "api key not found")
+}
+
+func (r *APIKeyRepo) List(ctx context.Context) ([]model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY created_at`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return keys, err
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepo) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, revokedAt, id)
+	if err != nil {
+		return err
+	}
+
+	err = requireAffected(res, apperrors.CodeAPIKeyNotFound, "api key not found")
+	if errors.Is(err, apperrors.ErrNotFound) {
+		if _, err := r.GetById(ctx, id); err != nil {
+			return err
+		}
+		return apperrors.Conflict(apperrors.CodeAPIKeyRevoked, "api key already revoked")
+	}
+	return err
+}
+
+func (r *APIKeyRepo) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $1 WHERE id = $2`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, usedAt, id)
+	return err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row rowScanner) (model.APIKey, error) {
+	var key model.APIKey
+	var scopes string
+	var lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(&key.Id, &key.Name, &key.Prefix, &key.KeyHash, &scopes, &key.CreatedAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return model.APIKey{}, err
+	}
+
+	key.Scopes = strings.Fields(scopes)
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return key, nil
+}
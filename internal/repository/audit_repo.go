@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"time"
+)
+
+// auditChainLockKey is the advisory lock serialising appends to the chain.
+const auditChainLockKey = 7_294_001
+
+type AuditRepo struct {
+	db *sql.DB
+	// lockChain is run before reading the chain head; empty when the
+	// database's write transactions already exclude each other.
+	lockChain string
+}
+
+var _ contracts.AuditRepository = (*AuditRepo)(nil)
+
+func NewAuditRepository(db *sql.DB) *AuditRepo {
+	return &AuditRepo{db: db, lockChain: `SELECT pg_advisory_xact_lock($1)`}
+}
+
+// NewLocklessAuditRepository is for databases that serialise write
+// transactions themselves, such as SQLite opened with immediate transactions.
+func NewLocklessAuditRepository(db *sql.DB) *AuditRepo {
+	return &AuditRepo{db: db}
+}
+
+func (r *AuditRepo) Append(ctx context.Context, entry model.AuditEntry) (model.AuditEntry, error) {
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+
+	err := withinTransaction(ctx, r.db, func(ctx context.Context) error {
+		db := conn(ctx, r.db)
+
+		if r.lockChain != "" {
+			if _, err := db.ExecContext(ctx, r.lockChain, auditChainLockKey); err != nil {
+				return err
+			}
+		}
+
+		var prevHash string
+		err := db.QueryRowContext(ctx, `SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).Scan(&prevHash)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		entry.PrevHash = prevHash
+		entry.Hash = entry.ComputeHash()
+
+		query := `INSERT INTO audit_log (actor, action, entity_type, entity_id, before_value, after_value, created_at, prev_hash, hash)
+                  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+
+		return db.QueryRowContext(ctx, query,
+			entry.Actor, entry.Action, entry.EntityType, entry.EntityId,
+			nullableJSON(entry.Before), nullableJSON(entry.After),
+			entry.CreatedAt, entry.PrevHash, entry.Hash,
+		).Scan(&entry.Id)
+	})
+	if err != nil {
+		return model.AuditEntry{}, err
+	}
+
+	return entry, nil
+}
+
+func (r *AuditRepo) List(ctx context.Context, afterId int64, limit int) ([]model.AuditEntry, error) {
+	query := `SELECT id, actor, action, entity_type, entity_id, before_value, after_value, created_at, prev_hash, hash
+              FROM audit_log WHERE id > $1 ORDER BY id LIMIT $2`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []model.AuditEntry
+
+	for rows.Next() {
+		var entry model.AuditEntry
+		var before, after sql.NullString
+		if err := rows.Scan(
+			&entry.Id,
+			&entry.Actor,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityId,
+			&before,
+			&after,
+			&entry.CreatedAt,
+			&entry.PrevHash,
+			&entry.Hash,
+		); err != nil {
+			return entries, err
+		}
+		if before.Valid {
+			entry.Before = []byte(before.String)
+		}
+		if after.Valid {
+			entry.After = []byte(after.String)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return entries, err
+	}
+
+	return entries, nil
+}
+
+func nullableJSON(value []byte) sql.NullString {
+	if value == nil {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: string(value), Valid: true}
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"time"
+)
+
+// The audited repositories wrap the money-moving and admin writes of another
+// repository so that each change and its audit entry commit together.
+// Reads pass straight through to the wrapped repository.
+
+type AuditedUserRepo struct {
+	contracts.UserRepository
+	audit     contracts.AuditRepository
+	txManager contracts.TxManager
+}
+
+var _ contracts.UserRepository = (*AuditedUserRepo)(nil)
+
+func NewAuditedUserRepository(inner contracts.UserRepository, audit contracts.AuditRepository, txManager contracts.TxManager) *AuditedUserRepo {
+	return &AuditedUserRepo{UserRepository: inner, audit: audit, txManager: txManager}
+}
+
+type balanceSnapshot struct {
+	Balance float64 `json:"balance"`
+}
+
+func (r *AuditedUserRepo) UpdateBalance(ctx context.Context, userId string, newBalance float64) error {
+	return r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := r.UserRepository.GetBalance(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		if err := r.UserRepository.UpdateBalance(ctx, userId, newBalance); err != nil {
+			return err
+		}
+
+		return appendAudit(ctx, r.audit, model.AuditActionBalanceUpdated, model.AuditEntityUser, userId,
+			balanceSnapshot{before}, balanceSnapshot{newBalance})
+	})
+}
+
+func (r *AuditedUserRepo) Debit(ctx context.Context, userId string, amount float64) (float64, float64, error) {
+	return r.change(ctx, userId, amount, r.UserRepository.Debit)
+}
+
+func (r *AuditedUserRepo) Credit(ctx context.Context, userId string, amount float64) (float64, float64, error) {
+	return r.change(ctx, userId, amount, r.UserRepository.Credit)
+}
+
+// change applies a debit or credit and records the balance before and after.
+func (r *AuditedUserRepo) change(ctx context.Context, userId string, amount float64,
+	apply func(ctx context.Context, userId string, amount float64) (float64, float64, error)) (before, after float64, err error) {
+	err = r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if before, after, err = apply(ctx, userId, amount); err != nil {
+			return err
+		}
+
+		return appendAudit(ctx, r.audit, model.AuditActionBalanceUpdated, model.AuditEntityUser, userId,
+			balanceSnapshot{before}, balanceSnapshot{after})
+	})
+	return before, after, err
+}
+
+type AuditedTransferRepo struct {
+	contracts.TransferRepository
+	audit     contracts.AuditRepository
+	txManager contracts.TxManager
+}
+
+var _ contracts.TransferRepository = (*AuditedTransferRepo)(nil)
+
+func NewAuditedTransferRepository(inner contracts.TransferRepository, audit contracts.AuditRepository, txManager contracts.TxManager) *AuditedTransferRepo {
+	return &AuditedTransferRepo{TransferRepository: inner, audit: audit, txManager: txManager}
+}
+
+func (r *AuditedTransferRepo) CreateTransfer(ctx context.Context, tx model.Transaction) error {
+	return r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := r.TransferRepository.CreateTransfer(ctx, tx); err != nil {
+			return err
+		}
+
+		return appendAudit(ctx, r.audit, model.AuditActionTransactionCreated, model.AuditEntityTransaction, tx.Id.String(), nil, tx)
+	})
+}
+
+func (r *AuditedTransferRepo) FinishTransaction(ctx context.Context, txId, status string) error {
+	return r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := r.TransferRepository.GetTransactionById(ctx, txId)
+		if err != nil {
+			return err
+		}
+
+		if err := r.TransferRepository.FinishTransaction(ctx, txId, status); err != nil {
+			return err
+		}
+
+		after := before
+		after.Status = status
+		return appendAudit(ctx, r.audit, model.AuditActionTransactionStatusChanged, model.AuditEntityTransaction, txId, before, after)
+	})
+}
+
+func (r *AuditedTransferRepo) DeletePendingTransaction(ctx context.Context, txId string) error {
+	return r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := r.TransferRepository.GetTransactionById(ctx, txId)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if before.Status != model.StatusPending {
+			return nil
+		}
+
+		if err := r.TransferRepository.DeletePendingTransaction(ctx, txId); err != nil {
+			return err
+		}
+
+		return appendAudit(ctx, r.audit, model.AuditActionTransactionDeleted, model.AuditEntityTransaction, txId, before, nil)
+	})
+}
+
+type AuditedAPIKeyRepo struct {
+	contracts.APIKeyRepository
+	audit     contracts.AuditRepository
+	txManager contracts.TxManager
+}
+
+var _ contracts.APIKeyRepository = (*AuditedAPIKeyRepo)(nil)
+
+func NewAuditedAPIKeyRepository(inner contracts.APIKeyRepository, audit contracts.AuditRepository, txManager contracts.TxManager) *AuditedAPIKeyRepo {
+	return &AuditedAPIKeyRepo{APIKeyRepository: inner, audit: audit, txManager: txManager}
+}
+
+func (r *AuditedAPIKeyRepo) Create(ctx context.Context, key model.APIKey) error {
+	return r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := r.APIKeyRepository.Create(ctx, key); err != nil {
+			return err
+		}
+
+		return appendAudit(ctx, r.audit, model.AuditActionAPIKeyCreated, model.AuditEntityAPIKey, key.Id.String(), nil, key)
+	})
+}
+
+func (r *AuditedAPIKeyRepo) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	return r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := r.APIKeyRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := r.APIKeyRepository.Revoke(ctx, id, revokedAt); err != nil {
+			return err
+		}
+
+		after := before
+		after.RevokedAt = &revokedAt
+		return appendAudit(ctx, r.audit, model.AuditActionAPIKeyRevoked, model.AuditEntityAPIKey, id, before, after)
+	})
+}
+
+type AuditedWebhookRepo struct {
+	contracts.WebhookRepository
+	audit     contracts.AuditRepository
+	txManager contracts.TxManager
+}
+
+var _ contracts.WebhookRepository = (*AuditedWebhookRepo)(nil)
+
+func NewAuditedWebhookRepository(inner contracts.WebhookRepository, audit contracts.AuditRepository, txManager contracts.TxManager) *AuditedWebhookRepo {
+	return &AuditedWebhookRepo{WebhookRepository: inner, audit: audit, txManager: txManager}
+}
+
+func (r *AuditedWebhookRepo) Create(ctx context.Context, webhook model.Webhook) error {
+	return r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := r.WebhookRepository.Create(ctx, webhook); err != nil {
+			return err
+		}
+
+		return appendAudit(ctx, r.audit, model.AuditActionWebhookRegistered, model.AuditEntityWebhook, webhook.Id.String(), nil, webhook)
+	})
+}
+
+func (r *AuditedWebhookRepo) Delete(ctx context.Context, id string) error {
+	return r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := r.WebhookRepository.GetById(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := r.WebhookRepository.Delete(ctx, id); err != nil {
+			return err
+		}
+
+		return appendAudit(ctx, r.audit, model.AuditActionWebhookDeleted, model.AuditEntityWebhook, id, before, nil)
+	})
+}
+
+func appendAudit(ctx context.Context, audit contracts.AuditRepository, action, entityType, entityId string, before, after any) error {
+	entry := model.AuditEntry{
+		Actor:      model.ActorFromContext(ctx),
+		Action:     action,
+		EntityType: entityType,
+		EntityId:   entityId,
+		CreatedAt:  time.Now(),
+	}
+
+	var err error
+	if entry.Before, err = marshalSnapshot(before); err != nil {
+		return err
+	}
+	if entry.After, err = marshalSnapshot(after); err != nil {
+		return err
+	}
+
+	_, err = audit.Append(ctx, entry)
+	return err
+}
+
+func marshalSnapshot(value any) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return json.Marshal(value)
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"moneyTransfer/internal/domain/apperrors"
+)
+
+// notFound turns sql.ErrNoRows into a typed not-found error. The original
+// error stays in the chain, so errors.Is(err, sql.ErrNoRows) still holds.
+func notFound(err error, code, message string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return apperrors.NotFound(code, message).WithCause(err)
+	}
+	return err
+}
+
+// requireAffected reports a not-found error when an UPDATE or DELETE matched
+// no rows.
+func requireAffected(res sql.Result, code, message string) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound(sql.ErrNoRows, code, message)
+	}
+	return nil
+}
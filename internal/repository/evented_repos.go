@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+)
+
+// The evented repositories write a domain event to the outbox in the same
+// transaction as the change it describes, so an event is published if and
+// only if the change committed.
+
+type EventedUserRepo struct {
+	contracts.UserRepository
+	outbox    contracts.OutboxRepository
+	txManager contracts.TxManager
+}
+
+var _ contracts.UserRepository = (*EventedUserRepo)(nil)
+
+func NewEventedUserRepository(inner contracts.UserRepository, outbox contracts.OutboxRepository, txManager contracts.TxManager) *EventedUserRepo {
+	return &EventedUserRepo{UserRepository: inner, outbox: outbox, txManager: txManager}
+}
+
+func (r *EventedUserRepo) UpdateBalance(ctx context.Context, userId string, newBalance float64) error {
+	return r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		before, err := r.UserRepository.GetBalance(ctx, userId)
+		if err != nil {
+			return err
+		}
+
+		if err := r.UserRepository.UpdateBalance(ctx, userId, newBalance); err != nil {
+			return err
+		}
+
+		return r.appendBalanceChanged(ctx, userId, before, newBalance)
+	})
+}
+
+func (r *EventedUserRepo) Debit(ctx context.Context, userId string, amount float64) (float64, float64, error) {
+	return r.change(ctx, userId, amount, r.UserRepository.Debit)
+}
+
+func (r *EventedUserRepo) Credit(ctx context.Context, userId string, amount float64) (float64, float64, error) {
+	return r.change(ctx, userId, amount, r.UserRepository.Credit)
+}
+
+// change applies a debit or credit and emits BalanceChanged for it.
+func (r *EventedUserRepo) change(ctx context.Context, userId string, amount float64,
+	apply func(ctx context.Context, userId string, amount float64) (float64, float64, error)) (before, after float64, err error) {
+	err = r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		if before, after, err = apply(ctx, userId, amount); err != nil {
+			return err
+		}
+
+		return r.appendBalanceChanged(ctx, userId, before, after)
+	})
+	return before, after, err
+}
+
+func (r *EventedUserRepo) appendBalanceChanged(ctx context.Context, userId string, before, after float64) error {
+	event, err := model.NewDomainEvent(model.EventBalanceChanged, userId, model.BalanceChangedPayload{
+		AccountId:       userId,
+		PreviousBalance: before,
+		Balance:         after,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.outbox.Append(ctx, event)
+	return err
+}
+
+type EventedTransferRepo struct {
+	contracts.TransferRepository
+	outbox    contracts.OutboxRepository
+	txManager contracts.TxManager
+}
+
+var _ contracts.TransferRepository = (*EventedTransferRepo)(nil)
+
+func NewEventedTransferRepository(inner contracts.TransferRepository, outbox contracts.OutboxRepository, txManager contracts.TxManager) *EventedTransferRepo {
+	return &EventedTransferRepo{TransferRepository: inner, outbox: outbox, txManager: txManager}
+}
+
+func (r *EventedTransferRepo) CreateTransfer(ctx context.Context, tx model.Transaction) error {
+	return r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := r.TransferRepository.CreateTransfer(ctx, tx); err != nil {
+			return err
+		}
+
+		return r.appendTransferEvent(ctx, model.EventTransferCreated, tx)
+	})
+}
+
+// FinishTransaction emits TransferSucceeded or TransferFailed when the
+// transaction reaches that status; other changes emit nothing.
+func (r *EventedTransferRepo) FinishTransaction(ctx context.Context, txId, status string) error {
+	var eventType string
+	switch status {
+	case model.StatusSuccess:
+		eventType = model.EventTransferSucceeded
+	case model.StatusFailed:
+		eventType = model.EventTransferFailed
+	default:
+		return r.TransferRepository.FinishTransaction(ctx, txId, status)
+	}
+
+	return r.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		tx, err := r.TransferRepository.GetTransactionById(ctx, txId)
+		if err != nil {
+			return err
+		}
+
+		if err := r.TransferRepository.FinishTransaction(ctx, txId, status); err != nil {
+			return err
+		}
+
+		tx.Status = status
+		return r.appendTransferEvent(ctx, eventType, tx)
+	})
+}
+
+func (r *EventedTransferRepo) appendTransferEvent(ctx context.Context, eventType string, tx model.Transaction) error {
+	event, err := model.NewTransferEvent(eventType, tx)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.outbox.Append(ctx, event)
+	return err
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+)
+
+type LedgerRepo struct {
+	db *sql.DB
+}
+
+var _ contracts.LedgerRepository = (*LedgerRepo)(nil)
+
+func NewLedgerRepository(db *sql.DB) *LedgerRepo {
+	return &LedgerRepo{db}
+}
+
+// GetAccountLedgers reads every account in one snapshot, so a transfer
+// committing meanwhile is seen on both sides or on neither.
+func (r *LedgerRepo) GetAccountLedgers(ctx context.Context) ([]model.AccountLedger, error) {
+	var ledgers []model.AccountLedger
+	err := withinSnapshot(ctx, r.db, func(ctx context.Context) error {
+		var err error
+		ledgers, err = r.accountLedgers(ctx)
+		return err
+	})
+	return ledgers, err
+}
+
+func (r *LedgerRepo) accountLedgers(ctx context.Context) ([]model.AccountLedger, error) {
+	query := `SELECT u.id, u.balance, u.initial_balance,
+                     COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.receiver_id = u.id AND t.status = $1), 0),
+                     COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.sender_id = u.id AND t.status = $1), 0)
+              FROM users u ORDER BY u.id`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, model.StatusSuccess)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ledgers []model.AccountLedger
+
+	for rows.Next() {
+		var ledger model.AccountLedger
+		if err := rows.Scan(
+			&ledger.UserId,
+			&ledger.Balance,
+			&ledger.InitialBalance,
+			&ledger.Incoming,
+			&ledger.Outgoing,
+		); err != nil {
+			return ledgers, err
+		}
+		ledgers = append(ledgers, ledger)
+	}
+	if err := rows.Err(); err != nil {
+		return ledgers, err
+	}
+
+	return ledgers, nil
+}
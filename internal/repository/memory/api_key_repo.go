@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"slices"
+	"time"
+)
+
+type APIKeyRepo struct {
+	store *Store
+}
+
+var _ contracts.APIKeyRepository = (*APIKeyRepo)(nil)
+
+func NewAPIKeyRepository(store *Store) *APIKeyRepo {
+	return &APIKeyRepo{store}
+}
+
+func (r *APIKeyRepo) Create(ctx context.Context, key model.APIKey) error {
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.apiKeys[key.Id]; ok {
+		return fmt.Errorf("duplicate api key id %s", key.Id)
+	}
+	for _, existing := range r.store.apiKeys {
+		if existing.KeyHash == key.KeyHash {
+			return fmt.Errorf("duplicate api key hash")
+		}
+	}
+
+	r.store.apiKeys[key.Id] = cloneAPIKey(key)
+	return nil
+}
+
+func (r *APIKeyRepo) GetById(ctx context.Context, id string) (model.APIKey, error) {
+	keyId, err := parseId(id)
+	if err != nil {
+		return model.APIKey{}, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	key, ok := r.store.apiKeys[keyId]
+	if !ok {
+		return model.APIKey{}, notFound(apperrors.CodeAPIKeyNotFound, "api key not found")
+	}
+	return cloneAPIKey(key), nil
+}
+
+func (r *APIKeyRepo) GetByHash(ctx context.Context, keyHash string) (model.APIKey, error) {
+	defer r.store.lock(ctx)()
+
+	for _, key := range r.store.apiKeys {
+		if key.KeyHash == keyHash {
+			return cloneAPIKey(key), nil
+		}
+	}
+	return model.APIKey{}, notFound(apperrors.CodeAPIKeyNotFound, "api key not found")
+}
+
+func (r *APIKeyRepo) List(ctx context.Context) ([]model.APIKey, error) {
+	defer r.store.lock(ctx)()
+
+	keys := make([]model.APIKey, 0, len(r.store.apiKeys))
+	for _, key := range r.store.apiKeys {
+		keys = append(keys, cloneAPIKey(key))
+	}
+	slices.SortFunc(keys, func(a, b model.APIKey) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	return keys, nil
+}
+
+func (r *APIKeyRepo) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	keyId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	key, ok := r.store.apiKeys[keyId]
+	if !ok {
+		return notFound(apperrors.CodeAPIKeyNotFound, "api key not found")
+	}
+	if key.RevokedAt != nil {
+		return apperrors.Conflict(apperrors.CodeAPIKeyRevoked, "api key already revoked")
+	}
+	key.RevokedAt = &revokedAt
+	r.store.apiKeys[keyId] = key
+	return nil
+}
+
+func (r *APIKeyRepo) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	keyId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if key, ok := r.store.apiKeys[keyId]; ok {
+		key.LastUsedAt = &usedAt
+		r.store.apiKeys[keyId] = key
+	}
+	return nil
+}
+
+// cloneAPIKey copies the scope slice so callers cannot mutate stored keys.
+func cloneAPIKey(key model.APIKey) model.APIKey {
+	key.Scopes = slices.Clone(key.Scopes)
+	return key
+}
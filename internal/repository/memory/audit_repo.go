@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"time"
+)
+
+type AuditRepo struct {
+	store *Store
+}
+
+var _ contracts.AuditRepository = (*AuditRepo)(nil)
+
+func NewAuditRepository(store *Store) *AuditRepo {
+	return &AuditRepo{store}
+}
+
+func (r *AuditRepo) Append(ctx context.Context, entry model.AuditEntry) (model.AuditEntry, error) {
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+
+	defer r.store.lock(ctx)()
+
+	log := r.store.auditLog
+	entry.PrevHash = ""
+	if len(log) > 0 {
+		entry.PrevHash = log[len(log)-1].Hash
+	}
+	entry.Hash = entry.ComputeHash()
+	entry.Id = int64(len(log)) + 1
+
+	r.store.auditLog = append(log, entry)
+	return entry, nil
+}
+
+func (r *AuditRepo) List(ctx context.Context, afterId int64, limit int) ([]model.AuditEntry, error) {
+	defer r.store.lock(ctx)()
+
+	var entries []model.AuditEntry
+	for _, entry := range r.store.auditLog {
+		if len(entries) == limit {
+			break
+		}
+		if entry.Id > afterId {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
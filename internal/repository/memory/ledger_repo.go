@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"github.com/google/uuid"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"slices"
+)
+
+type LedgerRepo struct {
+	store *Store
+}
+
+var _ contracts.LedgerRepository = (*LedgerRepo)(nil)
+
+func NewLedgerRepository(store *Store) *LedgerRepo {
+	return &LedgerRepo{store}
+}
+
+func (r *LedgerRepo) GetAccountLedgers(ctx context.Context) ([]model.AccountLedger, error) {
+	defer r.store.lock(ctx)()
+
+	ledgers := make(map[uuid.UUID]*model.AccountLedger, len(r.store.users))
+	for id, acc := range r.store.users {
+		ledgers[id] = &model.AccountLedger{
+			UserId:         id,
+			Balance:        acc.user.Balance,
+			InitialBalance: acc.initialBalance,
+		}
+	}
+
+	for _, tx := range r.store.transactions {
+		if tx.Status != model.StatusSuccess {
+			continue
+		}
+		if ledger, ok := ledgers[tx.ReceiverId]; ok {
+			ledger.Incoming += tx.Amount
+		}
+		if ledger, ok := ledgers[tx.SenderId]; ok {
+			ledger.Outgoing += tx.Amount
+		}
+	}
+
+	result := make([]model.AccountLedger, 0, len(ledgers))
+	for _, ledger := range ledgers {
+		result = append(result, *ledger)
+	}
+	// Same order as Postgres sorts the uuid column.
+	slices.SortFunc(result, func(a, b model.AccountLedger) int { return bytes.Compare(a.UserId[:], b.UserId[:]) })
+
+	return result, nil
+}
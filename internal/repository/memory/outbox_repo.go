@@ -0,0 +1,96 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"slices"
+	"time"
+)
+
+// outboxAccount mirrors a row of outbox_accounts.
+type outboxAccount struct {
+	lastSequence int64
+	claimedUntil time.Time
+}
+
+type OutboxRepo struct {
+	store *Store
+}
+
+var _ contracts.OutboxRepository = (*OutboxRepo)(nil)
+
+func NewOutboxRepository(store *Store) *OutboxRepo {
+	return &OutboxRepo{store}
+}
+
+func (r *OutboxRepo) Append(ctx context.Context, event model.DomainEvent) (model.DomainEvent, error) {
+	defer r.store.lock(ctx)()
+
+	account := r.store.outboxAccounts[event.AccountId]
+	account.lastSequence++
+	r.store.outboxAccounts[event.AccountId] = account
+
+	r.store.outboxSeq++
+	event.Sequence = r.store.outboxSeq
+	event.AccountSequence = account.lastSequence
+	event.Payload = slices.Clone(event.Payload)
+	r.store.outbox = append(r.store.outbox, event)
+	return event, nil
+}
+
+func (r *OutboxRepo) Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.DomainEvent, error) {
+	defer r.store.lock(ctx)()
+
+	// The outbox is in Sequence order, so accounts are met oldest event first.
+	claimed := make(map[string]bool)
+	for _, event := range r.store.outbox {
+		if len(claimed) == limit {
+			break
+		}
+		account := r.store.outboxAccounts[event.AccountId]
+		if claimed[event.AccountId] || account.claimedUntil.After(now) {
+			continue
+		}
+		account.claimedUntil = leaseUntil
+		r.store.outboxAccounts[event.AccountId] = account
+		claimed[event.AccountId] = true
+	}
+
+	var events []model.DomainEvent
+	for _, event := range r.store.outbox {
+		if claimed[event.AccountId] {
+			events = append(events, event)
+		}
+	}
+	slices.SortStableFunc(events, func(a, b model.DomainEvent) int {
+		return cmp.Or(cmp.Compare(a.AccountId, b.AccountId), cmp.Compare(a.AccountSequence, b.AccountSequence))
+	})
+	return events, nil
+}
+
+func (r *OutboxRepo) Pending(ctx context.Context, limit int) ([]model.DomainEvent, error) {
+	defer r.store.lock(ctx)()
+
+	return slices.Clone(r.store.outbox[:min(limit, len(r.store.outbox))]), nil
+}
+
+func (r *OutboxRepo) MarkPublished(ctx context.Context, sequence int64, publishedAt time.Time) error {
+	defer r.store.lock(ctx)()
+
+	r.store.outbox = slices.DeleteFunc(r.store.outbox, func(e model.DomainEvent) bool {
+		return e.Sequence == sequence
+	})
+	return nil
+}
+
+func (r *OutboxRepo) Release(ctx context.Context, accountId string, notBefore time.Time) error {
+	defer r.store.lock(ctx)()
+
+	if account, ok := r.store.outboxAccounts[accountId]; ok {
+		account.claimedUntil = notBefore
+		r.store.outboxAccounts[accountId] = account
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"github.com/google/uuid"
+	"moneyTransfer/internal/domain/model"
+	"time"
+)
+
+// SeedDemoData loads the same demo accounts and transfers as the Postgres seed
+// migration, so the two backends start from identical books.
+func SeedDemoData(store *Store) error {
+	users := []struct {
+		id, firstName, lastName, email string
+		initialBalance                 float64
+	}{
+		{"7141b92f-a8c8-471e-83e5-7fc72da61cb9", "Alice", "Doe", "alice@example.com", 1010},
+		{"861d7697-b717-43e8-95a2-1a74f9a36ab1", "Joe", "Brook", "joe@example.com", 10790},
+		{"939cb506-0d70-4791-8c9e-d4284d87c749", "Bob", "Sink", "bob@example.com", 501},
+		{"9d02adbc-27ca-4695-9d92-10cb35db67f4", "Carol", "Smith", "carol@example.com", 749},
+		{"befeef21-1475-4a13-a0de-3943d2eb0910", "Dave", "Johnson", "dave@example.com", 1205},
+		{"39ede33f-2a57-44bb-a563-7a37dda46bdf", "Eve", "Miller", "eve@example.com", 2995},
+		{"3f2fdcde-cb17-488e-819e-99cafea3f984", "Frank", "White", "frank@example.com", 647},
+		{"595e4e71-ad88-4a65-85d2-be98718f36df", "Grace", "Taylor", "grace@example.com", 9793},
+	}
+	for _, u := range users {
+		user := model.User{Id: uuid.MustParse(u.id), FirstName: u.firstName, LastName: u.lastName, Email: u.email}
+		if err := store.AddUser(user, u.initialBalance); err != nil {
+			return err
+		}
+	}
+
+	transfers := []struct {
+		from, to string
+		amount   float64
+	}{
+		{"7141b92f-a8c8-471e-83e5-7fc72da61cb9", "861d7697-b717-43e8-95a2-1a74f9a36ab1", 10},
+		{"939cb506-0d70-4791-8c9e-d4284d87c749", "9d02adbc-27ca-4695-9d92-10cb35db67f4", 1},
+		{"befeef21-1475-4a13-a0de-3943d2eb0910", "39ede33f-2a57-44bb-a563-7a37dda46bdf", 5},
+		{"3f2fdcde-cb17-488e-819e-99cafea3f984", "595e4e71-ad88-4a65-85d2-be98718f36df", 7},
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	now := time.Now().Truncate(time.Microsecond)
+	for _, t := range transfers {
+		tx := model.Transaction{
+			Id:         uuid.New(),
+			SenderId:   uuid.MustParse(t.from),
+			ReceiverId: uuid.MustParse(t.to),
+			Amount:     t.amount,
+			Status:     model.StatusSuccess,
+			CreatedAt:  now,
+		}
+		store.transactions[tx.Id] = tx
+
+		sender, receiver := store.users[tx.SenderId], store.users[tx.ReceiverId]
+		sender.user.Balance -= tx.Amount
+		receiver.user.Balance += tx.Amount
+		store.users[tx.SenderId], store.users[tx.ReceiverId] = sender, receiver
+	}
+
+	return nil
+}
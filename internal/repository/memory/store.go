@@ -0,0 +1,167 @@
+// Package memory implements the repository contracts on in-process maps, for
+// demos and fast integration tests. Data is lost when the process exits.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/google/uuid"
+	"maps"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"slices"
+	"sync"
+)
+
+// Store holds the data shared by the repositories created from it. A single
+// lock serialises access, which also makes transactions serialisable.
+type Store struct {
+	mu           sync.Mutex
+	users        map[uuid.UUID]account
+	transactions map[uuid.UUID]model.Transaction
+	apiKeys      map[uuid.UUID]model.APIKey
+	auditLog     []model.AuditEntry
+	// outbox holds unpublished events only; published ones are dropped.
+	outbox         []model.DomainEvent
+	outboxSeq      int64
+	outboxAccounts map[string]outboxAccount
+	webhooks       map[uuid.UUID]model.Webhook
+	deliveries     map[uuid.UUID]model.WebhookDelivery
+}
+
+type account struct {
+	user           model.User
+	initialBalance float64
+}
+
+func NewStore() *Store {
+	return &Store{
+		users:          make(map[uuid.UUID]account),
+		transactions:   make(map[uuid.UUID]model.Transaction),
+		apiKeys:        make(map[uuid.UUID]model.APIKey),
+		outboxAccounts: make(map[string]outboxAccount),
+		webhooks:       make(map[uuid.UUID]model.Webhook),
+		deliveries:     make(map[uuid.UUID]model.WebhookDelivery),
+	}
+}
+
+// AddUser creates an account funded with initialBalance.
+func (s *Store) AddUser(user model.User, initialBalance float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.Id]; ok {
+		return fmt.Errorf("user %s already exists", user.Id)
+	}
+	for _, existing := range s.users {
+		if existing.user.Email == user.Email {
+			return fmt.Errorf("email %s already in use", user.Email)
+		}
+	}
+
+	user.Balance = initialBalance
+	s.users[user.Id] = account{user: user, initialBalance: initialBalance}
+	return nil
+}
+
+type txContextKey struct{}
+
+type TxManager struct {
+	store *Store
+}
+
+var _ contracts.TxManager = (*TxManager)(nil)
+
+func NewTxManager(store *Store) *TxManager {
+	return &TxManager{store}
+}
+
+// WithinTransaction holds the store lock while fn runs and restores the data
+// if fn fails. Nested calls join the outer transaction.
+func (m *TxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	s := m.store
+	if s.inTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.snapshot()
+	if err := fn(context.WithValue(ctx, txContextKey{}, s)); err != nil {
+		s.restore(snapshot)
+		return err
+	}
+	return nil
+}
+
+func (s *Store) inTransaction(ctx context.Context) bool {
+	owner, _ := ctx.Value(txContextKey{}).(*Store)
+	return owner == s
+}
+
+// lock takes the store lock unless ctx is inside a transaction on this store,
+// which already holds it.
+func (s *Store) lock(ctx context.Context) func() {
+	if s.inTransaction(ctx) {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+type storeSnapshot struct {
+	users          map[uuid.UUID]account
+	transactions   map[uuid.UUID]model.Transaction
+	apiKeys        map[uuid.UUID]model.APIKey
+	auditLen       int
+	outbox         []model.DomainEvent
+	outboxSeq      int64
+	outboxAccounts map[string]outboxAccount
+	webhooks       map[uuid.UUID]model.Webhook
+	deliveries     map[uuid.UUID]model.WebhookDelivery
+}
+
+func (s *Store) snapshot() storeSnapshot {
+	return storeSnapshot{
+		users:          maps.Clone(s.users),
+		transactions:   maps.Clone(s.transactions),
+		apiKeys:        maps.Clone(s.apiKeys),
+		auditLen:       len(s.auditLog),
+		outbox:         slices.Clone(s.outbox),
+		outboxSeq:      s.outboxSeq,
+		outboxAccounts: maps.Clone(s.outboxAccounts),
+		webhooks:       maps.Clone(s.webhooks),
+		deliveries:     maps.Clone(s.deliveries),
+	}
+}
+
+func (s *Store) restore(snapshot storeSnapshot) {
+	s.users = snapshot.users
+	s.transactions = snapshot.transactions
+	s.apiKeys = snapshot.apiKeys
+	s.auditLog = slices.Clip(s.auditLog[:snapshot.auditLen])
+	s.outbox = snapshot.outbox
+	s.outboxSeq = snapshot.outboxSeq
+	s.outboxAccounts = snapshot.outboxAccounts
+	s.webhooks = snapshot.webhooks
+	s.deliveries = snapshot.deliveries
+}
+
+// parseId mirrors Postgres, which rejects malformed UUIDs with an error
+// rather than reporting no rows.
+func parseId(id string) (uuid.UUID, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("invalid input syntax for type uuid: %q", id)
+	}
+	return parsed, nil
+}
+
+// notFound matches the Postgres repositories: a typed not-found error that
+// still satisfies errors.Is(err, sql.ErrNoRows).
+func notFound(code, message string) error {
+	return apperrors.NotFound(code, message).WithCause(sql.ErrNoRows)
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"slices"
+	"time"
+)
+
+type TransferRepo struct {
+	store *Store
+}
+
+var _ contracts.TransferRepository = (*TransferRepo)(nil)
+
+func NewTransferRepository(store *Store) *TransferRepo {
+	return &TransferRepo{store}
+}
+
+func (r *TransferRepo) GetTransactionsByUserId(ctx context.Context, userId string) ([]model.Transaction, error) {
+	id, err := parseId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	var transfers []model.Transaction
+	for _, tx := range r.store.transactions {
+		if tx.SenderId == id || tx.ReceiverId == id {
+			transfers = append(transfers, tx)
+		}
+	}
+	slices.SortFunc(transfers, func(a, b model.Transaction) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	return transfers, nil
+}
+
+func (r *TransferRepo) GetTransactionById(ctx context.Context, txId string) (model.Transaction, error) {
+	id, err := parseId(txId)
+	if err != nil {
+		return model.Transaction{}, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	tx, ok := r.store.transactions[id]
+	if !ok {
+		return model.Transaction{}, notFound(apperrors.CodeTransactionNotFound, "transaction not found")
+	}
+	return tx, nil
+}
+
+// CreateTransfer enforces the same constraints as the transactions table:
+// a unique id and sender and receiver that exist.
+func (r *TransferRepo) CreateTransfer(ctx context.Context, tx model.Transaction) error {
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.transactions[tx.Id]; ok {
+		return fmt.Errorf("duplicate transaction id %s", tx.Id)
+	}
+	if _, ok := r.store.users[tx.SenderId]; !ok {
+		return fmt.Errorf("transaction references unknown sender %s", tx.SenderId)
+	}
+	if _, ok := r.store.users[tx.ReceiverId]; !ok {
+		return fmt.Errorf("transaction references unknown receiver %s", tx.ReceiverId)
+	}
+
+	// Postgres keeps microseconds; match it so round-trips compare equal.
+	tx.CreatedAt = tx.CreatedAt.Truncate(time.Microsecond)
+	r.store.transactions[tx.Id] = tx
+	return nil
+}
+
+func (r *TransferRepo) FinishTransaction(ctx context.Context, txId, status string) error {
+	id, err := parseId(txId)
+	if err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	tx, ok := r.store.transactions[id]
+	if !ok {
+		return notFound(apperrors.CodeTransactionNotFound, "transaction not found")
+	}
+	if tx.Status != model.StatusPending {
+		return apperrors.Conflict(apperrors.CodeTransactionSettled, "transaction is no longer pending")
+	}
+	tx.Status = status
+	r.store.transactions[id] = tx
+	return nil
+}
+
+// DeletePendingTransaction removes a transaction that never made it onto the
+// queue. Rows that have already been picked up by the worker are left alone.
+func (r *TransferRepo) DeletePendingTransaction(ctx context.Context, txId string) error {
+	id, err := parseId(txId)
+	if err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if tx, ok := r.store.transactions[id]; ok && tx.Status == model.StatusPending {
+		delete(r.store.transactions, id)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package memory
+
+import (
+	"context"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+)
+
+type UserRepo struct {
+	store *Store
+}
+
+var _ contracts.UserRepository = (*UserRepo)(nil)
+
+func NewUserRepository(store *Store) *UserRepo {
+	return &UserRepo{store}
+}
+
+func (r *UserRepo) GetBalance(ctx context.Context, userId string) (float64, error) {
+	user, err := r.GetById(ctx, userId)
+	if err != nil {
+		return 0, err
+	}
+	return user.Balance, nil
+}
+
+func (r *UserRepo) GetById(ctx context.Context, userId string) (model.User, error) {
+	id, err := parseId(userId)
+	if err != nil {
+		return model.User{}, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	acc, ok := r.store.users[id]
+	if !ok {
+		return model.User{}, notFound(apperrors.CodeUserNotFound, "user not found")
+	}
+	return acc.user, nil
+}
+
+func (r *UserRepo) UpdateBalance(ctx context.Context, userId string, newBalance float64) error {
+	id, err := parseId(userId)
+	if err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	acc, ok := r.store.users[id]
+	if !ok {
+		return notFound(apperrors.CodeUserNotFound, "user not found")
+	}
+	acc.user.Balance = newBalance
+	r.store.users[id] = acc
+	return nil
+}
+
+func (r *UserRepo) Debit(ctx context.Context, userId string, amount float64) (float64, float64, error) {
+	return r.add(ctx, userId, -amount)
+}
+
+func (r *UserRepo) Credit(ctx context.Context, userId string, amount float64) (float64, float64, error) {
+	return r.add(ctx, userId, amount)
+}
+
+// add changes the balance by delta under the store lock and returns it before
+// and after. A change that would take the balance below zero fails.
+func (r *UserRepo) add(ctx context.Context, userId string, delta float64) (float64, float64, error) {
+	id, err := parseId(userId)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	acc, ok := r.store.users[id]
+	if !ok {
+		return 0, 0, notFound(apperrors.CodeUserNotFound, "user not found")
+	}
+	previous := acc.user.Balance
+	if previous+delta < 0 {
+		return 0, 0, apperrors.InsufficientFunds("insufficient funds")
+	}
+	acc.user.Balance = previous + delta
+	r.store.users[id] = acc
+	return previous, acc.user.Balance, nil
+}
+
+// Lock does nothing: a transaction holds the store lock, which already keeps
+// other transactions away from the users.
+func (r *UserRepo) Lock(ctx context.Context, userIds ...string) error {
+	return nil
+}
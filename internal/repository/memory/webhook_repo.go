@@ -0,0 +1,216 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"slices"
+	"time"
+)
+
+type WebhookRepo struct {
+	store *Store
+}
+
+var _ contracts.WebhookRepository = (*WebhookRepo)(nil)
+
+func NewWebhookRepository(store *Store) *WebhookRepo {
+	return &WebhookRepo{store}
+}
+
+func (r *WebhookRepo) Create(ctx context.Context, webhook model.Webhook) error {
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.webhooks[webhook.Id]; ok {
+		return fmt.Errorf("duplicate webhook id %s", webhook.Id)
+	}
+
+	r.store.webhooks[webhook.Id] = cloneWebhook(webhook)
+	return nil
+}
+
+func (r *WebhookRepo) GetById(ctx context.Context, id string) (model.Webhook, error) {
+	webhookId, err := parseId(id)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	webhook, ok := r.store.webhooks[webhookId]
+	if !ok {
+		return model.Webhook{}, notFound(apperrors.CodeWebhookNotFound, "webhook not found")
+	}
+	return cloneWebhook(webhook), nil
+}
+
+func (r *WebhookRepo) List(ctx context.Context) ([]model.Webhook, error) {
+	defer r.store.lock(ctx)()
+
+	webhooks := make([]model.Webhook, 0, len(r.store.webhooks))
+	for _, webhook := range r.store.webhooks {
+		webhooks = append(webhooks, cloneWebhook(webhook))
+	}
+	slices.SortFunc(webhooks, func(a, b model.Webhook) int { return a.CreatedAt.Compare(b.CreatedAt) })
+
+	return webhooks, nil
+}
+
+func (r *WebhookRepo) ListByOwner(ctx context.Context, ownerId string) ([]model.Webhook, error) {
+	owner, err := parseId(ownerId)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(webhooks, func(w model.Webhook) bool { return w.OwnerId != owner }), nil
+}
+
+func (r *WebhookRepo) Reassign(ctx context.Context, fromOwnerId, toOwnerId string) error {
+	from, err := parseId(fromOwnerId)
+	if err != nil {
+		return err
+	}
+	to, err := parseId(toOwnerId)
+	if err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	for id, webhook := range r.store.webhooks {
+		if webhook.OwnerId == from {
+			webhook.OwnerId = to
+			r.store.webhooks[id] = webhook
+		}
+	}
+	return nil
+}
+
+func (r *WebhookRepo) Delete(ctx context.Context, id string) error {
+	webhookId, err := parseId(id)
+	if err != nil {
+		return err
+	}
+
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.webhooks[webhookId]; !ok {
+		return notFound(apperrors.CodeWebhookNotFound, "webhook not found")
+	}
+	delete(r.store.webhooks, webhookId)
+	for deliveryId, delivery := range r.store.deliveries {
+		if delivery.WebhookId == webhookId {
+			delete(r.store.deliveries, deliveryId)
+		}
+	}
+	return nil
+}
+
+// cloneWebhook copies the event slice so callers cannot mutate stored webhooks.
+func cloneWebhook(webhook model.Webhook) model.Webhook {
+	webhook.Events = slices.Clone(webhook.Events)
+	webhook.Accounts = slices.Clone(webhook.Accounts)
+	return webhook
+}
+
+type WebhookDeliveryRepo struct {
+	store *Store
+}
+
+var _ contracts.WebhookDeliveryRepository = (*WebhookDeliveryRepo)(nil)
+
+func NewWebhookDeliveryRepository(store *Store) *WebhookDeliveryRepo {
+	return &WebhookDeliveryRepo{store}
+}
+
+func (r *WebhookDeliveryRepo) Create(ctx context.Context, delivery model.WebhookDelivery) error {
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.webhooks[delivery.WebhookId]; !ok {
+		return fmt.Errorf("webhook %s does not exist", delivery.WebhookId)
+	}
+	for _, existing := range r.store.deliveries {
+		if existing.WebhookId == delivery.WebhookId && existing.EventId == delivery.EventId {
+			return nil
+		}
+	}
+
+	r.store.deliveries[delivery.Id] = cloneDelivery(delivery)
+	return nil
+}
+
+func (r *WebhookDeliveryRepo) GetById(ctx context.Context, id string) (model.WebhookDelivery, error) {
+	deliveryId, err := parseId(id)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	delivery, ok := r.store.deliveries[deliveryId]
+	if !ok {
+		return model.WebhookDelivery{}, notFound(apperrors.CodeDeliveryNotFound, "delivery not found")
+	}
+	return cloneDelivery(delivery), nil
+}
+
+func (r *WebhookDeliveryRepo) ListByWebhook(ctx context.Context, webhookId string, limit int) ([]model.WebhookDelivery, error) {
+	id, err := parseId(webhookId)
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.store.lock(ctx)()
+
+	var deliveries []model.WebhookDelivery
+	for _, delivery := range r.store.deliveries {
+		if delivery.WebhookId == id {
+			deliveries = append(deliveries, cloneDelivery(delivery))
+		}
+	}
+	slices.SortFunc(deliveries, func(a, b model.WebhookDelivery) int { return b.CreatedAt.Compare(a.CreatedAt) })
+
+	return deliveries[:min(limit, len(deliveries))], nil
+}
+
+func (r *WebhookDeliveryRepo) Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	defer r.store.lock(ctx)()
+
+	var due []model.WebhookDelivery
+	for _, delivery := range r.store.deliveries {
+		if delivery.Status == model.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+	slices.SortFunc(due, func(a, b model.WebhookDelivery) int { return a.NextAttemptAt.Compare(b.NextAttemptAt) })
+	due = due[:min(limit, len(due))]
+
+	for i := range due {
+		due[i].NextAttemptAt = leaseUntil
+		r.store.deliveries[due[i].Id] = due[i]
+		due[i] = cloneDelivery(due[i])
+	}
+	return due, nil
+}
+
+func (r *WebhookDeliveryRepo) Update(ctx context.Context, delivery model.WebhookDelivery) error {
+	defer r.store.lock(ctx)()
+
+	if _, ok := r.store.deliveries[delivery.Id]; !ok {
+		return notFound(apperrors.CodeDeliveryNotFound, "delivery not found")
+	}
+	r.store.deliveries[delivery.Id] = cloneDelivery(delivery)
+	return nil
+}
+
+// cloneDelivery copies the payload so callers cannot mutate stored deliveries.
+func cloneDelivery(delivery model.WebhookDelivery) model.WebhookDelivery {
+	delivery.Payload = slices.Clone(delivery.Payload)
+	return delivery
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type OutboxRepo struct {
+	db *sql.DB
+}
+
+var _ contracts.OutboxRepository = (*OutboxRepo)(nil)
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepo {
+	return &OutboxRepo{db: db}
+}
+
+const outboxColumns = `id, event_id, event_type, account_id, account_sequence, payload, occurred_at`
+
+// Append numbers event after the last event of its account. The upsert locks
+// the account's row in outbox_accounts until the transaction ends, so a
+// concurrent append for the same account waits and takes the next number.
+func (r *OutboxRepo) Append(ctx context.Context, event model.DomainEvent) (model.DomainEvent, error) {
+	db := conn(ctx, r.db)
+
+	query := `INSERT INTO outbox_accounts (account_id, last_sequence) VALUES ($1, 1)
+              ON CONFLICT (account_id) DO UPDATE SET last_sequence = outbox_accounts.last_sequence + 1
+              RETURNING last_sequence`
+	if err := db.QueryRowContext(ctx, query, event.AccountId).Scan(&event.AccountSequence); err != nil {
+		return model.DomainEvent{}, err
+	}
+
+	query = `INSERT INTO outbox (event_id, event_type, account_id, account_sequence, payload, occurred_at)
+             VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	err := db.QueryRowContext(ctx, query,
+		event.Id, event.Type, event.AccountId, event.AccountSequence, string(event.Payload), event.OccurredAt,
+	).Scan(&event.Sequence)
+	if err != nil {
+		return model.DomainEvent{}, err
+	}
+
+	return event, nil
+}
+
+// Claim leases the accounts whose oldest unpublished events are the oldest
+// overall. The lease is re-checked on the locked row, so when two relays
+// race for an account only one of them gets it.
+func (r *OutboxRepo) Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.DomainEvent, error) {
+	db := conn(ctx, r.db)
+
+	query := `UPDATE outbox_accounts SET claimed_until = $1
+              WHERE account_id IN (
+                  SELECT o.account_id FROM outbox o JOIN outbox_accounts a ON a.account_id = o.account_id
+                  WHERE o.published_at IS NULL AND (a.claimed_until IS NULL OR a.claimed_until <= $2)
+                  GROUP BY o.account_id ORDER BY MIN(o.id) LIMIT $3)
+              AND (claimed_until IS NULL OR claimed_until <= $2)
+              RETURNING account_id`
+
+	rows, err := db.QueryContext(ctx, query, leaseUntil, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var args []any
+	var placeholders []string
+	for rows.Next() {
+		var accountId string
+		if err := rows.Scan(&accountId); err != nil {
+			return nil, err
+		}
+		args = append(args, accountId)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	query = `SELECT ` + outboxColumns + ` FROM outbox
+             WHERE published_at IS NULL AND account_id IN (` + strings.Join(placeholders, ", ") + `)
+             ORDER BY account_id, account_sequence`
+	return r.list(ctx, query, args...)
+}
+
+func (r *OutboxRepo) Pending(ctx context.Context, limit int) ([]model.DomainEvent, error) {
+	query := `SELECT ` + outboxColumns + ` FROM outbox WHERE published_at IS NULL ORDER BY id LIMIT $1`
+	return r.list(ctx, query, limit)
+}
+
+func (r *OutboxRepo) list(ctx context.Context, query string, args ...any) ([]model.DomainEvent, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []model.DomainEvent
+
+	for rows.Next() {
+		var event model.DomainEvent
+		var payload string
+		if err := rows.Scan(
+			&event.Sequence,
+			&event.Id,
+			&event.Type,
+			&event.AccountId,
+			&event.AccountSequence,
+			&payload,
+			&event.OccurredAt,
+		); err != nil {
+			return events, err
+		}
+		event.Payload = []byte(payload)
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return events, err
+	}
+
+	return events, nil
+}
+
+func (r *OutboxRepo) MarkPublished(ctx context.Context, sequence int64, publishedAt time.Time) error {
+	query := `UPDATE outbox SET published_at = $1 WHERE id = $2`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, publishedAt, sequence)
+	return err
+}
+
+func (r *OutboxRepo) Release(ctx context.Context, accountId string, notBefore time.Time) error {
+	query := `UPDATE outbox_accounts SET claimed_until = $1 WHERE account_id = $2`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, notBefore, accountId)
+	return err
+}
@@ -1,41 +1,36 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
-	"github.com/joho/godotenv"
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
-	"log"
-	"os"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/tracing"
+	"time"
 )
 
-func NewPostgresClient() (*sql.DB, error) {
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
-
-	user := os.Getenv("POSTGRES_USER")
-	password := os.Getenv("POSTGRES_PASSWORD")
-	host := os.Getenv("POSTGRES_HOST")
-	port := os.Getenv("POSTGRES_PORT")
-	database := os.Getenv("POSTGRES_DB")
-
-	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s "+
-		"password=%s dbname=%s sslmode=disable",
-		host, port, user, password, database)
+const connectTimeout = 5 * time.Second
 
-	db, err := sql.Open("postgres", psqlInfo)
+func NewPostgresClient(cfg config.DatabaseConfig) (*sql.DB, error) {
+	db, err := otelsql.Open("postgres", cfg.DSN(), tracing.SQLOptions(semconv.DBSystemNamePostgreSQL)...)
 	if err != nil {
 		return nil, err
 	}
 
-	err = db.Ping()
-	if err != nil {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	fmt.Println("Successfully connected!")
-
 	return db, nil
 }
@@ -0,0 +1,18 @@
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    balance NUMERIC DEFAULT 0,
+    -- funding the account started with; reconciliation recomputes balance from it
+    initial_balance NUMERIC NOT NULL DEFAULT 0
+);
+
+CREATE TABLE transactions (
+    id TEXT PRIMARY KEY,
+    sender_id TEXT REFERENCES users(id),
+    receiver_id TEXT REFERENCES users(id),
+    amount NUMERIC NOT NULL,
+    status TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,18 @@
+DELETE FROM transactions WHERE sender_id IN (
+    '7141b92f-a8c8-471e-83e5-7fc72da61cb9', '861d7697-b717-43e8-95a2-1a74f9a36ab1',
+    '939cb506-0d70-4791-8c9e-d4284d87c749', '9d02adbc-27ca-4695-9d92-10cb35db67f4',
+    'befeef21-1475-4a13-a0de-3943d2eb0910', '39ede33f-2a57-44bb-a563-7a37dda46bdf',
+    '3f2fdcde-cb17-488e-819e-99cafea3f984', '595e4e71-ad88-4a65-85d2-be98718f36df'
+) OR receiver_id IN (
+    '7141b92f-a8c8-471e-83e5-7fc72da61cb9', '861d7697-b717-43e8-95a2-1a74f9a36ab1',
+    '939cb506-0d70-4791-8c9e-d4284d87c749', '9d02adbc-27ca-4695-9d92-10cb35db67f4',
+    'befeef21-1475-4a13-a0de-3943d2eb0910', '39ede33f-2a57-44bb-a563-7a37dda46bdf',
+    '3f2fdcde-cb17-488e-819e-99cafea3f984', '595e4e71-ad88-4a65-85d2-be98718f36df'
+);
+
+DELETE FROM users WHERE id IN (
+    '7141b92f-a8c8-471e-83e5-7fc72da61cb9', '861d7697-b717-43e8-95a2-1a74f9a36ab1',
+    '939cb506-0d70-4791-8c9e-d4284d87c749', '9d02adbc-27ca-4695-9d92-10cb35db67f4',
+    'befeef21-1475-4a13-a0de-3943d2eb0910', '39ede33f-2a57-44bb-a563-7a37dda46bdf',
+    '3f2fdcde-cb17-488e-819e-99cafea3f984', '595e4e71-ad88-4a65-85d2-be98718f36df'
+);
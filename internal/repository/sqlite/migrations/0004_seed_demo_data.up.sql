@@ -0,0 +1,25 @@
+-- initial_balance is the balance before the seeded transactions below.
+INSERT OR IGNORE INTO users (id, first_name, last_name, email, balance, initial_balance) VALUES
+   ('7141b92f-a8c8-471e-83e5-7fc72da61cb9', 'Alice', 'Doe', 'alice@example.com', 1000, 1010),
+   ('861d7697-b717-43e8-95a2-1a74f9a36ab1', 'Joe', 'Brook', 'joe@example.com', 10800, 10790),
+   ('939cb506-0d70-4791-8c9e-d4284d87c749', 'Bob', 'Sink', 'bob@example.com', 500, 501),
+   ('9d02adbc-27ca-4695-9d92-10cb35db67f4', 'Carol', 'Smith', 'carol@example.com', 750, 749),
+   ('befeef21-1475-4a13-a0de-3943d2eb0910', 'Dave', 'Johnson', 'dave@example.com', 1200, 1205),
+   ('39ede33f-2a57-44bb-a563-7a37dda46bdf', 'Eve', 'Miller', 'eve@example.com', 3000, 2995),
+   ('3f2fdcde-cb17-488e-819e-99cafea3f984', 'Frank', 'White', 'frank@example.com', 640, 647),
+   ('595e4e71-ad88-4a65-85d2-be98718f36df', 'Grace', 'Taylor', 'grace@example.com', 9800, 9793);
+
+-- 1. Alice send to Joe
+-- 2. Bob send to carol
+-- 3. Dave sends to Eve
+-- 4. Frank sends to Grace
+-- SQLite has no gen_random_uuid(), so version 4 ids are built from randomblob().
+INSERT INTO transactions(id, sender_id, receiver_id, amount, status, created_at)
+SELECT lower(hex(randomblob(4)) || '-' || hex(randomblob(2)) || '-4' || substr(hex(randomblob(2)), 2) || '-' ||
+             substr('89ab', 1 + abs(random()) % 4, 1) || substr(hex(randomblob(2)), 2) || '-' || hex(randomblob(6))),
+       sender_id, receiver_id, amount, 'SUCCESS', CURRENT_TIMESTAMP
+FROM (SELECT '7141b92f-a8c8-471e-83e5-7fc72da61cb9' AS sender_id, '861d7697-b717-43e8-95a2-1a74f9a36ab1' AS receiver_id, 10 AS amount
+      UNION ALL SELECT '939cb506-0d70-4791-8c9e-d4284d87c749', '9d02adbc-27ca-4695-9d92-10cb35db67f4', 1
+      UNION ALL SELECT 'befeef21-1475-4a13-a0de-3943d2eb0910', '39ede33f-2a57-44bb-a563-7a37dda46bdf', 5
+      UNION ALL SELECT '3f2fdcde-cb17-488e-819e-99cafea3f984', '595e4e71-ad88-4a65-85d2-be98718f36df', 7)
+WHERE NOT EXISTS (SELECT 1 FROM transactions);
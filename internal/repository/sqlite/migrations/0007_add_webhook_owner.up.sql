@@ -0,0 +1,9 @@
+-- A webhook belongs to the API key that registered it and may be narrowed to
+-- some accounts (space-separated ids, empty for all). Webhooks registered
+-- before owners existed have none: only admin keys can manage them and they
+-- receive no events. SQLite cannot drop a column that is part of a foreign
+-- key, so owner_id is not declared as one.
+ALTER TABLE webhooks ADD COLUMN owner_id TEXT;
+ALTER TABLE webhooks ADD COLUMN accounts TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX webhooks_owner_idx ON webhooks (owner_id);
@@ -0,0 +1,3 @@
+DROP TABLE outbox_accounts;
+DROP INDEX outbox_account_sequence_idx;
+ALTER TABLE outbox DROP COLUMN account_sequence;
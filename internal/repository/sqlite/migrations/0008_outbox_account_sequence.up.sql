@@ -0,0 +1,20 @@
+-- Events are numbered per account. outbox_accounts holds each account's last
+-- number; SQLite serialises the write transactions that append events, so the
+-- events of one account commit in sequence order. The relay leases an account
+-- through claimed_until, and holds it back there after a failed publish.
+ALTER TABLE outbox ADD COLUMN account_sequence INTEGER NOT NULL DEFAULT 0;
+
+UPDATE outbox SET account_sequence = (
+    SELECT COUNT(*) FROM outbox o WHERE o.account_id = outbox.account_id AND o.id <= outbox.id
+);
+
+CREATE UNIQUE INDEX outbox_account_sequence_idx ON outbox (account_id, account_sequence);
+
+CREATE TABLE outbox_accounts (
+    account_id TEXT PRIMARY KEY,
+    last_sequence INTEGER NOT NULL,
+    claimed_until TIMESTAMP
+);
+
+INSERT INTO outbox_accounts (account_id, last_sequence)
+SELECT account_id, MAX(account_sequence) FROM outbox GROUP BY account_id;
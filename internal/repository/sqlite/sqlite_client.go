@@ -0,0 +1,72 @@
+// Package sqlite opens the SQLite database used by small deployments and
+// local tooling, and holds its migrations. The SQL repositories in
+// internal/repository run on it unchanged.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"github.com/XSAM/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"io/fs"
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/migrate"
+	"moneyTransfer/internal/tracing"
+	"moneyTransfer/pkg/logger"
+	"net/url"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const connectTimeout = 5 * time.Second
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// NewSQLiteClient opens the database file in WAL mode with foreign keys on.
+// Transactions begin IMMEDIATE, taking the write lock up front, so they are
+// serialised like the locked Postgres paths and never fail half-way with
+// SQLITE_BUSY; waiting writers retry for BusyTimeout.
+func NewSQLiteClient(cfg config.SQLiteConfig) (*sql.DB, error) {
+	params := url.Values{}
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", cfg.BusyTimeout.Milliseconds()))
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Set("_txlock", "immediate")
+	params.Set("_time_format", "sqlite")
+
+	db, err := otelsql.Open("sqlite", cfg.Path+"?"+params.Encode(), tracing.SQLOptions(semconv.DBSystemNameSQLite)...)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// Migrations returns the embedded SQLite migrations.
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+func NewMigrator(db *sql.DB, logger logger.Logger) (*migrate.Migrator, error) {
+	all, err := migrate.Load(Migrations())
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewDialectMigrator(db, migrate.SQLite, all, logger), nil
+}
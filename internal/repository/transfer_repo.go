@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"moneyTransfer/internal/domain/apperrors"
 	"moneyTransfer/internal/domain/contracts"
 	"moneyTransfer/internal/domain/model"
 )
@@ -20,7 +21,7 @@ func NewTransferRepository(db *sql.DB) *TransferRepo {
 func (r *TransferRepo) GetTransactionsByUserId(ctx context.Context, userId string) ([]model.Transaction, error) {
 	query := `SELECT id, sender_id, receiver_id, amount, status, created_at FROM transactions WHERE sender_id = $1 OR receiver_id = $1`
 
-	rows, err := r.db.QueryContext(ctx, query, userId)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, userId)
 	if err != nil {
 		return nil, err
 	}
@@ -49,18 +50,64 @@ func (r *TransferRepo) GetTransactionsByUserId(ctx context.Context, userId strin
 	return transfers, nil
 }
 
+func (r *TransferRepo) GetTransactionById(ctx context.Context, txId string) (model.Transaction, error) {
+	query := `SELECT id, sender_id, receiver_id, amount, status, created_at FROM transactions WHERE id = $1`
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, txId)
+
+	var transaction model.Transaction
+	err := row.Scan(
+		&transaction.Id,
+		&transaction.SenderId,
+		&transaction.ReceiverId,
+		&transaction.Amount,
+		&transaction.Status,
+		&transaction.CreatedAt,
+	)
+	if err != nil {
+		return model.Transaction{}, notFound(err, apperrors.CodeTransactionNotFound, "transaction not found")
+	}
+
+	return transaction, nil
+}
+
 func (r *TransferRepo) CreateTransfer(ctx context.Context, tx model.Transaction) error {
 	query := `INSERT INTO transactions (id, sender_id, receiver_id, amount, status, created_at)
               VALUES ($1, $2, $3, $4, $5, $6)`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
 		tx.Id, tx.SenderId, tx.ReceiverId, tx.Amount, tx.Status, tx.CreatedAt)
 	return err
 }
 
-func (r *TransferRepo) UpdateTransactionStatus(ctx context.Context, txId, status string) error {
-	query := `UPDATE transactions SET status = $1 WHERE id = $2`
+// FinishTransaction only updates a PENDING row. Inside a transaction the
+// updated row stays locked until commit, so a second delivery of the same job
+// waits and then finds the transaction already settled.
+func (r *TransferRepo) FinishTransaction(ctx context.Context, txId, status string) error {
+	query := `UPDATE transactions SET status = $1 WHERE id = $2 AND status = $3`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, status, txId, model.StatusPending)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		if _, err := r.GetTransactionById(ctx, txId); err != nil {
+			return err
+		}
+		return apperrors.Conflict(apperrors.CodeTransactionSettled, "transaction is no longer pending")
+	}
+	return nil
+}
+
+// DeletePendingTransaction removes a transaction that never made it onto the
+// queue. Rows that have already been picked up by the worker are left alone.
+func (r *TransferRepo) DeletePendingTransaction(ctx context.Context, txId string) error {
+	query := `DELETE FROM transactions WHERE id = $1 AND status = $2`
 
-	_, err := r.db.ExecContext(ctx, query, status, txId)
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, txId, model.StatusPending)
 	return err
 }
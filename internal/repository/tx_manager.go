@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/pkg/metrics"
+	"strings"
+	"time"
+)
+
+// DBTX is the subset of *sql.DB and *sql.Tx used by the repositories.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txContextKey struct{}
+
+type TxManager struct {
+	db *sql.DB
+}
+
+var _ contracts.TxManager = (*TxManager)(nil)
+
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{db}
+}
+
+func (m *TxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTransaction(ctx, m.db, fn)
+}
+
+func withinTransaction(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	return withinTx(ctx, db, nil, fn)
+}
+
+// withinSnapshot runs fn in a read-only REPEATABLE READ transaction, so every
+// statement sees the same committed data. Inside a transaction fn joins it.
+func withinSnapshot(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	return withinTx(ctx, db, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}, fn)
+}
+
+func withinTx(ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txContextKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(context.WithValue(ctx, txContextKey{}, tx)); err != nil {
+		tx.Rollback()
+		return transient(err)
+	}
+
+	return transient(tx.Commit())
+}
+
+// SQLSTATEs of transactions Postgres aborted to resolve a conflict with
+// another one; the same work succeeds when retried.
+const (
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// transient marks err as retryable when the database aborted the transaction
+// over a deadlock or a serialization failure.
+func transient(err error) error {
+	var state interface{ SQLState() string }
+	if !errors.As(err, &state) {
+		return err
+	}
+	switch state.SQLState() {
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return apperrors.Unavailable(apperrors.CodeConcurrentUpdate, "transaction aborted by a concurrent update, retry it", 0).WithCause(err)
+	}
+	return err
+}
+
+// conn returns the transaction carried by ctx, or db when there is none,
+// timing each statement in db_query_duration_seconds.
+func conn(ctx context.Context, db *sql.DB) DBTX {
+	if tx, ok := ctx.Value(txContextKey{}).(*sql.Tx); ok {
+		return timedDBTX{tx}
+	}
+	return timedDBTX{db}
+}
+
+// timedDBTX observes how long the database takes to answer a statement. For
+// queries that is until the first row is ready, not until the rows are read.
+type timedDBTX struct {
+	db DBTX
+}
+
+func (t timedDBTX) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	defer observe(query, time.Now())
+	return t.db.ExecContext(ctx, query, args...)
+}
+
+func (t timedDBTX) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	defer observe(query, time.Now())
+	return t.db.QueryContext(ctx, query, args...)
+}
+
+func (t timedDBTX) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	defer observe(query, time.Now())
+	return t.db.QueryRowContext(ctx, query, args...)
+}
+
+func observe(query string, start time.Time) {
+	metrics.DBQueryDuration.WithLabelValues(operation(query)).Observe(time.Since(start).Seconds())
+}
+
+// operation is the statement's leading keyword, e.g. "select", which keeps
+// the label's cardinality bounded.
+func operation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "unknown"
+	}
+	return strings.ToLower(fields[0])
+}
@@ -3,29 +3,42 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"moneyTransfer/internal/domain/apperrors"
 	"moneyTransfer/internal/domain/contracts"
 	"moneyTransfer/internal/domain/model"
+	"strings"
 )
 
 type UserRepo struct {
 	db *sql.DB
+	// lockRows is appended to the query Lock runs; empty when the database's
+	// write transactions already exclude each other.
+	lockRows string
 }
 
 var _ contracts.UserRepository = (*UserRepo)(nil)
 
 func NewUserRepository(db *sql.DB) *UserRepo {
-	return &UserRepo{db}
+	return &UserRepo{db: db, lockRows: ` FOR UPDATE`}
+}
+
+// NewLocklessUserRepository is for databases that serialise write
+// transactions themselves, such as SQLite opened with immediate transactions.
+func NewLocklessUserRepository(db *sql.DB) *UserRepo {
+	return &UserRepo{db: db}
 }
 
 func (r *UserRepo) GetBalance(ctx context.Context, userId string) (float64, error) {
 	query := `SELECT balance FROM users WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, query, userId)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, userId)
 
 	var balance float64
 
 	err := row.Scan(&balance)
 	if err != nil {
-		return 0, err
+		return 0, notFound(err, apperrors.CodeUserNotFound, "user not found")
 	}
 
 	return balance, nil
@@ -33,12 +46,12 @@ func (r *UserRepo) GetBalance(ctx context.Context, userId string) (float64, erro
 
 func (r *UserRepo) GetById(ctx context.Context, userId string) (model.User, error) {
 	query := `SELECT id, first_name, last_name, email, balance FROM users WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, query, userId)
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, userId)
 
 	var user model.User
 	err := row.Scan(&user.Id, &user.FirstName, &user.LastName, &user.Email, &user.Balance)
 	if err != nil {
-		return user, err
+		return user, notFound(err, apperrors.CodeUserNotFound, "user not found")
 	}
 
 	return user, nil
@@ -46,7 +59,69 @@ func (r *UserRepo) GetById(ctx context.Context, userId string) (model.User, erro
 
 func (r *UserRepo) UpdateBalance(ctx context.Context, userId string, newBalance float64) error {
 	query := `UPDATE users SET balance = $1 WHERE id = $2`
-	_, err := r.db.ExecContext(ctx, query, newBalance, userId)
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, newBalance, userId)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(res, apperrors.CodeUserNotFound, "user not found")
+}
+
+// Debit takes amount from the user's balance in a single statement, so
+// concurrent transfers cannot overwrite each other's updates, and returns the
+// balance before and after. It fails with an insufficient-funds error, and
+// changes nothing, when the balance does not cover amount.
+func (r *UserRepo) Debit(ctx context.Context, userId string, amount float64) (float64, float64, error) {
+	query := `UPDATE users SET balance = balance - $1 WHERE id = $2 AND balance >= $1 RETURNING balance + $1, balance`
+
+	var previous, balance float64
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, amount, userId).Scan(&previous, &balance)
+	if errors.Is(err, sql.ErrNoRows) {
+		if _, err := r.GetBalance(ctx, userId); err != nil {
+			return 0, 0, err
+		}
+		return 0, 0, apperrors.InsufficientFunds("insufficient funds")
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return previous, balance, nil
+}
 
-	return err
+// Credit adds amount to the user's balance in a single statement and returns
+// the balance before and after.
+func (r *UserRepo) Credit(ctx context.Context, userId string, amount float64) (float64, float64, error) {
+	query := `UPDATE users SET balance = balance + $1 WHERE id = $2 RETURNING balance - $1, balance`
+
+	var previous, balance float64
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, amount, userId).Scan(&previous, &balance)
+	if err != nil {
+		return 0, 0, notFound(err, apperrors.CodeUserNotFound, "user not found")
+	}
+
+	return previous, balance, nil
+}
+
+func (r *UserRepo) Lock(ctx context.Context, userIds ...string) error {
+	if r.lockRows == "" || len(userIds) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, len(userIds))
+	args := make([]any, len(userIds))
+	for i, id := range userIds {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = id
+	}
+	query := `SELECT id FROM users WHERE id IN (` + strings.Join(placeholders, ", ") + `) ORDER BY id` + r.lockRows
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
 }
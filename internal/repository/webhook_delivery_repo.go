@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"time"
+)
+
+// WebhookDeliveryRepo stores deliveries. Times are compared in SQL, so
+// callers pass them in UTC: SQLite compares the stored text.
+type WebhookDeliveryRepo struct {
+	db *sql.DB
+	// claimRows is appended to the Claim subquery so concurrent dispatchers
+	// take different deliveries instead of waiting for each other.
+	claimRows string
+}
+
+var _ contracts.WebhookDeliveryRepository = (*WebhookDeliveryRepo)(nil)
+
+func NewWebhookDeliveryRepository(db *sql.DB) *WebhookDeliveryRepo {
+	return &WebhookDeliveryRepo{db: db, claimRows: ` FOR UPDATE SKIP LOCKED`}
+}
+
+// NewLocklessWebhookDeliveryRepository is for databases that serialise write
+// transactions themselves, such as SQLite opened with immediate transactions.
+func NewLocklessWebhookDeliveryRepository(db *sql.DB) *WebhookDeliveryRepo {
+	return &WebhookDeliveryRepo{db: db}
+}
+
+const deliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts,
+              response_status, last_error, next_attempt_at, created_at, delivered_at`
+
+func (r *WebhookDeliveryRepo) Create(ctx context.Context, delivery model.WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries (` + deliveryColumns + `)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+              ON CONFLICT (webhook_id, event_id) DO NOTHING`
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		delivery.Id, delivery.WebhookId, delivery.EventId, delivery.EventType, string(delivery.Payload),
+		delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.LastError,
+		delivery.NextAttemptAt, delivery.CreatedAt, delivery.DeliveredAt)
+	return err
+}
+
+func (r *WebhookDeliveryRepo) GetById(ctx context.Context, id string) (model.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+	delivery, err := scanDelivery(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	return delivery, notFound(err, apperrors.CodeDeliveryNotFound, "delivery not found")
+}
+
+func (r *WebhookDeliveryRepo) ListByWebhook(ctx context.Context, webhookId string, limit int) ([]model.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+              WHERE webhook_id = $1 ORDER BY created_at DESC LIMIT $2`
+
+	return r.query(ctx, query, webhookId, limit)
+}
+
+func (r *WebhookDeliveryRepo) Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries SET next_attempt_at = $1
+              WHERE id IN (SELECT id FROM webhook_deliveries
+                           WHERE status = 'PENDING' AND next_attempt_at <= $2
+                           ORDER BY next_attempt_at LIMIT $3` + r.claimRows + `)
+              RETURNING ` + deliveryColumns
+
+	return r.query(ctx, query, leaseUntil, now, limit)
+}
+
+func (r *WebhookDeliveryRepo) Update(ctx context.Context, delivery model.WebhookDelivery) error {
+	query := `UPDATE webhook_deliveries SET status = $1, attempts = $2, response_status = $3, last_error = $4,
+              next_attempt_at = $5, delivered_at = $6 WHERE id = $7`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query,
+		delivery.Status, delivery.Attempts, delivery.ResponseStatus, delivery.LastError,
+		delivery.NextAttemptAt, delivery.DeliveredAt, delivery.Id)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(res, apperrors.CodeDeliveryNotFound, "delivery not found")
+}
+
+func (r *WebhookDeliveryRepo) query(ctx context.Context, query string, args ...any) ([]model.WebhookDelivery, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []model.WebhookDelivery
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return deliveries, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return deliveries, err
+	}
+
+	return deliveries, nil
+}
+
+func scanDelivery(row rowScanner) (model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	var payload string
+	var deliveredAt sql.NullTime
+
+	err := row.Scan(
+		&delivery.Id,
+		&delivery.WebhookId,
+		&delivery.EventId,
+		&delivery.EventType,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.ResponseStatus,
+		&delivery.LastError,
+		&delivery.NextAttemptAt,
+		&delivery.CreatedAt,
+		&deliveredAt,
+	)
+	if err != nil {
+		return model.WebhookDelivery{}, err
+	}
+
+	delivery.Payload = []byte(payload)
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"strings"
+)
+
+type WebhookRepo struct {
+	db *sql.DB
+}
+
+var _ contracts.WebhookRepository = (*WebhookRepo)(nil)
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepo {
+	return &WebhookRepo{db}
+}
+
+const webhookColumns = `id, owner_id, url, events, accounts, secret, created_at`
+
+func (r *WebhookRepo) Create(ctx context.Context, webhook model.Webhook) error {
+	query := `INSERT INTO webhooks (` + webhookColumns + `) VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	accounts := make([]string, len(webhook.Accounts))
+	for i, account := range webhook.Accounts {
+		accounts[i] = account.String()
+	}
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
+		webhook.Id, uuid.NullUUID{UUID: webhook.OwnerId, Valid: webhook.OwnerId != uuid.Nil}, webhook.URL,
+		strings.Join(webhook.Events, " "), strings.Join(accounts, " "), webhook.Secret, webhook.CreatedAt)
+	return err
+}
+
+func (r *WebhookRepo) GetById(ctx context.Context, id string) (model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = $1`
+	webhook, err := scanWebhook(conn(ctx, r.db).QueryRowContext(ctx, query, id))
+	return webhook, notFound(err, apperrors.CodeWebhookNotFound, "webhook not found")
+}
+
+func (r *WebhookRepo) List(ctx context.Context) ([]model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY created_at`
+	return r.list(ctx, query)
+}
+
+func (r *WebhookRepo) ListByOwner(ctx context.Context, ownerId string) ([]model.Webhook, error) {
+	query := `SELECT ` + webhookColumns + ` FROM webhooks WHERE owner_id = $1 ORDER BY created_at`
+	return r.list(ctx, query, ownerId)
+}
+
+func (r *WebhookRepo) list(ctx context.Context, query string, args ...any) ([]model.Webhook, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []model.Webhook
+
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return webhooks, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	if err := rows.Err(); err != nil {
+		return webhooks, err
+	}
+
+	return webhooks, nil
+}
+
+func (r *WebhookRepo) Reassign(ctx context.Context, fromOwnerId, toOwnerId string) error {
+	query := `UPDATE webhooks SET owner_id = $2 WHERE owner_id = $1`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, fromOwnerId, toOwnerId)
+	return err
+}
+
+func (r *WebhookRepo) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM webhooks WHERE id = $1`
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return requireAffected(res, apperrors.CodeWebhookNotFound, "webhook not found")
+}
+
+func scanWebhook(row rowScanner) (model.Webhook, error) {
+	var webhook model.Webhook
+	var owner uuid.NullUUID
+	var events, accounts string
+
+	err := row.Scan(&webhook.Id, &owner, &webhook.URL, &events, &accounts, &webhook.Secret, &webhook.CreatedAt)
+	if err != nil {
+		return model.Webhook{}, err
+	}
+
+	webhook.OwnerId = owner.UUID
+	webhook.Events = strings.Fields(events)
+	for _, account := range strings.Fields(accounts) {
+		id, err := uuid.Parse(account)
+		if err != nil {
+			return model.Webhook{}, err
+		}
+		webhook.Accounts = append(webhook.Accounts, id)
+	}
+	return webhook, nil
+}
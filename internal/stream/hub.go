@@ -0,0 +1,203 @@
+// Package stream pushes domain events to clients watching an account, over
+// Server-Sent Events or WebSocket.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/outbox"
+	"sync"
+)
+
+// EventResync is sent instead of a replay when the events after a client's
+// resume point are no longer retained; the client should refetch its state.
+const EventResync = "resync"
+
+// Hub is the outbox sink that fans events out to the subscribers of the
+// accounts they concern: the account an event is keyed by and, for transfers,
+// the receiver too. It keeps the latest events so a reconnecting client can
+// resume from the last one it saw. A subscriber that falls a full buffer
+// behind is dropped; it reconnects and catches up from the history.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[*Subscription]struct{}
+	bufferSize  int
+	// history holds the latest events in the order they were published.
+	history     []model.DomainEvent
+	historySize int
+	retained    map[int64]struct{}
+	// lastDropped is the highest sequence that has left the history, or the
+	// one before the first event the hub saw.
+	lastDropped int64
+	started     bool
+	closed      bool
+}
+
+var _ outbox.Sink = (*Hub)(nil)
+
+func NewHub(historySize, bufferSize int) *Hub {
+	return &Hub{
+		subscribers: make(map[string]map[*Subscription]struct{}),
+		bufferSize:  bufferSize,
+		historySize: historySize,
+		retained:    make(map[int64]struct{}),
+	}
+}
+
+// Subscription receives the events of one account until Close is called or
+// the hub drops it, either of which closes Events.
+type Subscription struct {
+	Events <-chan model.DomainEvent
+	// Replay holds the retained events after the resume point, oldest first.
+	Replay []model.DomainEvent
+	// Complete is false when some events after the resume point were no
+	// longer retained, so Replay may have gaps.
+	Complete bool
+
+	events    chan model.DomainEvent
+	accountId string
+	hub       *Hub
+}
+
+// Subscribe starts a subscription to accountId. When after is positive, the
+// retained events with a greater sequence are replayed first; nothing is
+// missed between the replay and the live events.
+func (h *Hub) Subscribe(accountId string, after int64) *Subscription {
+	events := make(chan model.DomainEvent, h.bufferSize)
+	sub := &Subscription{Events: events, Complete: true, events: events, accountId: accountId, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		close(events)
+		return sub
+	}
+
+	if after > 0 {
+		// Before its first event the hub cannot tell what the client missed.
+		sub.Complete = h.started && after >= h.lastDropped
+		for _, event := range h.history {
+			if event.Sequence > after && concerns(event, accountId) {
+				sub.Replay = append(sub.Replay, event)
+			}
+		}
+	}
+
+	if h.subscribers[accountId] == nil {
+		h.subscribers[accountId] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[accountId][sub] = struct{}{}
+	return sub
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// Publish never fails: a subscriber that cannot keep up is dropped rather
+// than holding up the relay. An event relayed twice is delivered once.
+func (h *Hub) Publish(_ context.Context, event model.DomainEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil
+	}
+	if _, ok := h.retained[event.Sequence]; ok {
+		return nil
+	}
+	h.retain(event)
+
+	for _, accountId := range accountsOf(event) {
+		for sub := range h.subscribers[accountId] {
+			select {
+			case sub.events <- event:
+			default:
+				h.remove(sub)
+			}
+		}
+	}
+	return nil
+}
+
+// Close ends every subscription; later events are discarded.
+func (h *Hub) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subs := range h.subscribers {
+		for sub := range subs {
+			h.remove(sub)
+		}
+	}
+	return nil
+}
+
+// Subscribers returns the number of open subscriptions.
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	count := 0
+	for _, subs := range h.subscribers {
+		count += len(subs)
+	}
+	return count
+}
+
+func (h *Hub) retain(event model.DomainEvent) {
+	if !h.started {
+		h.started = true
+		h.lastDropped = event.Sequence - 1
+	}
+	if len(h.history) == h.historySize {
+		dropped := h.history[0]
+		h.history = h.history[1:]
+		delete(h.retained, dropped.Sequence)
+		h.lastDropped = max(h.lastDropped, dropped.Sequence)
+	}
+	h.history = append(h.history, event)
+	h.retained[event.Sequence] = struct{}{}
+}
+
+// remove must be called with h.mu held.
+func (h *Hub) remove(sub *Subscription) {
+	subs := h.subscribers[sub.accountId]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subscribers, sub.accountId)
+	}
+	close(sub.events)
+}
+
+// accountsOf lists the accounts an event concerns.
+func accountsOf(event model.DomainEvent) []string {
+	accounts := []string{event.AccountId}
+
+	switch event.Type {
+	case model.EventTransferCreated, model.EventTransferSucceeded, model.EventTransferFailed:
+		var payload model.TransferEventPayload
+		if err := json.Unmarshal(event.Payload, &payload); err == nil && payload.ReceiverId.String() != event.AccountId {
+			accounts = append(accounts, payload.ReceiverId.String())
+		}
+	}
+	return accounts
+}
+
+func concerns(event model.DomainEvent, accountId string) bool {
+	for _, account := range accountsOf(event) {
+		if account == accountId {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	userAgent = "moneyTransfer-webhooks/1.0"
+	// maxResponseBody is how much of a response is read before the
+	// connection is reused; the body itself is ignored.
+	maxResponseBody = 64 << 10
+)
+
+// Dispatcher sends pending deliveries. A 2xx response completes a delivery;
+// anything else is retried with exponential backoff until MaxAttempts, after
+// which the delivery is marked failed and can only be redelivered manually.
+type Dispatcher struct {
+	webhooks   contracts.WebhookRepository
+	deliveries contracts.WebhookDeliveryRepository
+	client     *http.Client
+	cfg        config.WebhookConfig
+	log        logger.Logger
+}
+
+func NewDispatcher(webhooks contracts.WebhookRepository, deliveries contracts.WebhookDeliveryRepository, cfg config.WebhookConfig, logger logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		webhooks:   webhooks,
+		deliveries: deliveries,
+		client:     &http.Client{Timeout: cfg.Timeout, Transport: guardedTransport(cfg)},
+		cfg:        cfg,
+		log:        logger,
+	}
+}
+
+// guardedTransport dials through a Guard, so a webhook whose host came to
+// resolve to a non-public address after it was registered is not reached.
+// Proxies are not used: the guard could only check the proxy's address.
+func guardedTransport(cfg config.WebhookConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = NewGuard(cfg.AllowedHosts, net.DefaultResolver).DialContext
+	return transport
+}
+
+// Run dispatches batches until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	for {
+		sent, err := d.DispatchBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			d.log.Error("failed to dispatch webhook deliveries", "error", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if sent == d.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-time.After(d.cfg.PollInterval):
+		case <-ctx.Done():
+		}
+	}
+}
+
+// DispatchBatch attempts up to BatchSize due deliveries concurrently and
+// returns how many were attempted.
+func (d *Dispatcher) DispatchBatch(ctx context.Context) (int, error) {
+	// A claimed delivery whose dispatcher dies becomes due again once the
+	// lease, comfortably longer than one attempt, runs out.
+	now := time.Now().UTC()
+	deliveries, err := d.deliveries.Claim(ctx, now, now.Add(2*d.cfg.Timeout), d.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.attempt(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery model.WebhookDelivery) {
+	webhook, err := d.webhooks.GetById(ctx, delivery.WebhookId.String())
+	if errors.Is(err, apperrors.ErrNotFound) {
+		// Deleted after the claim; its deliveries went with it.
+		return
+	}
+	if err != nil {
+		d.log.Error("failed to load webhook", "webhookId", delivery.WebhookId, "error", err)
+		return
+	}
+
+	status, err := d.send(ctx, webhook, delivery)
+	if ctx.Err() != nil {
+		// Shutting down: leave the delivery to be retried when its lease ends.
+		return
+	}
+
+	now := time.Now().UTC()
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+	case delivery.Attempts >= d.cfg.MaxAttempts:
+		delivery.Status = model.DeliveryFailed
+		delivery.LastError = err.Error()
+		d.log.Warn("webhook delivery failed permanently",
+			"deliveryId", delivery.Id, "webhookId", webhook.Id, "attempts", delivery.Attempts, "error", err)
+	default:
+		delivery.LastError = err.Error()
+		delivery.NextAttemptAt = now.Add(d.Backoff(delivery.Attempts))
+		d.log.Info("webhook delivery failed, will retry",
+			"deliveryId", delivery.Id, "webhookId", webhook.Id, "attempts", delivery.Attempts, "error", err)
+	}
+
+	if err := d.deliveries.Update(ctx, delivery); err != nil {
+		d.log.Error("failed to record webhook delivery", "deliveryId", delivery.Id, "error", err)
+	}
+}
+
+// send posts the delivery and returns the response status, 0 when there was
+// no response.
+func (d *Dispatcher) send(ctx context.Context, webhook model.Webhook, delivery model.WebhookDelivery) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set(HeaderId, delivery.Id.String())
+	request.Header.Set(HeaderEvent, delivery.EventType)
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	request.Header.Set(HeaderSignature, Sign(webhook.Secret, now, delivery.Payload))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, maxResponseBody))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("unexpected response status %d", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
+
+// Backoff returns the wait after the given number of failed attempts:
+// InitialBackoff doubled for each attempt after the first, capped at
+// MaxBackoff.
+func (d *Dispatcher) Backoff(attempts int) time.Duration {
+	backoff := d.cfg.InitialBackoff
+	for i := 1; i < attempts && backoff < d.cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, d.cfg.MaxBackoff)
+}
@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/contracts"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/outbox"
+	"time"
+)
+
+// Fanout is the outbox sink that turns each relayed event into one pending
+// delivery per subscribed webhook. Deliveries are unique per webhook and
+// event, so an event the relay publishes again is not delivered twice.
+//
+// A webhook only receives events its owning API key could read through the
+// API: the key must be active and hold the transfers:read scope, and the
+// transfer must touch one of the accounts the webhook watches.
+type Fanout struct {
+	webhooks   contracts.WebhookRepository
+	deliveries contracts.WebhookDeliveryRepository
+	apiKeys    contracts.APIKeyRepository
+}
+
+var _ outbox.Sink = (*Fanout)(nil)
+
+func NewFanout(webhooks contracts.WebhookRepository, deliveries contracts.WebhookDeliveryRepository, apiKeys contracts.APIKeyRepository) *Fanout {
+	return &Fanout{webhooks: webhooks, deliveries: deliveries, apiKeys: apiKeys}
+}
+
+func (f *Fanout) Publish(ctx context.Context, event model.DomainEvent) error {
+	if !model.IsWebhookEventType(event.Type) {
+		return nil
+	}
+
+	var transfer model.TransferEventPayload
+	if err := json.Unmarshal(event.Payload, &transfer); err != nil {
+		return err
+	}
+
+	webhooks, err := f.webhooks.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	var payload []byte
+	owners := make(map[uuid.UUID]bool)
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Type) || !webhook.Watches(transfer.SenderId, transfer.ReceiverId) {
+			continue
+		}
+
+		allowed, seen := owners[webhook.OwnerId]
+		if !seen {
+			if allowed, err = f.ownerMaySee(ctx, webhook.OwnerId); err != nil {
+				return err
+			}
+			owners[webhook.OwnerId] = allowed
+		}
+		if !allowed {
+			continue
+		}
+
+		if payload == nil {
+			if payload, err = json.Marshal(event); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now().UTC()
+		err := f.deliveries.Create(ctx, model.WebhookDelivery{
+			Id:            uuid.New(),
+			WebhookId:     webhook.Id,
+			EventId:       event.Id,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        model.DeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ownerMaySee reports whether the key that registered a webhook may still read
+// transfers. Webhooks without an owner, or whose key was deleted, get nothing.
+func (f *Fanout) ownerMaySee(ctx context.Context, ownerId uuid.UUID) (bool, error) {
+	if ownerId == uuid.Nil {
+		return false, nil
+	}
+
+	key, err := f.apiKeys.GetById(ctx, ownerId.String())
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return !key.IsRevoked() && key.HasScope(model.ScopeTransfersRead), nil
+}
+
+func (f *Fanout) Close() error {
+	return nil
+}
@@ -0,0 +1,96 @@
+package webhook
+
+import (
+	"context"
+	"moneyTransfer/internal/domain/apperrors"
+	"net"
+	"strings"
+)
+
+// sharedAddressSpace is the carrier-grade NAT range, internal to a provider's
+// network like the private ranges.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+var (
+	ErrUnresolvableHost = apperrors.Validation(apperrors.CodeInvalidWebhookRequest, "url host could not be resolved")
+	ErrNonPublicAddress = apperrors.Validation(apperrors.CodeInvalidWebhookRequest, "url must point at a public address")
+)
+
+// Resolver looks up the addresses of a host; *net.Resolver is one.
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Guard keeps webhooks from reaching into the network the service runs in:
+// a host is only accepted when every address it resolves to is public, so
+// loopback, link-local (cloud metadata included) and private addresses are
+// refused. Hosts in the allowlist skip the check, for local development.
+type Guard struct {
+	allowed  map[string]bool
+	resolver Resolver
+	dialer   net.Dialer
+}
+
+func NewGuard(allowedHosts []string, resolver Resolver) *Guard {
+	allowed := make(map[string]bool, len(allowedHosts))
+	for _, host := range allowedHosts {
+		allowed[strings.ToLower(host)] = true
+	}
+	return &Guard{allowed: allowed, resolver: resolver}
+}
+
+// CheckHost fails with ErrNonPublicAddress when host resolves to an address
+// that is not public, and with ErrUnresolvableHost when it does not resolve.
+func (g *Guard) CheckHost(ctx context.Context, host string) error {
+	if g.allowed[strings.ToLower(host)] {
+		return nil
+	}
+	_, err := g.resolve(ctx, host)
+	return err
+}
+
+// DialContext dials addr the way CheckHost accepts it. It connects to the
+// address it checked, so a host that resolves differently after registration
+// or between lookups is still refused.
+func (g *Guard) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if g.allowed[strings.ToLower(host)] {
+		return g.dialer.DialContext(ctx, network, addr)
+	}
+
+	ip, err := g.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	return g.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+}
+
+// resolve returns the first address of host once all of them are known to be
+// public.
+func (g *Guard) resolve(ctx context.Context, host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		if !isPublic(ip) {
+			return nil, ErrNonPublicAddress
+		}
+		return ip, nil
+	}
+
+	addrs, err := g.resolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return nil, ErrUnresolvableHost
+	}
+	for _, addr := range addrs {
+		if !isPublic(addr.IP) {
+			return nil, ErrNonPublicAddress
+		}
+	}
+	return addrs[0].IP, nil
+}
+
+func isPublic(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified() && !sharedAddressSpace.Contains(ip)
+}
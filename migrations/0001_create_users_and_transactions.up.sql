@@ -0,0 +1,35 @@
+-- IF NOT EXISTS lets databases created from the old init.sql adopt the
+-- migrations; the block at the end adds what that schema lacks.
+CREATE TABLE IF NOT EXISTS users (
+    id UUID PRIMARY KEY,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    email TEXT UNIQUE NOT NULL,
+    balance NUMERIC DEFAULT 0,
+    -- funding the account started with; reconciliation recomputes balance from it
+    initial_balance NUMERIC NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id UUID PRIMARY KEY,
+    sender_id UUID REFERENCES users(id),
+    receiver_id UUID REFERENCES users(id),
+    amount NUMERIC NOT NULL,
+    status TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- The first init.sql had no initial_balance. Add it and backfill it as the
+-- balance with every successful transfer undone, so reconciliation balances.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM information_schema.columns
+                   WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'initial_balance') THEN
+        ALTER TABLE users ADD COLUMN initial_balance NUMERIC NOT NULL DEFAULT 0;
+
+        UPDATE users u SET initial_balance = COALESCE(u.balance, 0)
+            - COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.receiver_id = u.id AND t.status = 'SUCCESS'), 0)
+            + COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.sender_id = u.id AND t.status = 'SUCCESS'), 0);
+    END IF;
+END
+$$;
@@ -0,0 +1,11 @@
+-- Jobs for the Postgres queue backend. available_at hides a claimed job until
+-- its visibility timeout passes.
+CREATE TABLE IF NOT EXISTS transfer_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    payload TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    available_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS transfer_jobs_available_at_idx ON transfer_jobs (available_at);
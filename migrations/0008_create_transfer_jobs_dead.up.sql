@@ -0,0 +1,11 @@
+-- Jobs the Postgres queue gave up on after queue.max_attempts deliveries. They
+-- are kept for an operator to inspect and re-enqueue instead of being retried
+-- forever.
+CREATE TABLE IF NOT EXISTS transfer_jobs_dead (
+    id BIGINT PRIMARY KEY,
+    payload TEXT NOT NULL,
+    attempts INT NOT NULL,
+    last_error TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    dead_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS webhooks_owner_idx;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS accounts;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS owner_id;
@@ -0,0 +1,8 @@
+-- A webhook belongs to the API key that registered it and may be narrowed to
+-- some accounts (space-separated ids, empty for all). Webhooks registered
+-- before owners existed have none: only admin keys can manage them and they
+-- receive no events.
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS owner_id UUID REFERENCES api_keys(id);
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS accounts TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS webhooks_owner_idx ON webhooks (owner_id);
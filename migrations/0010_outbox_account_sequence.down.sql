@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS outbox_accounts;
+DROP INDEX IF EXISTS outbox_account_sequence_idx;
+ALTER TABLE outbox DROP COLUMN IF EXISTS account_sequence;
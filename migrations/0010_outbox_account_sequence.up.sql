@@ -0,0 +1,23 @@
+-- Events are numbered per account. outbox_accounts holds each account's last
+-- number: appending an event locks its row until the transaction ends, so the
+-- events of one account commit in sequence order. The relay leases an account
+-- through claimed_until, and holds it back there after a failed publish.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS account_sequence BIGINT;
+
+UPDATE outbox SET account_sequence = numbered.n
+FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY account_id ORDER BY id) AS n FROM outbox) numbered
+WHERE outbox.id = numbered.id;
+
+ALTER TABLE outbox ALTER COLUMN account_sequence SET NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS outbox_account_sequence_idx ON outbox (account_id, account_sequence);
+
+CREATE TABLE IF NOT EXISTS outbox_accounts (
+    account_id TEXT PRIMARY KEY,
+    last_sequence BIGINT NOT NULL,
+    claimed_until TIMESTAMPTZ
+);
+
+INSERT INTO outbox_accounts (account_id, last_sequence)
+SELECT account_id, MAX(account_sequence) FROM outbox GROUP BY account_id
+ON CONFLICT (account_id) DO NOTHING;
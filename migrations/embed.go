@@ -0,0 +1,8 @@
+// Package migrations holds the numbered SQL migrations embedded in the binary.
+// Each version has a NNNN_name.up.sql and a matching NNNN_name.down.sql file.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,76 @@
+package logger
+
+import "context"
+
+type fieldsContextKey struct{}
+
+// WithFields returns a context carrying args, key-value pairs like those
+// passed to Info, for every line logged through WithContext. Fields
+// accumulate; a later value for a key replaces the earlier one.
+func WithFields(ctx context.Context, args ...any) context.Context {
+	fields := fieldsFromContext(ctx)
+	merged := make([]any, 0, len(fields)+len(args))
+	for i := 0; i+1 < len(fields); i += 2 {
+		if !hasKey(args, fields[i]) {
+			merged = append(merged, fields[i], fields[i+1])
+		}
+	}
+	merged = append(merged, args...)
+	return context.WithValue(ctx, fieldsContextKey{}, merged)
+}
+
+// WithContext returns l adding the fields stored in ctx to each line, or l
+// itself when there are none. A key passed explicitly wins over the same
+// key in ctx.
+func WithContext(ctx context.Context, l Logger) Logger {
+	fields := fieldsFromContext(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return contextLogger{Logger: l, fields: fields}
+}
+
+func fieldsFromContext(ctx context.Context) []any {
+	fields, _ := ctx.Value(fieldsContextKey{}).([]any)
+	return fields
+}
+
+func hasKey(args []any, key any) bool {
+	for i := 0; i+1 < len(args); i += 2 {
+		if args[i] == key {
+			return true
+		}
+	}
+	return false
+}
+
+type contextLogger struct {
+	Logger
+	fields []any
+}
+
+func (l contextLogger) with(args []any) []any {
+	merged := append(make([]any, 0, len(args)+len(l.fields)), args...)
+	for i := 0; i+1 < len(l.fields); i += 2 {
+		if !hasKey(args, l.fields[i]) {
+			merged = append(merged, l.fields[i], l.fields[i+1])
+		}
+	}
+	return merged
+}
+
+func (l contextLogger) Debug(msg string, args ...any) {
+	l.Logger.Debug(msg, l.with(args)...)
+}
+
+func (l contextLogger) Info(msg string, args ...any) {
+	l.Logger.Info(msg, l.with(args)...)
+}
+
+func (l contextLogger) Warn(msg string, args ...any) {
+	l.Logger.Warn(msg, l.with(args)...)
+}
+
+func (l contextLogger) Error(msg string, args ...any) {
+	l.Logger.Error(msg, l.with(args)...)
+}
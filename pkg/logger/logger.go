@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 )
@@ -18,8 +20,72 @@ type loggerImpl struct {
 
 var Log Logger
 
-func Init() {
-	Log = &loggerImpl{slog.New(slog.NewJSONHandler(os.Stdout, nil))}
+// Level is the minimum level written by Log. It can be changed while the
+// process runs.
+var Level = new(slog.LevelVar)
+
+// Output formats.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options configures a logger.
+type Options struct {
+	Level slog.Level
+	// Format is FormatJSON or FormatText; empty means JSON.
+	Format string
+	// File, when its Path is set, receives every line written to stdout too.
+	File      FileOptions
+	Sampling  Sampling
+	Redaction Redaction
+}
+
+// Init installs the process logger, writing to stdout and to the file sink
+// when one is configured. Its level can later be changed through Level.
+func Init(opts Options) error {
+	writers := []io.Writer{os.Stdout}
+	if opts.File.Path != "" {
+		file, err := OpenRotatingFile(opts.File)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		writers = append(writers, file)
+	}
+
+	Level.Set(opts.Level)
+	Log = &loggerImpl{slog.New(newHandler(writers, Level, opts))}
+	return nil
+}
+
+// New returns a Logger writing to w with a fixed level.
+func New(w io.Writer, opts Options) Logger {
+	return &loggerImpl{slog.New(newHandler([]io.Writer{w}, opts.Level, opts))}
+}
+
+func newHandler(writers []io.Writer, level slog.Leveler, opts Options) slog.Handler {
+	handlerOpts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: newRedactor(opts.Redaction).replaceAttr,
+	}
+
+	handlers := make(multiHandler, len(writers))
+	for i, w := range writers {
+		if opts.Format == FormatText {
+			handlers[i] = slog.NewTextHandler(w, handlerOpts)
+		} else {
+			handlers[i] = slog.NewJSONHandler(w, handlerOpts)
+		}
+	}
+
+	var handler slog.Handler = handlers
+	if len(handlers) == 1 {
+		handler = handlers[0]
+	}
+	if opts.Sampling.enabled() {
+		handler = &samplingHandler{Handler: handler, sampler: newSampler(opts.Sampling)}
+	}
+	return handler
 }
 
 func (l *loggerImpl) Debug(msg string, args ...any) {
@@ -0,0 +1,230 @@
+package logger
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Redaction actions.
+const (
+	RedactKeep = "keep"
+	RedactMask = "mask"
+	RedactHash = "hash"
+)
+
+// Classes of sensitive data. A struct field is put in a class with a tag
+// such as `redact:"email"`; attributes and untagged fields are classified by
+// name through fieldClasses.
+const (
+	ClassEmail  = "email"
+	ClassName   = "name"
+	ClassAmount = "amount"
+)
+
+const maskedValue = "******"
+
+// fieldClasses classifies attribute keys and JSON field names that hold
+// sensitive data wherever they appear.
+var fieldClasses = map[string]string{
+	"email":      ClassEmail,
+	"first_name": ClassName,
+	"last_name":  ClassName,
+	"amount":     ClassAmount,
+	"balance":    ClassAmount,
+}
+
+// Redaction says what happens to each class of sensitive data in log lines.
+// Classes without an action are kept.
+type Redaction struct {
+	Actions map[string]string
+	// HashKey keys the HMAC used by the hash action, so hashes of guessable
+	// values such as amounts cannot be reversed without it. When empty, a
+	// plain SHA-256 is used.
+	HashKey string
+}
+
+// redactor applies a Redaction as a slog ReplaceAttr function.
+type redactor struct {
+	actions map[string]string
+	hashKey []byte
+	// plans caches a *typePlan per struct type.
+	plans sync.Map
+}
+
+func newRedactor(r Redaction) *redactor {
+	return &redactor{actions: r.Actions, hashKey: []byte(r.HashKey)}
+}
+
+func (r *redactor) replaceAttr(_ []string, a slog.Attr) slog.Attr {
+	if class, ok := fieldClasses[a.Key]; ok {
+		if action := r.actions[class]; action != "" && action != RedactKeep {
+			return slog.Any(a.Key, r.apply(action, a.Value.Any()))
+		}
+	}
+	if a.Value.Kind() == slog.KindAny {
+		if v, ok := r.redact(reflect.ValueOf(a.Value.Any())); ok {
+			return slog.Any(a.Key, v)
+		}
+	}
+	return a
+}
+
+func (r *redactor) apply(action string, v any) any {
+	switch action {
+	case RedactMask:
+		return maskedValue
+	case RedactHash:
+		var mac []byte
+		text := []byte(fmt.Sprint(v))
+		if len(r.hashKey) > 0 {
+			h := hmac.New(sha256.New, r.hashKey)
+			h.Write(text)
+			mac = h.Sum(nil)
+		} else {
+			sum := sha256.Sum256(text)
+			mac = sum[:]
+		}
+		return "sha256:" + hex.EncodeToString(mac[:8])
+	default:
+		return v
+	}
+}
+
+// redact rebuilds v with its sensitive fields redacted, as the maps and
+// slices json.Marshal would have produced. It reports false, leaving v to be
+// logged as is, when v holds nothing to redact.
+func (r *redactor) redact(v reflect.Value) (any, bool) {
+	if !v.IsValid() || !r.mayRedact(v.Type()) {
+		return nil, false
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil, false
+		}
+		return r.redact(v.Elem())
+	case reflect.Slice, reflect.Array:
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = r.value(v.Index(i))
+		}
+		return out, true
+	case reflect.Map:
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = r.value(iter.Value())
+		}
+		return out, true
+	case reflect.Struct:
+		plan := r.plan(v.Type())
+		out := make(map[string]any, len(plan.fields))
+		for _, f := range plan.fields {
+			field := v.Field(f.index)
+			if f.omitEmpty && field.IsZero() {
+				continue
+			}
+			if action := r.actions[f.class]; f.class != "" && action != "" && action != RedactKeep {
+				out[f.name] = r.apply(action, field.Interface())
+				continue
+			}
+			out[f.name] = r.value(field)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// value is v redacted, or v itself when it holds nothing to redact.
+func (r *redactor) value(v reflect.Value) any {
+	if redacted, ok := r.redact(v); ok {
+		return redacted
+	}
+	return v.Interface()
+}
+
+// mayRedact reports whether values of t can contain a classified field.
+func (r *redactor) mayRedact(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return r.mayRedact(t.Elem())
+	case reflect.Struct:
+		return r.plan(t).sensitive
+	}
+	return false
+}
+
+type fieldPlan struct {
+	index     int
+	name      string
+	class     string
+	omitEmpty bool
+}
+
+type typePlan struct {
+	fields []fieldPlan
+	// sensitive is set when a field, possibly nested, has a class.
+	sensitive bool
+}
+
+// plan describes how a struct type is logged: its exported fields under
+// their JSON names, with their class. Types that marshal themselves, such as
+// time.Time, are left alone.
+func (r *redactor) plan(t reflect.Type) *typePlan {
+	if cached, ok := r.plans.Load(t); ok {
+		return cached.(*typePlan)
+	}
+
+	plan := &typePlan{}
+	// Stored before the fields are inspected, so recursive types end.
+	r.plans.Store(t, plan)
+	if marshalsItself(t) {
+		return plan
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		class := field.Tag.Get("redact")
+		if class == "" {
+			class = fieldClasses[name]
+		}
+		plan.fields = append(plan.fields, fieldPlan{index: i, name: name, class: class, omitEmpty: strings.Contains(opts, "omitempty")})
+		if class != "" || r.mayRedact(field.Type) {
+			plan.sensitive = true
+		}
+	}
+	return plan
+}
+
+var (
+	jsonMarshaler = reflect.TypeFor[json.Marshaler]()
+	textMarshaler = reflect.TypeFor[encoding.TextMarshaler]()
+	errorType     = reflect.TypeFor[error]()
+)
+
+func marshalsItself(t reflect.Type) bool {
+	for _, iface := range []reflect.Type{jsonMarshaler, textMarshaler, errorType} {
+		if t.Implements(iface) || reflect.PointerTo(t).Implements(iface) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// multiHandler writes each record to every handler.
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(multiHandler, len(m))
+	for i, h := range m {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	out := make(multiHandler, len(m))
+	for i, h := range m {
+		out[i] = h.WithGroup(name)
+	}
+	return out
+}
+
+// FileOptions configures the file sink.
+type FileOptions struct {
+	Path string
+	// MaxSizeMB is the size at which the file is rotated; 0 never rotates.
+	MaxSizeMB int
+	// MaxBackups is how many rotated files are kept, as Path.1 (the newest)
+	// to Path.N.
+	MaxBackups int
+}
+
+// RotatingFile is an append-only file that is rotated once it would grow
+// past its maximum size.
+type RotatingFile struct {
+	opts    FileOptions
+	maxSize int64
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotatingFile opens opts.Path for appending, creating it if needed.
+func OpenRotatingFile(opts FileOptions) (*RotatingFile, error) {
+	f := &RotatingFile{opts: opts, maxSize: int64(opts.MaxSizeMB) << 20}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.opts.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups up by one, dropping the oldest, and starts a new
+// file.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	path := f.opts.Path
+	if f.opts.MaxBackups == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return f.open()
+	}
+
+	for i := f.opts.MaxBackups - 1; i > 0; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", path, i), fmt.Sprintf("%s.%d", path, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		return err
+	}
+	return f.open()
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// Sampling limits how often the same debug or info message is written.
+// Within each Interval the first First records with a given level and
+// message are written, then every Thereafter-th; warnings and errors are
+// always written. A zero Interval disables sampling.
+type Sampling struct {
+	Interval   time.Duration
+	First      int
+	Thereafter int
+}
+
+func (s Sampling) enabled() bool {
+	return s.Interval > 0
+}
+
+type sampleKey struct {
+	level slog.Level
+	msg   string
+}
+
+type sampler struct {
+	Sampling
+
+	mu     sync.Mutex
+	window time.Time
+	counts map[sampleKey]int
+}
+
+func newSampler(s Sampling) *sampler {
+	return &sampler{Sampling: s, counts: map[sampleKey]int{}}
+}
+
+func (s *sampler) allow(r slog.Record) bool {
+	if r.Level >= slog.LevelWarn {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Time.Sub(s.window) >= s.Interval {
+		s.window = r.Time
+		clear(s.counts)
+	}
+
+	key := sampleKey{r.Level, r.Message}
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= s.First {
+		return true
+	}
+	return s.Thereafter > 0 && (n-s.First)%s.Thereafter == 0
+}
+
+// samplingHandler drops the records its sampler does not allow. Handlers
+// derived from it share the sampler, so counts cover the whole logger.
+type samplingHandler struct {
+	slog.Handler
+	sampler *sampler
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if !h.sampler.allow(r) {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), sampler: h.sampler}
+}
@@ -2,27 +2,106 @@ package metrics
 
 import (
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
-var (
-	HttpRequestCountWithPath = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "Number of HTTP requests.",
-		},
-		[]string{"url"},
+// NewRegistry returns the registry served on /metrics: the Go runtime and
+// process collectors plus the transfer, queue and database metrics. Callers
+// register the HTTP metrics and the queue depth on it.
+func NewRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		TransfersTotal,
+		TransferAmount,
+		TransferProcessingDuration,
+		JobsInFlight,
+		JobRetries,
+		JobsDeadLettered,
+		DBQueryDuration,
 	)
+	return reg
+}
 
-	HttpRequestDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name: "http_request_duration_seconds",
-			Help: "Response time of HTTP request.",
-		},
-		[]string{"path"},
-	)
-)
+// HTTPMetrics are the request metrics recorded by the HTTP middleware. They
+// are labelled by route template, not path, so that each user id does not
+// become a series of its own.
+type HTTPMetrics struct {
+	Requests     *prometheus.CounterVec
+	Duration     *prometheus.HistogramVec
+	ResponseSize *prometheus.HistogramVec
+	InFlight     *prometheus.GaugeVec
+}
+
+func NewHTTPMetrics(reg prometheus.Registerer) *HTTPMetrics {
+	m := &HTTPMetrics{
+		Requests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_requests_total",
+				Help: "Number of HTTP requests by method, route and status code.",
+			},
+			[]string{"method", "route", "code"},
+		),
+		Duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "http_request_duration_seconds",
+				Help: "Response time of HTTP requests by method, route and status code.",
+			},
+			[]string{"method", "route", "code"},
+		),
+		ResponseSize: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_response_size_bytes",
+				Help:    "Size of HTTP response bodies by method, route and status code.",
+				Buckets: prometheus.ExponentialBuckets(100, 10, 6),
+			},
+			[]string{"method", "route", "code"},
+		),
+		InFlight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "http_requests_in_flight",
+				Help: "Number of HTTP requests being served by method and route.",
+			},
+			[]string{"method", "route"},
+		),
+	}
+	reg.MustRegister(m.Requests, m.Duration, m.ResponseSize, m.InFlight)
+	return m
+}
+
+// GRPCMetrics are the call metrics recorded by the gRPC interceptors,
+// labelled by service and method name.
+type GRPCMetrics struct {
+	Handled  *prometheus.CounterVec
+	Duration *prometheus.HistogramVec
+	InFlight *prometheus.GaugeVec
+}
 
-func init() {
-	prometheus.MustRegister(HttpRequestCountWithPath)
-	prometheus.MustRegister(HttpRequestDuration)
+func NewGRPCMetrics(reg prometheus.Registerer) *GRPCMetrics {
+	m := &GRPCMetrics{
+		Handled: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "grpc_server_handled_total",
+				Help: "Number of gRPC calls completed by service, method and status code.",
+			},
+			[]string{"service", "method", "code"},
+		),
+		Duration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name: "grpc_server_handling_seconds",
+				Help: "Time taken by gRPC calls by service, method and status code, including whole streams.",
+			},
+			[]string{"service", "method", "code"},
+		),
+		InFlight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "grpc_server_in_flight",
+				Help: "Number of gRPC calls being served by service and method.",
+			},
+			[]string{"service", "method"},
+		),
+	}
+	reg.MustRegister(m.Handled, m.Duration, m.InFlight)
+	return m
 }
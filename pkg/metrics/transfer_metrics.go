@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// The transfer, queue and database metrics are recorded where the work
+// happens, without being passed around; NewRegistry exports them.
+var (
+	TransfersTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "transfers_total",
+			Help: "Number of processed transfers by final status and failure reason.",
+		},
+		[]string{"status", "reason"},
+	)
+
+	TransferAmount = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "transfer_amount",
+			Help:    "Amount of processed transfers by final status.",
+			Buckets: []float64{1, 5, 10, 50, 100, 500, 1000, 5000, 10000, 50000, 100000},
+		},
+		[]string{"status"},
+	)
+
+	TransferProcessingDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "transfer_processing_duration_seconds",
+			Help:    "Time from a transfer's creation to its final status.",
+			Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300},
+		},
+	)
+
+	JobsInFlight = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "transfer_jobs_in_flight",
+			Help: "Number of transfer jobs being processed by workers.",
+		},
+	)
+
+	JobRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "transfer_job_retries_total",
+			Help: "Number of failed transfer jobs handed back to the queue for redelivery.",
+		},
+		[]string{"queue"},
+	)
+
+	JobsDeadLettered = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "transfer_jobs_dead_lettered_total",
+			Help: "Number of transfer jobs given up on after queue.max_attempts deliveries.",
+		},
+		[]string{"queue"},
+	)
+
+	DBQueryDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Duration of repository SQL statements by operation.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		},
+		[]string{"operation"},
+	)
+)
+
+// queueDepthTimeout bounds how long a scrape waits for the queue backend.
+const queueDepthTimeout = time.Second
+
+var queueDepthDesc = prometheus.NewDesc("transfer_queue_depth", "Number of transfer jobs waiting in the queue.", nil, nil)
+
+// queueDepthCollector asks the queue for its depth on every scrape. Nothing
+// is reported when the backend cannot answer, rather than a stale value.
+type queueDepthCollector struct {
+	depth func(ctx context.Context) (int, error)
+}
+
+// RegisterQueueDepth exports transfer_queue_depth from depth on reg.
+func RegisterQueueDepth(reg prometheus.Registerer, depth func(ctx context.Context) (int, error)) {
+	reg.MustRegister(queueDepthCollector{depth: depth})
+}
+
+func (c queueDepthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueDepthDesc
+}
+
+func (c queueDepthCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), queueDepthTimeout)
+	defer cancel()
+
+	depth, err := c.depth(ctx)
+	if err != nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(queueDepthDesc, prometheus.GaugeValue, float64(depth))
+}
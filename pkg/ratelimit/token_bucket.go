@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+}
+
+// Limiter is a keyed token bucket. Each key gets its own bucket holding up
+// to burst tokens, refilled continuously at rate tokens per second.
+type Limiter struct {
+	rate  float64
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	// Now is the clock used for refills. It can be replaced in tests.
+	Now func() time.Time
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is how long until the next token is available. Zero when allowed.
+	RetryAfter time.Duration
+}
+
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+		Now:     time.Now,
+	}
+}
+
+func (l *Limiter) Limit() int {
+	return l.burst
+}
+
+// Window is the time it takes an empty bucket to refill completely.
+func (l *Limiter) Window() time.Duration {
+	return l.fillTime(float64(l.burst))
+}
+
+// Allow takes one token from the bucket for key if one is available.
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return takeAll([]Take{{Limiter: l, Key: key}})[0]
+}
+
+// Take names the bucket for Key in Limiter.
+type Take struct {
+	Limiter *Limiter
+	Key     string
+}
+
+// multiMu serialises AllowAll calls, so the limiters they lock one after the
+// other cannot deadlock. Allow holds a single lock and does not need it.
+var multiMu sync.Mutex
+
+// AllowAll takes one token from every bucket in takes, or from none of them
+// if any is empty, so a request refused by one limit does not use up the
+// others. It returns each bucket's result, in the order of takes.
+func AllowAll(takes ...Take) []Result {
+	multiMu.Lock()
+	defer multiMu.Unlock()
+
+	locked := make(map[*Limiter]bool, len(takes))
+	for _, take := range takes {
+		if !locked[take.Limiter] {
+			take.Limiter.mu.Lock()
+			defer take.Limiter.mu.Unlock()
+			locked[take.Limiter] = true
+		}
+	}
+
+	return takeAll(takes)
+}
+
+// takeAll implements AllowAll. The mutex of every limiter must be held.
+func takeAll(takes []Take) []Result {
+	buckets := make([]*bucket, len(takes))
+	allowed := true
+	for i, take := range takes {
+		buckets[i] = take.Limiter.refill(take.Key)
+		allowed = allowed && buckets[i].tokens >= 1
+	}
+
+	results := make([]Result, len(takes))
+	for i, take := range takes {
+		l, b := take.Limiter, buckets[i]
+		results[i] = Result{Limit: l.burst}
+		if allowed {
+			b.tokens--
+			results[i].Allowed = true
+		} else if b.tokens < 1 {
+			results[i].RetryAfter = l.fillTime(1 - b.tokens)
+		}
+
+		results[i].Remaining = int(math.Floor(math.Max(b.tokens, 0)))
+		results[i].Reset = l.fillTime(float64(l.burst) - b.tokens)
+	}
+	return results
+}
+
+// refill returns the bucket for key topped up to now. l.mu must be held.
+func (l *Limiter) refill(key string) *bucket {
+	now := l.Now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(l.burst), b.tokens+elapsed*l.rate)
+		b.updated = now
+	}
+	return b
+}
+
+// sweep drops buckets that have been idle long enough to be full again,
+// since a fresh bucket behaves identically. It runs at most once per window.
+func (l *Limiter) sweep(now time.Time) {
+	window := l.Window()
+	if now.Sub(l.lastSweep) < window {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= window {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *Limiter) fillTime(tokens float64) time.Duration {
+	if l.rate <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
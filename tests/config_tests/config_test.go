@@ -0,0 +1,223 @@
+package config_tests
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"moneyTransfer/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, config.Default().Worker, cfg.Worker)
+	assert.Equal(t, config.Default().Queue, cfg.Queue)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeConfigFile(t, `
+server:
+  port: 9000
+  read_timeout: 3s
+worker:
+  count: 4
+log:
+  level: debug
+`)
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("WORKER_COUNT", "6")
+	t.Setenv("POSTGRES_PASSWORD", "secret")
+
+	cfg, err := config.Load([]string{"-port", "9100"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 9100, cfg.Server.Port, "flag beats file")
+	assert.Equal(t, 6, cfg.Worker.Count, "env beats file")
+	assert.Equal(t, 3*time.Second, cfg.Server.ReadTimeout, "file beats default")
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, "secret", cfg.Database.Password)
+}
+
+func TestLoad_RejectsUnknownFileKeys(t *testing.T) {
+	path := writeConfigFile(t, "server:\n  prot: 9000\n")
+
+	_, err := config.Load([]string{"-config", path})
+	require.Error(t, err)
+}
+
+func TestLoad_RejectsMalformedEnv(t *testing.T) {
+	t.Setenv("QUEUE_ENQUEUE_TIMEOUT", "soon")
+
+	_, err := config.Load(nil)
+	require.ErrorContains(t, err, "QUEUE_ENQUEUE_TIMEOUT")
+}
+
+func TestValidate_ReportsAllProblems(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.Port = 0
+	cfg.Worker.Count = 0
+	cfg.Log.Level = "loud"
+
+	err := cfg.Validate()
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "server.port")
+	assert.ErrorContains(t, err, "worker.count")
+	assert.ErrorContains(t, err, "log.level")
+}
+
+func TestLoad_StorageDriver(t *testing.T) {
+	t.Setenv("STORAGE_DRIVER", "postgres")
+
+	cfg, err := config.Load([]string{"-storage", "memory"})
+	require.NoError(t, err)
+	assert.Equal(t, config.StorageMemory, cfg.Storage.Driver)
+
+	_, err = config.Load([]string{"-storage", "mongo"})
+	require.ErrorContains(t, err, "storage.driver")
+}
+
+func TestValidate_PostgresQueueNeedsPostgresStorage(t *testing.T) {
+	cfg := config.Default()
+	cfg.Storage.Driver = config.StorageMemory
+	cfg.Queue.Driver = config.QueuePostgres
+
+	require.ErrorContains(t, cfg.Validate(), "queue.driver postgres")
+}
+
+func TestValidate_QueueMaxAttempts(t *testing.T) {
+	cfg := config.Default()
+	cfg.Queue.Driver = config.QueueNATS
+	cfg.Queue.MaxAttempts = 0
+
+	require.ErrorContains(t, cfg.Validate(), "queue.max_attempts")
+}
+
+func TestValidate_SQLiteNeedsPath(t *testing.T) {
+	cfg := config.Default()
+	cfg.Storage.Driver = config.StorageSQLite
+	cfg.Storage.SQLite.Path = ""
+
+	require.ErrorContains(t, cfg.Validate(), "storage.sqlite.path")
+}
+
+func TestValidate_WebhookBackoffRange(t *testing.T) {
+	cfg := config.Default()
+	cfg.Webhooks.InitialBackoff = time.Minute
+	cfg.Webhooks.MaxBackoff = time.Second
+
+	require.ErrorContains(t, cfg.Validate(), "webhooks.max_backoff")
+}
+
+func TestLoad_WebhookAllowedHostsFromEnv(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOWED_HOSTS", "localhost, receiver.internal,")
+
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"localhost", "receiver.internal"}, cfg.Webhooks.AllowedHosts)
+}
+
+func TestLoad_TraceExporter(t *testing.T) {
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+
+	cfg, err := config.Load([]string{"-trace-exporter", "stdout"})
+	require.NoError(t, err)
+	assert.Equal(t, config.TraceExporterStdout, cfg.Tracing.Exporter)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRatio)
+
+	_, err = config.Load([]string{"-trace-exporter", "zipkin"})
+	require.ErrorContains(t, err, "tracing.exporter")
+}
+
+func TestValidate_MaxJobDurationExceedsProcessingDelay(t *testing.T) {
+	cfg := config.Default()
+	cfg.Worker.ProcessingDelay = 2 * time.Minute
+
+	require.ErrorContains(t, cfg.Validate(), "health.max_job_duration")
+}
+
+func TestValidate_SkipsDatabaseForMemoryStorage(t *testing.T) {
+	cfg := config.Default()
+	cfg.Storage.Driver = config.StorageMemory
+	cfg.Database.Host = ""
+
+	require.NoError(t, cfg.Validate())
+}
+
+func TestDump_RedactsSecrets(t *testing.T) {
+	cfg := config.Default()
+	cfg.Database.Password = "hunter2"
+	cfg.Auth.AdminAPIKey = "mtk_super_secret_admin_key_0123456789"
+
+	dump := cfg.Dump()
+
+	assert.NotContains(t, dump, "hunter2")
+	assert.NotContains(t, dump, "mtk_super_secret")
+	assert.Contains(t, dump, "******")
+	assert.Contains(t, dump, "shutdown_timeout: 10s")
+	assert.Equal(t, "hunter2", cfg.Database.Password, "the original is left untouched")
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_RedactionFromEnv(t *testing.T) {
+	t.Setenv("LOG_REDACT_AMOUNT", "keep")
+	t.Setenv("LOG_REDACT_HASH_KEY", "pepper")
+
+	cfg, err := config.Load(nil)
+	require.NoError(t, err)
+
+	redaction := cfg.Log.Redaction()
+	assert.Equal(t, "keep", redaction.Actions["amount"])
+	assert.Equal(t, "hash", redaction.Actions["email"])
+	assert.Equal(t, "pepper", redaction.HashKey)
+	assert.NotContains(t, cfg.Dump(), "pepper")
+}
+
+func TestValidate_RedactAction(t *testing.T) {
+	cfg := config.Default()
+	cfg.Log.Redact.Name = "scramble"
+
+	require.ErrorContains(t, cfg.Validate(), "log.redact.name")
+}
+
+func TestLoad_LogOptions(t *testing.T) {
+	t.Setenv("LOG_FILE", "/var/log/money-transfer.log")
+	t.Setenv("LOG_SAMPLING_INTERVAL", "1s")
+
+	cfg, err := config.Load([]string{"-log-format", "text", "-log-level", "debug"})
+	require.NoError(t, err)
+
+	opts, err := cfg.Log.Options()
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, opts.Level)
+	assert.Equal(t, "text", opts.Format)
+	assert.Equal(t, "/var/log/money-transfer.log", opts.File.Path)
+	assert.Equal(t, 100, opts.File.MaxSizeMB)
+	assert.Equal(t, time.Second, opts.Sampling.Interval)
+}
+
+func TestValidate_LogFormat(t *testing.T) {
+	cfg := config.Default()
+	cfg.Log.Format = "xml"
+
+	require.ErrorContains(t, cfg.Validate(), "log.format")
+}
+
+func TestValidate_GRPCPortMustDifferFromHTTP(t *testing.T) {
+	cfg := config.Default()
+	cfg.Server.GRPCPort = cfg.Server.Port
+
+	require.ErrorContains(t, cfg.Validate(), "server.grpc_port")
+
+	cfg.Server.GRPCPort = 0
+	require.NoError(t, cfg.Validate(), "0 disables gRPC")
+}
@@ -0,0 +1,115 @@
+package controller_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/api/handler"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/tests"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIKeyController_CreateAPIKey_Success(t *testing.T) {
+	svc, logger, controller := initAPIKeyController()
+
+	key := model.APIKey{Id: uuid.New(), Name: "payments", Scopes: []string{model.ScopeTransfersWrite}}
+	svc.On("Issue", mock.Anything, "payments", []string{model.ScopeTransfersWrite}).Return(key, "mtk_secret", nil)
+	logger.On("Info", "api key issued successfully", "apiKeyId", key.Id).Return()
+
+	body, _ := json.Marshal(dtos.CreateAPIKeyRequestDto{Name: "payments", Scopes: []string{model.ScopeTransfersWrite}})
+	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	controller.CreateAPIKey(rr, req)
+
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var resp dtos.APIKeyResponseDto
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, "mtk_secret", resp.Key)
+	assert.Equal(t, key.Id, resp.APIKey.Id)
+	svc.AssertExpectations(t)
+	logger.AssertExpectations(t)
+}
+
+func TestAPIKeyController_CreateAPIKey_InvalidRequest(t *testing.T) {
+	svc, _, controller := initAPIKeyController()
+
+	svc.On("Issue", mock.Anything, "payments", []string{"bogus"}).Return(model.APIKey{}, "", service.ErrInvalidAPIKeyRequest)
+
+	body, _ := json.Marshal(dtos.CreateAPIKeyRequestDto{Name: "payments", Scopes: []string{"bogus"}})
+	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	controller.CreateAPIKey(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestAPIKeyController_ListAPIKeys_Success(t *testing.T) {
+	svc, _, controller := initAPIKeyController()
+
+	keys := []model.APIKey{{Id: uuid.New(), Name: "payments", KeyHash: "secret-hash"}}
+	svc.On("List", mock.Anything).Return(keys, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api-keys", nil)
+	rr := httptest.NewRecorder()
+
+	controller.ListAPIKeys(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "secret-hash")
+
+	var resp dtos.APIKeyListResponseDto
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Len(t, resp.APIKeys, 1)
+}
+
+func TestAPIKeyController_RevokeAPIKey_NotFound(t *testing.T) {
+	svc, _, controller := initAPIKeyController()
+
+	svc.On("Revoke", mock.Anything, "missing").Return(service.ErrAPIKeyNotFound)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api-keys/missing", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "missing"})
+	rr := httptest.NewRecorder()
+
+	controller.RevokeAPIKey(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestAPIKeyController_RotateAPIKey_Revoked(t *testing.T) {
+	svc, _, controller := initAPIKeyController()
+
+	svc.On("Rotate", mock.Anything, "old").Return(model.APIKey{}, "", service.ErrAPIKeyAlreadyRevoked)
+
+	req := httptest.NewRequest(http.MethodPost, "/api-keys/old/rotate", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "old"})
+	rr := httptest.NewRecorder()
+
+	controller.RotateAPIKey(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	var errResp dtos.ProblemDetails
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&errResp))
+	assert.Equal(t, apperrors.CodeAPIKeyRevoked, errResp.ErrorCode)
+}
+
+func initAPIKeyController() (*tests.MockAPIKeyService, *tests.MockLogger, *handler.APIKeyController) {
+	svc := new(tests.MockAPIKeyService)
+	logger := new(tests.MockLogger)
+	controller := handler.NewAPIKeyController(svc, logger)
+	return svc, logger, controller
+}
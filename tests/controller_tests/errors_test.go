@@ -0,0 +1,98 @@
+package controller_tests
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/api/handler"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/tests"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWriteError_MapsKindsToStatus(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{apperrors.NotFound(apperrors.CodeUserNotFound, "user not found"), http.StatusNotFound, apperrors.CodeUserNotFound},
+		{apperrors.Validation(apperrors.CodeInvalidAmount, "bad amount"), http.StatusBadRequest, apperrors.CodeInvalidAmount},
+		{apperrors.InsufficientFunds("insufficient funds"), http.StatusUnprocessableEntity, apperrors.CodeInsufficientFunds},
+		{apperrors.Conflict(apperrors.CodeAPIKeyRevoked, "already revoked"), http.StatusConflict, apperrors.CodeAPIKeyRevoked},
+		{apperrors.Forbidden(apperrors.CodeInsufficientScope, "no scope"), http.StatusForbidden, apperrors.CodeInsufficientScope},
+		{errors.New("boom"), http.StatusInternalServerError, apperrors.CodeInternal},
+	}
+
+	for _, tc := range cases {
+		logger := new(tests.MockLogger)
+		logger.On("Error", "request failed", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return().Maybe()
+
+		req := httptest.NewRequest(http.MethodGet, "/balance/42", nil)
+		rr := httptest.NewRecorder()
+		handler.WriteError(rr, req, logger, tc.err, "Something failed")
+
+		assert.Equal(t, tc.status, rr.Code, tc.err.Error())
+
+		var errResp dtos.ProblemDetails
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&errResp))
+		assert.Equal(t, tc.code, errResp.ErrorCode)
+		assert.Equal(t, tc.status, errResp.Status)
+		assert.Equal(t, http.StatusText(tc.status), errResp.Title)
+		assert.Equal(t, dtos.ProblemTypePrefix+tc.code, errResp.Type)
+		assert.Equal(t, "/balance/42", errResp.Instance)
+		assert.NotEmpty(t, errResp.CorrelationId)
+		assert.Equal(t, dtos.ProblemContentType, rr.Header().Get("Content-Type"))
+	}
+}
+
+func TestWriteError_HidesInternalDetails(t *testing.T) {
+	logger := new(tests.MockLogger)
+	cause := errors.New(`pq: relation "users" does not exist`)
+	logger.On("Error", "request failed", "request_id", "corr-1", "method", http.MethodGet, "path", "/balance/42", "status", http.StatusInternalServerError, "error", cause).Return()
+
+	req := httptest.NewRequest(http.MethodGet, "/balance/42", nil)
+	req = req.WithContext(model.WithRequestId(req.Context(), "corr-1"))
+	rr := httptest.NewRecorder()
+	handler.WriteError(rr, req, logger, cause, "Error fetching balance")
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "pq:")
+
+	var errResp dtos.ProblemDetails
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&errResp))
+	assert.Equal(t, "Error fetching balance", errResp.Detail)
+	assert.Equal(t, "corr-1", errResp.CorrelationId)
+	logger.AssertExpectations(t)
+}
+
+func TestWriteError_SetsRetryAfter(t *testing.T) {
+	rr := httptest.NewRecorder()
+	handler.WriteError(rr, httptest.NewRequest(http.MethodPost, "/transfers", nil), new(tests.MockLogger), apperrors.Unavailable(apperrors.CodeQueueFull, "queue full", 1500*time.Millisecond), "")
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+}
+
+func TestWriteError_IncludesFieldErrors(t *testing.T) {
+	rr := httptest.NewRecorder()
+	field := apperrors.FieldError{Field: "to", Code: apperrors.CodeSelfTransfer, Message: "cannot transfer to the sending account"}
+	handler.WriteError(rr, httptest.NewRequest(http.MethodPost, "/transfers", nil), new(tests.MockLogger), apperrors.Validation(apperrors.CodeValidationFailed, "transfer request is invalid").WithFields(field), "")
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var errResp dtos.ProblemDetails
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&errResp))
+	assert.Equal(t, []apperrors.FieldError{field}, errResp.Fields)
+}
+
+func expectRequestFailedLog(logger *tests.MockLogger) {
+	logger.On("Error", "request failed", "request_id", mock.Anything, "method", mock.Anything, "path", mock.Anything, "status", http.StatusInternalServerError, "error", mock.Anything).Return()
+}
@@ -0,0 +1,171 @@
+package controller_tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"moneyTransfer/api/handler"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/stream"
+	"moneyTransfer/tests"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const streamUserId = "861d7697-b717-43e8-95a2-1a74f9a36ab1"
+
+func initEventStream(t *testing.T) (*tests.MockUserService, *stream.Hub, *httptest.Server) {
+	svc := new(tests.MockUserService)
+	hub := stream.NewHub(10, 10)
+	controller := handler.NewEventStreamController(svc, hub, 50*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}/events", controller.StreamUserEvents)
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		hub.Close()
+		server.Close()
+	})
+	return svc, hub, server
+}
+
+func balanceChanged(t *testing.T, sequence int64) model.DomainEvent {
+	event, err := model.NewDomainEvent(model.EventBalanceChanged, streamUserId, model.BalanceChangedPayload{AccountId: streamUserId, Balance: float64(sequence)})
+	require.NoError(t, err)
+	event.Sequence = sequence
+	return event
+}
+
+// readSSE returns the fields of the next event, skipping comments.
+func readSSE(t *testing.T, reader *bufio.Reader) map[string]string {
+	t.Helper()
+	fields := map[string]string{}
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if len(fields) > 0 {
+				return fields
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			fields["comment"] = line
+			return fields
+		}
+		name, value, _ := strings.Cut(line, ": ")
+		fields[name] = value
+	}
+}
+
+func TestEventStreamController_SSEReplaysThenStreams(t *testing.T) {
+	svc, hub, server := initEventStream(t)
+	svc.On("GetById", mock.Anything, streamUserId).Return(model.User{}, nil)
+	ctx := context.Background()
+
+	require.NoError(t, hub.Publish(ctx, balanceChanged(t, 1)))
+	require.NoError(t, hub.Publish(ctx, balanceChanged(t, 2)))
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/users/"+streamUserId+"/events", nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := server.Client().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	reader := bufio.NewReader(resp.Body)
+
+	assert.Equal(t, "3000", readSSE(t, reader)["retry"])
+
+	replayed := readSSE(t, reader)
+	assert.Equal(t, "2", replayed["id"])
+	assert.Equal(t, model.EventBalanceChanged, replayed["event"])
+
+	require.NoError(t, hub.Publish(ctx, balanceChanged(t, 3)))
+	live := readSSE(t, reader)
+	assert.Equal(t, "3", live["id"])
+	var event model.DomainEvent
+	require.NoError(t, json.Unmarshal([]byte(live["data"]), &event))
+	assert.Equal(t, streamUserId, event.AccountId)
+
+	assert.Equal(t, ": ping", readSSE(t, reader)["comment"])
+}
+
+func TestEventStreamController_SSEResyncsOnGap(t *testing.T) {
+	svc, _, server := initEventStream(t)
+	svc.On("GetById", mock.Anything, streamUserId).Return(model.User{}, nil)
+
+	resp, err := server.Client().Get(server.URL + "/users/" + streamUserId + "/events?last_event_id=7")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	reader := bufio.NewReader(resp.Body)
+	readSSE(t, reader)
+	assert.Equal(t, stream.EventResync, readSSE(t, reader)["event"])
+}
+
+func TestEventStreamController_RejectsBadRequests(t *testing.T) {
+	svc, _, server := initEventStream(t)
+	svc.On("GetById", mock.Anything, streamUserId).Return(model.User{}, apperrors.NotFound(apperrors.CodeUserNotFound, "user not found"))
+
+	cases := map[string]struct {
+		path   string
+		header string
+		status int
+	}{
+		"malformed user id":      {path: "/users/nope/events", status: http.StatusBadRequest},
+		"malformed resume point": {path: "/users/" + uuid.NewString() + "/events", header: "soon", status: http.StatusBadRequest},
+		"unknown user":           {path: "/users/" + streamUserId + "/events", status: http.StatusNotFound},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, server.URL+tc.path, nil)
+			require.NoError(t, err)
+			if tc.header != "" {
+				req.Header.Set("Last-Event-ID", tc.header)
+			}
+			resp, err := server.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.status, resp.StatusCode)
+			assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+		})
+	}
+}
+
+func TestEventStreamController_WebSocket(t *testing.T) {
+	svc, hub, server := initEventStream(t)
+	svc.On("GetById", mock.Anything, streamUserId).Return(model.User{}, nil)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/users/" + streamUserId + "/events"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	require.Eventually(t, func() bool { return hub.Subscribers() == 1 }, time.Second, 5*time.Millisecond)
+	require.NoError(t, hub.Publish(context.Background(), balanceChanged(t, 1)))
+
+	var event model.DomainEvent
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, int64(1), event.Sequence)
+	assert.Equal(t, model.EventBalanceChanged, event.Type)
+
+	conn.Close()
+	require.Eventually(t, func() bool { return hub.Subscribers() == 0 }, time.Second, 5*time.Millisecond, "closing the socket ends the subscription")
+}
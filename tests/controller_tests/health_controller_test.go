@@ -0,0 +1,62 @@
+package controller_tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/api/handler"
+	"moneyTransfer/internal/health"
+	"moneyTransfer/tests"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHealthController_Livez(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.AddLiveness("workers", func(context.Context) error { return nil })
+	controller := handler.NewHealthController(checker, new(tests.MockLogger))
+
+	rr := httptest.NewRecorder()
+	controller.Livez(rr, httptest.NewRequest(http.MethodGet, "/livez", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var report health.Report
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&report))
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["workers"].Status)
+}
+
+func TestHealthController_Readyz_Failing(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.AddReadiness("database", func(context.Context) error { return errors.New("connection refused") })
+	logger := new(tests.MockLogger)
+	logger.On("Warn", "readiness check failed", "checks", mock.Anything).Return()
+	controller := handler.NewHealthController(checker, logger)
+
+	rr := httptest.NewRecorder()
+	controller.Readyz(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	var report health.Report
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&report))
+	assert.Equal(t, health.StatusFailing, report.Status)
+	assert.Equal(t, "connection refused", report.Checks["database"].Error)
+	logger.AssertExpectations(t)
+}
+
+func TestHealthController_Readyz_ShuttingDown(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.Shutdown()
+	controller := handler.NewHealthController(checker, new(tests.MockLogger))
+
+	rr := httptest.NewRecorder()
+	controller.Readyz(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.JSONEq(t, `{"status":"shutting_down"}`, rr.Body.String())
+}
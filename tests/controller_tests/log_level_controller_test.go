@@ -0,0 +1,84 @@
+package controller_tests
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"moneyTransfer/api/handler"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/tests"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogLevelController_GetLogLevel(t *testing.T) {
+	_, _, _, controller := initLogLevelController(slog.LevelWarn)
+
+	rr := httptest.NewRecorder()
+	controller.GetLogLevel(rr, httptest.NewRequest(http.MethodGet, "/admin/log-level", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp dtos.LogLevelDto
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, "warn", resp.Level)
+}
+
+func TestLogLevelController_SetLogLevel(t *testing.T) {
+	level, audit, logger, controller := initLogLevelController(slog.LevelInfo)
+	audit.On("Record", mock.Anything, model.AuditActionLogLevelChanged, model.AuditEntitySetting, "log.level",
+		dtos.LogLevelDto{Level: "info"}, dtos.LogLevelDto{Level: "debug"}).Return(nil)
+	logger.On("Warn", "log level changed", "from", "info", "to", "debug").Return()
+
+	rr := httptest.NewRecorder()
+	controller.SetLogLevel(rr, httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"debug"}`)))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var resp dtos.LogLevelDto
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, "debug", resp.Level)
+	assert.Equal(t, slog.LevelDebug, level.Level())
+	audit.AssertExpectations(t)
+	logger.AssertExpectations(t)
+}
+
+func TestLogLevelController_SetLogLevel_AuditFails(t *testing.T) {
+	level, audit, logger, controller := initLogLevelController(slog.LevelInfo)
+	audit.On("Record", mock.Anything, model.AuditActionLogLevelChanged, model.AuditEntitySetting, "log.level",
+		mock.Anything, mock.Anything).Return(errors.New("db error"))
+	logger.On("Error", "request failed", "request_id", mock.Anything, "method", mock.Anything, "path", mock.Anything, "status", http.StatusInternalServerError, "error", mock.Anything).Return()
+
+	rr := httptest.NewRecorder()
+	controller.SetLogLevel(rr, httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"debug"}`)))
+
+	require.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, slog.LevelInfo, level.Level(), "a change that cannot be audited is not made")
+}
+
+func TestLogLevelController_SetLogLevel_Invalid(t *testing.T) {
+	level, audit, _, controller := initLogLevelController(slog.LevelInfo)
+
+	rr := httptest.NewRecorder()
+	controller.SetLogLevel(rr, httptest.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"verbose"}`)))
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	var problem dtos.ProblemDetails
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&problem))
+	assert.Equal(t, apperrors.CodeInvalidLogLevel, problem.ErrorCode)
+	assert.Equal(t, slog.LevelInfo, level.Level(), "the level is left unchanged")
+	audit.AssertNotCalled(t, "Record")
+}
+
+func initLogLevelController(initial slog.Level) (*slog.LevelVar, *tests.MockAuditService, *tests.MockLogger, *handler.LogLevelController) {
+	level := new(slog.LevelVar)
+	level.Set(initial)
+	audit := new(tests.MockAuditService)
+	logger := new(tests.MockLogger)
+	return level, audit, logger, handler.NewLogLevelController(level, audit, logger)
+}
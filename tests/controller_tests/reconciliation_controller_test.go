@@ -0,0 +1,62 @@
+package controller_tests
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/api/handler"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/tests"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReconciliationController_Reconcile_Success(t *testing.T) {
+	svc, logger, controller := initReconciliationController()
+
+	report := model.ReconciliationReport{AccountsChecked: 8, Discrepancies: []model.AccountDiscrepancy{}, MoneyConserved: true, Balanced: true}
+	svc.On("Reconcile", mock.Anything).Return(report, nil)
+	logger.On("Info", "reconciliation report generated", "balanced", true).Return()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/reconciliation", nil)
+	rr := httptest.NewRecorder()
+
+	controller.Reconcile(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp model.ReconciliationReport
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, report, resp)
+	logger.AssertExpectations(t)
+}
+
+func TestReconciliationController_Reconcile_Error(t *testing.T) {
+	svc, logger, controller := initReconciliationController()
+	expectRequestFailedLog(logger)
+
+	svc.On("Reconcile", mock.Anything).Return(model.ReconciliationReport{}, errors.New("db error"))
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/reconciliation", nil)
+	rr := httptest.NewRecorder()
+
+	controller.Reconcile(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	var errResp dtos.ProblemDetails
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&errResp))
+	assert.Equal(t, "Failed to run reconciliation", errResp.Detail)
+	logger.AssertExpectations(t)
+}
+
+func initReconciliationController() (*tests.MockReconciliationService, *tests.MockLogger, *handler.ReconciliationController) {
+	svc := new(tests.MockReconciliationService)
+	logger := new(tests.MockLogger)
+	controller := handler.NewReconciliationController(svc, logger)
+	return svc, logger, controller
+}
@@ -4,14 +4,17 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"moneyTransfer/api/handler"
+	"moneyTransfer/internal/domain/apperrors"
 	"moneyTransfer/internal/domain/dtos"
 	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/queue"
 	"moneyTransfer/tests"
 	"net/http"
 	"net/http/httptest"
@@ -58,7 +61,8 @@ func TestTransferController_GetTransactionsByUserId_Success(t *testing.T) {
 }
 
 func TestTransferController_GetTransactionsByUserId_Error(t *testing.T) {
-	svc, _, controller := initTransferController()
+	svc, logger, controller := initTransferController()
+	expectRequestFailedLog(logger)
 
 	expectedErr := errors.New("database connection failed")
 	userId := "thgh"
@@ -73,10 +77,11 @@ func TestTransferController_GetTransactionsByUserId_Error(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 
-	var errResp dtos.ErrorResponse
+	var errResp dtos.ProblemDetails
 	err := json.NewDecoder(rr.Body).Decode(&errResp)
 	require.NoError(t, err)
-	assert.Equal(t, "Error fetching transactions", errResp.Message)
+	assert.Equal(t, "Error fetching transactions", errResp.Detail)
+	logger.AssertExpectations(t)
 }
 
 func TestTransferController_GetTransactionsByUserId_MissingUserId(t *testing.T) {
@@ -89,13 +94,13 @@ func TestTransferController_GetTransactionsByUserId_MissingUserId(t *testing.T)
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 
-	var errResp dtos.ErrorResponse
+	var errResp dtos.ProblemDetails
 	err := json.NewDecoder(rr.Body).Decode(&errResp)
 	require.NoError(t, err)
-	assert.Equal(t, "User Id is required", errResp.Message)
+	assert.Equal(t, "User Id is required", errResp.Detail)
 }
 
-func TestTransferController_CreateTransaction_Success(t *testing.T) {
+func TestTransferController_CreateTransaction_Accepted(t *testing.T) {
 	svc, logger, controller := initTransferController()
 
 	txId := "861d7697-b717-43e8-95a2-1a74f9a36ab1"
@@ -104,12 +109,12 @@ func TestTransferController_CreateTransaction_Success(t *testing.T) {
 	amount := 100
 	expectedResponse := dtos.CreateTransactionResponseDto{
 		TransactionId: uuid.MustParse(txId),
-		Status:        model.StatusSuccess,
-		Message:       "Transaction was successful",
+		Status:        model.StatusPending,
+		Message:       "Transaction is still being processed",
 	}
 
 	svc.On("CreateTransfer", mock.Anything, fromId, toId, 100.0).Return(expectedResponse.TransactionId, nil)
-	logger.On("Info", "transaction was successful", "response", expectedResponse).Return()
+	logger.On("Info", "transaction accepted", "response", expectedResponse).Return()
 
 	body := map[string]interface{}{
 		"from":   fromId,
@@ -122,7 +127,7 @@ func TestTransferController_CreateTransaction_Success(t *testing.T) {
 
 	controller.CreateTransaction(rr, req)
 
-	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, http.StatusAccepted, rr.Code, "without wait the transfer is only queued")
 
 	var resp dtos.CreateTransactionResponseDto
 	err := json.NewDecoder(rr.Body).Decode(&resp)
@@ -143,14 +148,15 @@ func TestTransferController_CreateTransaction_ErrorParseReqBody(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 
-	var errResp dtos.ErrorResponse
+	var errResp dtos.ProblemDetails
 	err := json.NewDecoder(rr.Body).Decode(&errResp)
 	require.NoError(t, err)
-	assert.Equal(t, "Error parsing request body", errResp.Message)
+	assert.Equal(t, "Error parsing request body", errResp.Detail)
 }
 
 func TestTransferController_CreateTransaction_Error(t *testing.T) {
-	svc, _, controller := initTransferController()
+	svc, logger, controller := initTransferController()
+	expectRequestFailedLog(logger)
 
 	expectedErr := errors.New("database connection failed")
 	fromId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
@@ -172,10 +178,67 @@ func TestTransferController_CreateTransaction_Error(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 
-	var errResp dtos.ErrorResponse
+	var errResp dtos.ProblemDetails
+	err := json.NewDecoder(rr.Body).Decode(&errResp)
+	require.NoError(t, err)
+	assert.Equal(t, "Failed to create transfer", errResp.Detail)
+
+	svc.AssertExpectations(t)
+	logger.AssertExpectations(t)
+}
+
+func TestTransferController_CreateTransaction_InvalidAmount(t *testing.T) {
+	svc, _, controller := initTransferController()
+
+	fromId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+	toId := "befeef21-1475-4a13-a0de-3943d2eb0910"
+
+	svc.On("CreateTransfer", mock.Anything, fromId, toId, -5.0).
+		Return(uuid.Nil, apperrors.Validation(apperrors.CodeInvalidAmount, "amount must be greater than zero"))
+
+	body := map[string]interface{}{
+		"from":   fromId,
+		"to":     toId,
+		"amount": -5,
+	}
+	bodyBytes, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/transfers/", bytes.NewReader(bodyBytes))
+	rr := httptest.NewRecorder()
+
+	controller.CreateTransaction(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	var errResp dtos.ProblemDetails
 	err := json.NewDecoder(rr.Body).Decode(&errResp)
 	require.NoError(t, err)
-	assert.Equal(t, "Failed to create transfer", errResp.Message)
+	assert.Equal(t, apperrors.CodeInvalidAmount, errResp.ErrorCode)
+
+	svc.AssertExpectations(t)
+}
+
+func TestTransferController_CreateTransaction_QueueFull(t *testing.T) {
+	svc, _, controller := initTransferController()
+
+	fromId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+	toId := "befeef21-1475-4a13-a0de-3943d2eb0910"
+
+	svc.On("CreateTransfer", mock.Anything, fromId, toId, 100.0).
+		Return(uuid.Nil, fmt.Errorf("failed to enqueue transfer: %w", queue.ErrQueueFull))
+
+	body := map[string]interface{}{
+		"from":   fromId,
+		"to":     toId,
+		"amount": 100,
+	}
+	bodyBytes, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/transfers/", bytes.NewReader(bodyBytes))
+	rr := httptest.NewRecorder()
+
+	controller.CreateTransaction(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
 
 	svc.AssertExpectations(t)
 }
@@ -183,6 +246,62 @@ func TestTransferController_CreateTransaction_Error(t *testing.T) {
 func initTransferController() (*tests.MockTransferService, *tests.MockLogger, *handler.TransferController) {
 	transferSvc := new(tests.MockTransferService)
 	logger := new(tests.MockLogger)
-	controller := handler.NewTransferController(transferSvc, logger)
+	controller := handler.NewTransferController(transferSvc, 10*time.Second, logger)
 	return transferSvc, logger, controller
 }
+
+func TestTransferController_CreateTransaction_Wait(t *testing.T) {
+	fromId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+	toId := "befeef21-1475-4a13-a0de-3943d2eb0910"
+	txId := uuid.MustParse("a5bceab4-9dab-4d7a-8cd5-4ba832ebf899")
+
+	cases := []struct {
+		name     string
+		wait     string
+		duration time.Duration
+		status   string
+		code     int
+	}{
+		{"completed", "5s", 5 * time.Second, model.StatusFailed, http.StatusOK},
+		{"seconds", "5", 5 * time.Second, model.StatusSuccess, http.StatusOK},
+		{"still pending", "1500ms", 1500 * time.Millisecond, model.StatusPending, http.StatusAccepted},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc, logger, controller := initTransferController()
+			svc.On("CreateTransfer", mock.Anything, fromId, toId, 100.0).Return(txId, nil)
+			svc.On("WaitForTransfer", mock.Anything, txId, tc.duration).Return(model.Transaction{Id: txId, Status: tc.status}, nil)
+			logger.On("Info", "transaction processed while waiting", "response", mock.Anything).Return()
+
+			bodyBytes, _ := json.Marshal(map[string]interface{}{"from": fromId, "to": toId, "amount": 100})
+			req := httptest.NewRequest(http.MethodPost, "/transfers?wait="+tc.wait, bytes.NewReader(bodyBytes))
+			rr := httptest.NewRecorder()
+
+			controller.CreateTransaction(rr, req)
+
+			require.Equal(t, tc.code, rr.Code)
+			var resp dtos.CreateTransactionResponseDto
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, txId, resp.TransactionId)
+			assert.Equal(t, tc.status, resp.Status)
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTransferController_CreateTransaction_InvalidWait(t *testing.T) {
+	for _, wait := range []string{"soon", "-1s", "1m"} {
+		t.Run(wait, func(t *testing.T) {
+			svc, _, controller := initTransferController()
+
+			req := httptest.NewRequest(http.MethodPost, "/transfers?wait="+wait, bytes.NewReader([]byte(`{}`)))
+			rr := httptest.NewRecorder()
+
+			controller.CreateTransaction(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			svc.AssertNotCalled(t, "CreateTransfer", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -1,13 +1,16 @@
 package controller_tests
 
 import (
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"moneyTransfer/api/handler"
+	"moneyTransfer/internal/domain/apperrors"
 	"moneyTransfer/internal/domain/dtos"
 	"moneyTransfer/tests"
 	"net/http"
@@ -44,7 +47,8 @@ func TestUserController_GetUserBalance_Success(t *testing.T) {
 }
 
 func TestUserController_GetUserBalance_Error(t *testing.T) {
-	svc, _, controller := initUserCOntroller()
+	svc, logger, controller := initUserCOntroller()
+	expectRequestFailedLog(logger)
 
 	expectedErr := errors.New("database connection failed")
 	userId := "thgh"
@@ -59,10 +63,34 @@ func TestUserController_GetUserBalance_Error(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, rr.Code)
 
-	var errResp dtos.ErrorResponse
+	var errResp dtos.ProblemDetails
+	err := json.NewDecoder(rr.Body).Decode(&errResp)
+	require.NoError(t, err)
+	assert.Equal(t, "Error fetching balance", errResp.Detail)
+	logger.AssertExpectations(t)
+}
+
+func TestUserController_GetUserBalance_NotFound(t *testing.T) {
+	svc, _, controller := initUserCOntroller()
+
+	userId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+	notFound := apperrors.NotFound(apperrors.CodeUserNotFound, "user not found").WithCause(sql.ErrNoRows)
+
+	svc.On("GetBalance", mock.Anything, userId).Return(0.0, fmt.Errorf("failed to get balance: %w", notFound))
+
+	req := httptest.NewRequest(http.MethodGet, "/balance/"+userId, nil)
+	req = mux.SetURLVars(req, map[string]string{"userId": userId})
+	rr := httptest.NewRecorder()
+
+	controller.GetUserBalance(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	var errResp dtos.ProblemDetails
 	err := json.NewDecoder(rr.Body).Decode(&errResp)
 	require.NoError(t, err)
-	assert.Equal(t, "Error fetching balance", errResp.Message)
+	assert.Equal(t, "user not found", errResp.Detail)
+	assert.Equal(t, apperrors.CodeUserNotFound, errResp.ErrorCode)
 }
 
 func TestUserController_GetUserBalance_MissingUserId(t *testing.T) {
@@ -75,10 +103,10 @@ func TestUserController_GetUserBalance_MissingUserId(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, rr.Code)
 
-	var errResp dtos.ErrorResponse
+	var errResp dtos.ProblemDetails
 	err := json.NewDecoder(rr.Body).Decode(&errResp)
 	require.NoError(t, err)
-	assert.Equal(t, "User Id is required", errResp.Message)
+	assert.Equal(t, "User Id is required", errResp.Detail)
 }
 
 func initUserCOntroller() (*tests.MockUserService, *tests.MockLogger, *handler.UserController) {
@@ -0,0 +1,111 @@
+package controller_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/api/handler"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/tests"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebhookController_CreateWebhook_ReturnsSecretOnce(t *testing.T) {
+	svc, logger, controller := initWebhookController()
+
+	webhook := model.Webhook{Id: uuid.New(), URL: "https://example.com/hook", Events: []string{model.EventTransferSucceeded}, Secret: "whsec_secret"}
+	accounts := []string{uuid.NewString()}
+	svc.On("Register", mock.Anything, webhook.URL, webhook.Events, accounts).Return(webhook, nil)
+	svc.On("List", mock.Anything).Return([]model.Webhook{webhook}, nil)
+	logger.On("Info", "webhook registered successfully", "webhookId", webhook.Id).Return()
+
+	body, _ := json.Marshal(dtos.CreateWebhookRequestDto{URL: webhook.URL, Events: webhook.Events, Accounts: accounts})
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	controller.CreateWebhook(rr, req)
+
+	require.Equal(t, http.StatusCreated, rr.Code)
+
+	var resp dtos.WebhookResponseDto
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, "whsec_secret", resp.Secret)
+	assert.Equal(t, webhook.Id, resp.Webhook.Id)
+
+	rr = httptest.NewRecorder()
+	controller.ListWebhooks(rr, httptest.NewRequest(http.MethodGet, "/webhooks", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.False(t, strings.Contains(rr.Body.String(), "whsec_secret"), "secret must not be listed")
+}
+
+func TestWebhookController_CreateWebhook_InvalidRequest(t *testing.T) {
+	svc, _, controller := initWebhookController()
+
+	svc.On("Register", mock.Anything, "/hook", []string{model.EventTransferSucceeded}, []string(nil)).
+		Return(model.Webhook{}, apperrors.Validation(apperrors.CodeInvalidWebhookRequest, "url must be an absolute http or https URL"))
+
+	body, _ := json.Marshal(dtos.CreateWebhookRequestDto{URL: "/hook", Events: []string{model.EventTransferSucceeded}})
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	controller.CreateWebhook(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestWebhookController_ListDeliveries_UnknownWebhook(t *testing.T) {
+	svc, _, controller := initWebhookController()
+
+	svc.On("ListDeliveries", mock.Anything, "missing").Return([]model.WebhookDelivery(nil), service.ErrWebhookNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/webhooks/missing/deliveries", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "missing"})
+	rr := httptest.NewRecorder()
+
+	controller.ListDeliveries(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	var errResp dtos.ProblemDetails
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&errResp))
+	assert.Equal(t, apperrors.CodeWebhookNotFound, errResp.ErrorCode)
+}
+
+func TestWebhookController_Redeliver_Accepted(t *testing.T) {
+	svc, logger, controller := initWebhookController()
+
+	delivery := model.WebhookDelivery{Id: uuid.New(), WebhookId: uuid.New(), Status: model.DeliveryPending}
+	svc.On("Redeliver", mock.Anything, delivery.WebhookId.String(), delivery.Id.String()).Return(delivery, nil)
+	logger.On("Info", "webhook redelivery queued successfully", "webhookId", delivery.WebhookId.String(), "deliveryId", delivery.Id.String()).Return()
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/id/deliveries/id/redeliver", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": delivery.WebhookId.String(), "deliveryId": delivery.Id.String()})
+	rr := httptest.NewRecorder()
+
+	controller.Redeliver(rr, req)
+
+	require.Equal(t, http.StatusAccepted, rr.Code)
+
+	var resp dtos.WebhookDeliveryResponseDto
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, delivery.Id, resp.Delivery.Id)
+	svc.AssertExpectations(t)
+}
+
+func initWebhookController() (*tests.MockWebhookService, *tests.MockLogger, *handler.WebhookController) {
+	svc := new(tests.MockWebhookService)
+	logger := new(tests.MockLogger)
+	controller := handler.NewWebhookController(svc, logger)
+	return svc, logger, controller
+}
@@ -0,0 +1,116 @@
+package health_tests
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/health"
+	"moneyTransfer/internal/queue"
+	"moneyTransfer/tests"
+	"testing"
+	"time"
+)
+
+func ok(context.Context) error { return nil }
+
+func TestChecker_ReportsEachCheck(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.AddReadiness("database", ok)
+	checker.AddReadiness("queue", func(context.Context) error { return errors.New("1200 jobs waiting, more than 1000") })
+
+	report := checker.Ready(context.Background())
+
+	assert.False(t, report.OK())
+	assert.Equal(t, health.StatusFailing, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["database"].Status)
+	assert.Equal(t, health.StatusFailing, report.Checks["queue"].Status)
+	assert.Equal(t, "1200 jobs waiting, more than 1000", report.Checks["queue"].Error)
+}
+
+func TestChecker_TimesOutSlowChecks(t *testing.T) {
+	checker := health.NewChecker(10 * time.Millisecond)
+	checker.AddReadiness("database", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	report := checker.Ready(context.Background())
+
+	assert.Equal(t, health.StatusFailing, report.Status)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["database"].Error)
+}
+
+func TestChecker_ReadinessFailsAfterShutdown(t *testing.T) {
+	checker := health.NewChecker(time.Second)
+	checker.AddLiveness("workers", ok)
+	checker.AddReadiness("database", ok)
+	require.True(t, checker.Ready(context.Background()).OK())
+
+	checker.Shutdown()
+
+	assert.Equal(t, health.StatusShuttingDown, checker.Ready(context.Background()).Status)
+	assert.True(t, checker.Live(context.Background()).OK())
+}
+
+func TestQueueBacklog(t *testing.T) {
+	depth := 5
+	check := health.QueueBacklog(func(context.Context) (int, error) { return depth, nil }, 5)
+	require.NoError(t, check(context.Background()))
+
+	depth = 6
+	require.EqualError(t, check(context.Background()), "6 jobs waiting, more than 5")
+}
+
+func TestWorkerHeartbeat(t *testing.T) {
+	worker := queue.NewWorker(queue.NewChannelQueue(1, time.Second), new(tests.MockUserRepo), new(tests.MockTransferRepo), tests.PassthroughTxManager{}, queue.NewCompletions(), 0, new(tests.MockLogger))
+	check := health.WorkerHeartbeat(worker, time.Minute)
+
+	require.EqualError(t, check(context.Background()), "no worker is running")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- worker.Run(ctx) }()
+	require.Eventually(t, func() bool { return check(context.Background()) == nil }, time.Second, time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	require.EqualError(t, check(context.Background()), "no worker is running")
+}
+
+func TestWorker_HeartbeatTracksJobInHand(t *testing.T) {
+	transferRepo, logger := new(tests.MockTransferRepo), new(tests.MockLogger)
+	worker := queue.NewWorker(nil, new(tests.MockUserRepo), transferRepo, tests.PassthroughTxManager{}, queue.NewCompletions(), 0, logger)
+	job := queue.TransferJob{TransactionId: uuid.New()}
+
+	var during time.Time
+	transferRepo.On("GetTransactionById", mock.Anything, job.TransactionId.String()).
+		Run(func(mock.Arguments) { _, during = worker.Heartbeat() }).
+		Return(model.Transaction{Id: job.TransactionId, Status: model.StatusSuccess}, nil)
+	logger.On("Info", "skipping already processed job", "transaction_id", job.TransactionId, "status", model.StatusSuccess, "user_id", job.SenderId).Return()
+
+	before := time.Now()
+	require.NoError(t, worker.Handle(context.Background(), job))
+
+	_, after := worker.Heartbeat()
+	assert.False(t, during.Before(before))
+	assert.True(t, after.IsZero())
+}
+
+func TestWorkerHeartbeat_StuckJob(t *testing.T) {
+	check := health.WorkerHeartbeat(heartbeat{running: 1, oldestJob: time.Now().Add(-2 * time.Minute)}, time.Minute)
+
+	require.EqualError(t, check(context.Background()), "a job has been in progress for 2m0s")
+}
+
+type heartbeat struct {
+	running   int
+	oldestJob time.Time
+}
+
+func (h heartbeat) Heartbeat() (int, time.Time) {
+	return h.running, h.oldestJob
+}
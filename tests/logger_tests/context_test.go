@@ -0,0 +1,37 @@
+package logger_tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"moneyTransfer/pkg/logger"
+	"moneyTransfer/tests"
+	"testing"
+)
+
+func TestWithContext_WithoutFieldsReturnsLogger(t *testing.T) {
+	log := new(tests.MockLogger)
+
+	assert.Same(t, log, logger.WithContext(context.Background(), log))
+}
+
+func TestWithContext_AddsFields(t *testing.T) {
+	log := new(tests.MockLogger)
+	log.On("Info", "transfer created", "amount", 10.0, "request_id", "req-1", "transaction_id", "tx-2").Return()
+
+	ctx := logger.WithFields(context.Background(), "request_id", "req-1", "transaction_id", "tx-1")
+	// A later value replaces the earlier one.
+	ctx = logger.WithFields(ctx, "transaction_id", "tx-2")
+	logger.WithContext(ctx, log).Info("transfer created", "amount", 10.0)
+
+	log.AssertExpectations(t)
+}
+
+func TestWithContext_ExplicitArgsWin(t *testing.T) {
+	log := new(tests.MockLogger)
+	log.On("Error", "failed", "request_id", "explicit").Return()
+
+	ctx := logger.WithFields(context.Background(), "request_id", "req-1")
+	logger.WithContext(ctx, log).Error("failed", "request_id", "explicit")
+
+	log.AssertExpectations(t)
+}
@@ -0,0 +1,93 @@
+package logger_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+	"strings"
+	"testing"
+	"time"
+)
+
+func redactingLogger(actions map[string]string, hashKey string) (logger.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	return logger.New(&buf, logger.Options{Level: slog.LevelDebug, Redaction: logger.Redaction{Actions: actions, HashKey: hashKey}}), &buf
+}
+
+func lastLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var line map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &line))
+	return line
+}
+
+func TestRedaction_MasksAttributesByKey(t *testing.T) {
+	log, buf := redactingLogger(map[string]string{logger.ClassEmail: logger.RedactMask, logger.ClassAmount: logger.RedactMask}, "")
+
+	log.Info("balance retrieved", "email", "ada@example.com", "balance", 120.5, "userId", "u-1")
+
+	line := lastLine(t, buf)
+	assert.Equal(t, "******", line["email"])
+	assert.Equal(t, "******", line["balance"])
+	assert.Equal(t, "u-1", line["userId"])
+}
+
+func TestRedaction_UsesStructTags(t *testing.T) {
+	log, buf := redactingLogger(map[string]string{logger.ClassName: logger.RedactMask, logger.ClassAmount: logger.RedactMask}, "")
+	id := uuid.New()
+
+	log.Info("user retrieved", "user", &model.User{Id: id, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Balance: 10})
+
+	user := lastLine(t, buf)["user"].(map[string]any)
+	assert.Equal(t, id.String(), user["id"])
+	assert.Equal(t, "******", user["first_name"])
+	assert.Equal(t, "******", user["last_name"])
+	assert.Equal(t, "******", user["balance"])
+	assert.Equal(t, "ada@example.com", user["email"], "classes without an action are kept")
+}
+
+func TestRedaction_WalksSlices(t *testing.T) {
+	log, buf := redactingLogger(map[string]string{logger.ClassAmount: logger.RedactMask}, "")
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	log.Info("transactions retrieved", "transactions", []model.Transaction{{Amount: 25, Status: model.StatusSuccess, CreatedAt: createdAt}})
+
+	transactions := lastLine(t, buf)["transactions"].([]any)
+	require.Len(t, transactions, 1)
+	tx := transactions[0].(map[string]any)
+	assert.Equal(t, "******", tx["amount"])
+	assert.Equal(t, model.StatusSuccess, tx["status"])
+	assert.Equal(t, "2024-05-01T12:00:00Z", tx["created_at"], "types that marshal themselves are left alone")
+}
+
+func TestRedaction_HashesConsistently(t *testing.T) {
+	actions := map[string]string{logger.ClassEmail: logger.RedactHash}
+	log, buf := redactingLogger(actions, "")
+
+	log.Info("first", "email", "ada@example.com")
+	first := lastLine(t, buf)["email"]
+	log.Info("second", "user", model.User{Email: "ada@example.com"})
+	second := lastLine(t, buf)["user"].(map[string]any)["email"]
+
+	assert.Regexp(t, `^sha256:[0-9a-f]{16}$`, first)
+	assert.Equal(t, first, second)
+
+	keyed, keyedBuf := redactingLogger(actions, "secret")
+	keyed.Info("keyed", "email", "ada@example.com")
+	assert.NotEqual(t, first, lastLine(t, keyedBuf)["email"], "the hash key changes the hash")
+}
+
+func TestRedaction_KeepLeavesValuesUntouched(t *testing.T) {
+	log, buf := redactingLogger(map[string]string{logger.ClassAmount: logger.RedactKeep}, "")
+
+	log.Info("transfer created", "amount", 42.5, "tx", model.Transaction{Amount: 42.5})
+
+	line := lastLine(t, buf)
+	assert.Equal(t, 42.5, line["amount"])
+	assert.Equal(t, 42.5, line["tx"].(map[string]any)["amount"])
+}
@@ -0,0 +1,69 @@
+package logger_tests
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"moneyTransfer/pkg/logger"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew_TextFormat(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.Options{Level: slog.LevelInfo, Format: logger.FormatText})
+
+	log.Debug("hidden")
+	log.Info("transfer created", "transaction_id", "tx-1")
+
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), `level=INFO msg="transfer created" transaction_id=tx-1`)
+}
+
+func TestNew_SamplesRepeatedMessages(t *testing.T) {
+	var buf bytes.Buffer
+	log := logger.New(&buf, logger.Options{
+		Level:    slog.LevelDebug,
+		Sampling: logger.Sampling{Interval: time.Hour, First: 2, Thereafter: 3},
+	})
+
+	for range 8 {
+		log.Info("job picked up")
+		log.Error("job failed")
+	}
+	log.Info("other message")
+
+	out := buf.String()
+	// The first two, then the fifth and the eighth.
+	assert.Equal(t, 4, strings.Count(out, "job picked up"))
+	assert.Equal(t, 8, strings.Count(out, "job failed"), "errors are never sampled")
+	assert.Equal(t, 1, strings.Count(out, "other message"))
+}
+
+func TestRotatingFile_RotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.log")
+	file, err := logger.OpenRotatingFile(logger.FileOptions{Path: path, MaxSizeMB: 1, MaxBackups: 2})
+	require.NoError(t, err)
+	defer file.Close()
+
+	chunk := bytes.Repeat([]byte("x"), 600<<10)
+	for _, b := range []byte("abcd") {
+		chunk[0] = b
+		_, err := file.Write(chunk)
+		require.NoError(t, err)
+	}
+
+	first := func(name string) byte {
+		content, err := os.ReadFile(name)
+		require.NoError(t, err)
+		return content[0]
+	}
+	assert.Equal(t, byte('d'), first(path))
+	assert.Equal(t, byte('c'), first(path+".1"))
+	assert.Equal(t, byte('b'), first(path+".2"))
+	assert.NoFileExists(t, path+".3", "only MaxBackups files are kept")
+}
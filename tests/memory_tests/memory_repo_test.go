@@ -0,0 +1,153 @@
+package memory_tests
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/repository/memory"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	aliceId = uuid.MustParse("7141b92f-a8c8-471e-83e5-7fc72da61cb9")
+	joeId   = uuid.MustParse("861d7697-b717-43e8-95a2-1a74f9a36ab1")
+)
+
+func initStore(t *testing.T) *memory.Store {
+	store := memory.NewStore()
+	require.NoError(t, memory.SeedDemoData(store))
+	return store
+}
+
+func TestUserRepo_GetBalance(t *testing.T) {
+	repo := memory.NewUserRepository(initStore(t))
+
+	balance, err := repo.GetBalance(context.Background(), aliceId.String())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, balance)
+}
+
+func TestUserRepo_NotFound(t *testing.T) {
+	repo := memory.NewUserRepository(initStore(t))
+	ctx := context.Background()
+
+	_, err := repo.GetById(ctx, uuid.NewString())
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.True(t, errors.Is(err, apperrors.ErrNotFound))
+	appErr, ok := apperrors.As(err)
+	require.True(t, ok)
+	assert.Equal(t, apperrors.CodeUserNotFound, appErr.Code)
+
+	err = repo.UpdateBalance(ctx, uuid.NewString(), 10)
+	assert.True(t, errors.Is(err, apperrors.ErrNotFound))
+}
+
+func TestUserRepo_InvalidId(t *testing.T) {
+	repo := memory.NewUserRepository(initStore(t))
+
+	_, err := repo.GetById(context.Background(), "not-a-uuid")
+
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, apperrors.ErrNotFound))
+}
+
+func TestTransferRepo_CreateAndUpdateStatus(t *testing.T) {
+	repo := memory.NewTransferRepository(initStore(t))
+	ctx := context.Background()
+
+	tx := model.Transaction{
+		Id:         uuid.New(),
+		SenderId:   aliceId,
+		ReceiverId: joeId,
+		Amount:     25,
+		Status:     model.StatusPending,
+		CreatedAt:  time.Now(),
+	}
+	require.NoError(t, repo.CreateTransfer(ctx, tx))
+	require.Error(t, repo.CreateTransfer(ctx, tx), "duplicate id")
+
+	require.NoError(t, repo.FinishTransaction(ctx, tx.Id.String(), model.StatusSuccess))
+	err := repo.FinishTransaction(ctx, tx.Id.String(), model.StatusFailed)
+	assert.ErrorIs(t, err, apperrors.ErrConflict, "only a pending transaction can be finished")
+
+	stored, err := repo.GetTransactionById(ctx, tx.Id.String())
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusSuccess, stored.Status)
+
+	transfers, err := repo.GetTransactionsByUserId(ctx, joeId.String())
+	require.NoError(t, err)
+	assert.Len(t, transfers, 2)
+
+	err = repo.FinishTransaction(ctx, uuid.NewString(), model.StatusSuccess)
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+}
+
+func TestTransferRepo_DeletePendingLeavesProcessed(t *testing.T) {
+	repo := memory.NewTransferRepository(initStore(t))
+	ctx := context.Background()
+
+	tx := model.Transaction{Id: uuid.New(), SenderId: aliceId, ReceiverId: joeId, Amount: 1, Status: model.StatusSuccess}
+	require.NoError(t, repo.CreateTransfer(ctx, tx))
+
+	require.NoError(t, repo.DeletePendingTransaction(ctx, tx.Id.String()))
+
+	_, err := repo.GetTransactionById(ctx, tx.Id.String())
+	require.NoError(t, err)
+}
+
+func TestTxManager_RollsBackOnError(t *testing.T) {
+	store := initStore(t)
+	userRepo := memory.NewUserRepository(store)
+	txManager := memory.NewTxManager(store)
+	ctx := context.Background()
+
+	failure := errors.New("boom")
+	err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		require.NoError(t, userRepo.UpdateBalance(ctx, aliceId.String(), 0))
+		// Nested calls join the outer transaction instead of deadlocking.
+		return txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			return failure
+		})
+	})
+
+	require.ErrorIs(t, err, failure)
+	balance, err := userRepo.GetBalance(ctx, aliceId.String())
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, balance)
+}
+
+func TestTxManager_ConcurrentTransactionsAreSerialised(t *testing.T) {
+	store := initStore(t)
+	userRepo := memory.NewUserRepository(store)
+	txManager := memory.NewTxManager(store)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+				balance, err := userRepo.GetBalance(ctx, joeId.String())
+				if err != nil {
+					return err
+				}
+				return userRepo.UpdateBalance(ctx, joeId.String(), balance+1)
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	balance, err := userRepo.GetBalance(ctx, joeId.String())
+	require.NoError(t, err)
+	assert.Equal(t, 10850.0, balance)
+}
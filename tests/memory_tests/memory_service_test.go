@@ -0,0 +1,108 @@
+package memory_tests
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/internal/queue"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/internal/repository/memory"
+	"testing"
+	"time"
+)
+
+// TestTransferFlow runs a transfer through the services and worker on the
+// in-memory backend, wired with the same audit and event decorators as the
+// server.
+func TestTransferFlow(t *testing.T) {
+	store := initStore(t)
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	txManager := memory.NewTxManager(store)
+	auditRepo := memory.NewAuditRepository(store)
+	outboxRepo := memory.NewOutboxRepository(store)
+	userRepo := repository.NewAuditedUserRepository(
+		repository.NewEventedUserRepository(memory.NewUserRepository(store), outboxRepo, txManager), auditRepo, txManager)
+	transferRepo := repository.NewAuditedTransferRepository(
+		repository.NewEventedTransferRepository(memory.NewTransferRepository(store), outboxRepo, txManager), auditRepo, txManager)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	jobs := queue.NewChannelQueue(1, time.Second)
+	completions := queue.NewCompletions()
+	go queue.NewWorker(jobs, userRepo, transferRepo, txManager, completions, 0, log).Run(ctx)
+	transferService := service.NewTransferService(transferRepo, userRepo, jobs, completions, log)
+
+	txId, err := transferService.CreateTransfer(ctx, aliceId.String(), joeId.String(), 100)
+	require.NoError(t, err)
+
+	stored, err := transferService.WaitForTransfer(ctx, txId, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusSuccess, stored.Status)
+
+	aliceBalance, err := userRepo.GetBalance(ctx, aliceId.String())
+	require.NoError(t, err)
+	assert.Equal(t, 900.0, aliceBalance)
+
+	events, err := outboxRepo.Pending(ctx, 10)
+	require.NoError(t, err)
+	var eventTypes []string
+	for _, event := range events {
+		eventTypes = append(eventTypes, event.Type)
+	}
+	assert.Equal(t, []string{
+		model.EventTransferCreated,
+		model.EventBalanceChanged,
+		model.EventBalanceChanged,
+		model.EventTransferSucceeded,
+	}, eventTypes)
+
+	_, err = transferService.CreateTransfer(ctx, aliceId.String(), joeId.String(), 5000)
+	assert.True(t, errors.Is(err, apperrors.ErrInsufficientFunds))
+
+	report, err := service.NewReconciliationService(memory.NewLedgerRepository(store), log).Reconcile(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.Balanced)
+
+	result, err := service.NewAuditService(auditRepo, log).Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+}
+
+// concurrentlyRevokedKeys revokes the key between Rotate reading it and
+// revoking it, as a second rotation of the same key would.
+type concurrentlyRevokedKeys struct {
+	*memory.APIKeyRepo
+}
+
+func (r concurrentlyRevokedKeys) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	if err := r.APIKeyRepo.Revoke(ctx, id, revokedAt); err != nil {
+		return err
+	}
+	return r.APIKeyRepo.Revoke(ctx, id, revokedAt)
+}
+
+func TestAPIKeyRotation_LeavesNoKeyBehindWhenItLosesARace(t *testing.T) {
+	ctx := context.Background()
+	store := initStore(t)
+	keys := memory.NewAPIKeyRepository(store)
+	svc := service.NewAPIKeyService(concurrentlyRevokedKeys{keys}, memory.NewWebhookRepository(store), memory.NewTxManager(store),
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	old, _, err := svc.Issue(ctx, "payments", []string{model.ScopeTransfersWrite})
+	require.NoError(t, err)
+
+	_, _, err = svc.Rotate(ctx, old.Id.String())
+	require.ErrorIs(t, err, service.ErrAPIKeyAlreadyRevoked)
+
+	all, err := keys.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 1, "the replacement key is rolled back")
+	assert.False(t, all[0].IsRevoked(), "so is the revoke")
+}
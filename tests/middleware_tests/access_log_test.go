@@ -0,0 +1,57 @@
+package middleware_tests
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/mock"
+	"moneyTransfer/api/middleware"
+	"moneyTransfer/tests"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func initAccessLogRouter(log *tests.MockLogger, h http.HandlerFunc) *mux.Router {
+	router := mux.NewRouter()
+	router.HandleFunc("/balance/{userId}", h)
+	router.HandleFunc("/readyz", h)
+	router.Use(middleware.RequestId)
+	router.Use(middleware.AccessLog(log))
+	return router
+}
+
+func TestAccessLog_LogsServedRequest(t *testing.T) {
+	log := new(tests.MockLogger)
+	log.On("Info", "request served",
+		"method", http.MethodGet, "path", "/balance/42", "route", "/balance/{userId}", "status", http.StatusNotFound, "bytes", 10,
+		"duration", mock.Anything, "remote_addr", mock.Anything, "user_agent", "probe/1.0", "request_id", "abc-123").Return()
+	router := initAccessLogRouter(log, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/balance/42", nil)
+	req.Header.Set(middleware.RequestIdHeader, "abc-123")
+	req.Header.Set("User-Agent", "probe/1.0")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	log.AssertExpectations(t)
+}
+
+func TestAccessLog_ProbesAndServerErrors(t *testing.T) {
+	log := new(tests.MockLogger)
+	log.On("Debug", "request served", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	log.On("Warn", "request served", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	router := initAccessLogRouter(log, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/balance/42" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/balance/42", nil))
+
+	log.AssertNumberOfCalls(t, "Debug", 1)
+	log.AssertNumberOfCalls(t, "Warn", 1)
+	log.AssertNumberOfCalls(t, "Info", 0)
+}
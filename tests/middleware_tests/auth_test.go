@@ -0,0 +1,86 @@
+package middleware_tests
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"moneyTransfer/api/middleware"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/tests"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthenticator_MissingKey(t *testing.T) {
+	_, _, handler := initAuthenticator(model.ScopeBalancesRead)
+
+	req := httptest.NewRequest(http.MethodGet, "/balance/1", nil)
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+}
+
+func TestAuthenticator_InvalidKey(t *testing.T) {
+	svc, _, handler := initAuthenticator(model.ScopeBalancesRead)
+
+	svc.On("Authenticate", mock.Anything, "mtk_bad").Return(model.APIKey{}, service.ErrInvalidAPIKey)
+
+	req := httptest.NewRequest(http.MethodGet, "/balance/1", nil)
+	req.Header.Set("X-API-Key", "mtk_bad")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestAuthenticator_MissingScope(t *testing.T) {
+	svc, logger, handler := initAuthenticator(model.ScopeTransfersWrite)
+
+	key := model.APIKey{Id: uuid.New(), Scopes: []string{model.ScopeBalancesRead}}
+	svc.On("Authenticate", mock.Anything, "mtk_reader").Return(key, nil)
+	logger.On("Warn", "api key missing scope", "apiKeyId", key.Id, "scope", model.ScopeTransfersWrite, "path", "/transfers").Return()
+
+	req := httptest.NewRequest(http.MethodPost, "/transfers", nil)
+	req.Header.Set("Authorization", "Bearer mtk_reader")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	logger.AssertExpectations(t)
+}
+
+func TestAuthenticator_AdminGrantsAllScopes(t *testing.T) {
+	svc, _, handler := initAuthenticator(model.ScopeTransfersWrite)
+
+	key := model.APIKey{Id: uuid.New(), Scopes: []string{model.ScopeAdmin}}
+	svc.On("Authenticate", mock.Anything, "mtk_admin").Return(key, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/transfers", nil)
+	req.Header.Set("Authorization", "bearer mtk_admin")
+	rr := httptest.NewRecorder()
+
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, key.Id.String(), rr.Body.String())
+}
+
+func initAuthenticator(scope string) (*tests.MockAPIKeyService, *tests.MockLogger, http.Handler) {
+	svc := new(tests.MockAPIKeyService)
+	logger := new(tests.MockLogger)
+	authenticator := middleware.NewAuthenticator(svc, logger)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, _ := model.APIKeyFromContext(r.Context())
+		w.Write([]byte(key.Id.String()))
+	})
+
+	return svc, logger, authenticator.RequireScope(scope)(next)
+}
@@ -0,0 +1,81 @@
+package middleware_tests
+
+import (
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/api/middleware"
+	"moneyTransfer/pkg/metrics"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func initMetricsRouter(h http.HandlerFunc) (*prometheus.Registry, *metrics.HTTPMetrics, *mux.Router) {
+	registry := prometheus.NewRegistry()
+	httpMetrics := metrics.NewHTTPMetrics(registry)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/balance/{userId}", h)
+	router.Use(middleware.Metrics(httpMetrics))
+	return registry, httpMetrics, router
+}
+
+func TestMetrics_LabelsByRouteTemplate(t *testing.T) {
+	registry, httpMetrics, router := initMetricsRouter(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"balance":10}`))
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/balance/1", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/balance/2", nil))
+
+	// Both users share one series.
+	count, err := testutil.GatherAndCount(registry, "http_requests_total")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, 2.0, testutil.ToFloat64(httpMetrics.Requests.WithLabelValues(http.MethodGet, "/balance/{userId}", "200")))
+}
+
+func TestMetrics_RecordsStatusAndSize(t *testing.T) {
+	registry, _, router := initMetricsRouter(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "user not found", http.StatusNotFound)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/balance/1", nil))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	var size *float64
+	for _, family := range families {
+		if family.GetName() != "http_response_size_bytes" {
+			continue
+		}
+		labels := map[string]string{}
+		for _, label := range family.Metric[0].Label {
+			labels[label.GetName()] = label.GetValue()
+		}
+		assert.Equal(t, map[string]string{"method": "GET", "route": "/balance/{userId}", "code": "404"}, labels)
+		size = family.Metric[0].Histogram.SampleSum
+	}
+	require.NotNil(t, size)
+	assert.Equal(t, float64(len("user not found\n")), *size)
+}
+
+func TestMetrics_CountsInFlightRequests(t *testing.T) {
+	httpMetrics := metrics.NewHTTPMetrics(prometheus.NewRegistry())
+	inFlight := httpMetrics.InFlight.WithLabelValues(http.MethodGet, "/balance/{userId}")
+
+	var during float64
+	router := mux.NewRouter()
+	router.HandleFunc("/balance/{userId}", func(w http.ResponseWriter, r *http.Request) {
+		during = testutil.ToFloat64(inFlight)
+	})
+	router.Use(middleware.Metrics(httpMetrics))
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/balance/1", nil))
+
+	assert.Equal(t, 1.0, during)
+	assert.Equal(t, 0.0, testutil.ToFloat64(inFlight))
+}
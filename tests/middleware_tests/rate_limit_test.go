@@ -0,0 +1,117 @@
+package middleware_tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"moneyTransfer/api/middleware"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/pkg/ratelimit"
+	"moneyTransfer/tests"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRateLimiter_SetsHeadersAndRejects(t *testing.T) {
+	logger, handler := initRateLimiter(middleware.RateLimitPolicy{
+		Name: "client", Limiter: ratelimit.NewLimiter(1, 2), Key: middleware.ClientKey,
+	})
+	logger.On("Warn", "rate limit exceeded", "route", "test", "policy", "client", "key", "ip:192.0.2.1").Return()
+
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(nil))
+		require.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+	}
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newRequest(nil))
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+
+	var errResp dtos.ProblemDetails
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&errResp))
+	assert.Equal(t, dtos.ProblemContentType, rr.Header().Get("Content-Type"))
+	assert.Equal(t, apperrors.CodeRateLimited, errResp.ErrorCode)
+	assert.Equal(t, `rate limit "client" exceeded`, errResp.Detail)
+	logger.AssertExpectations(t)
+}
+
+func TestRateLimiter_TransferSenderKeyPreservesBody(t *testing.T) {
+	_, handler := initRateLimiter(middleware.RateLimitPolicy{
+		Name: "account", Limiter: ratelimit.NewLimiter(1, 1), Key: middleware.TransferSenderKey,
+	})
+
+	body := []byte(`{"from":"7141b92f-a8c8-471e-83e5-7fc72da61cb9","to":"befeef21-1475-4a13-a0de-3943d2eb0910","amount":1}`)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newRequest(body))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, string(body), rr.Body.String())
+}
+
+func TestRateLimiter_SenderLimitIsPerAccount(t *testing.T) {
+	logger, handler := initRateLimiter(middleware.RateLimitPolicy{
+		Name: "account", Limiter: ratelimit.NewLimiter(1, 1), Key: middleware.TransferSenderKey,
+	})
+	logger.On("Warn", "rate limit exceeded", "route", "test", "policy", "account", "key", mock.Anything).Return()
+
+	alice := []byte(`{"from":"7141b92f-a8c8-471e-83e5-7fc72da61cb9"}`)
+	bob := []byte(`{"from":"939cb506-0d70-4791-8c9e-d4284d87c749"}`)
+
+	codes := []int{}
+	for _, body := range [][]byte{alice, alice, bob} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(body))
+		codes = append(codes, rr.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests, http.StatusOK}, codes)
+}
+
+func TestRateLimiter_RefusedRequestDoesNotUseOtherPolicies(t *testing.T) {
+	client := ratelimit.NewLimiter(1, 3)
+	logger, handler := initRateLimiter(
+		middleware.RateLimitPolicy{Name: "client", Limiter: client, Key: middleware.ClientKey},
+		middleware.RateLimitPolicy{Name: "account", Limiter: ratelimit.NewLimiter(1, 1), Key: middleware.TransferSenderKey},
+	)
+	logger.On("Warn", "rate limit exceeded", "route", "test", "policy", "account", "key", mock.Anything).Return()
+
+	alice := []byte(`{"from":"7141b92f-a8c8-471e-83e5-7fc72da61cb9"}`)
+	for _, want := range []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, newRequest(alice))
+		require.Equal(t, want, rr.Code)
+	}
+
+	assert.Equal(t, 1, client.Allow("client:ip:192.0.2.1").Remaining, "only the accepted request counts against the client")
+}
+
+func initRateLimiter(policies ...middleware.RateLimitPolicy) (*tests.MockLogger, http.Handler) {
+	logger := new(tests.MockLogger)
+	rl := middleware.NewRateLimiter(logger)
+	for _, policy := range policies {
+		rl.AddPolicy("test", policy)
+	}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})
+
+	return logger, rl.For("test")(next)
+}
+
+func newRequest(body []byte) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/transfers", bytes.NewReader(body))
+	req.RemoteAddr = "192.0.2.1:1234"
+	return req
+}
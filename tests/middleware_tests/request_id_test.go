@@ -0,0 +1,75 @@
+package middleware_tests
+
+import (
+	"github.com/stretchr/testify/assert"
+	"moneyTransfer/api/middleware"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+	"moneyTransfer/tests"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestId_ReusesIncomingHeader(t *testing.T) {
+	var seen string
+	handler := middleware.RequestId(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = model.RequestIdFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middleware.RequestIdHeader, "abc-123")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, "abc-123", seen)
+	assert.Equal(t, "abc-123", rr.Header().Get(middleware.RequestIdHeader))
+	assert.Equal(t, "abc-123", rr.Header().Get(middleware.CorrelationIdHeader))
+}
+
+func TestRequestId_AcceptsCorrelationIdHeader(t *testing.T) {
+	var seen string
+	handler := middleware.RequestId(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = model.RequestIdFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middleware.CorrelationIdHeader, "corr-1")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, "corr-1", seen)
+	assert.Equal(t, "corr-1", rr.Header().Get(middleware.RequestIdHeader))
+}
+
+func TestRequestId_GeneratesWhenMissingOrInvalid(t *testing.T) {
+	for _, incoming := range []string{"", "forged\nline"} {
+		var seen string
+		handler := middleware.RequestId(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = model.RequestIdFromContext(r.Context())
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(middleware.RequestIdHeader, incoming)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.NotEmpty(t, seen)
+		assert.NotEqual(t, incoming, seen)
+		assert.Equal(t, seen, rr.Header().Get(middleware.RequestIdHeader))
+	}
+}
+
+func TestRequestId_AddsIdToLogLines(t *testing.T) {
+	log := new(tests.MockLogger)
+	log.On("Info", "balance retrieved", "userId", "42", "request_id", "abc-123").Return()
+	handler := middleware.RequestId(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		logger.WithContext(r.Context(), log).Info("balance retrieved", "userId", "42")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(middleware.RequestIdHeader, "abc-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	log.AssertExpectations(t)
+}
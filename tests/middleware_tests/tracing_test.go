@@ -0,0 +1,78 @@
+package middleware_tests
+
+import (
+	"context"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"moneyTransfer/api/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func initTracingRouter(t *testing.T, h http.HandlerFunc) (*tracetest.SpanRecorder, *mux.Router) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	router := mux.NewRouter()
+	router.HandleFunc("/balance/{userId}", h)
+	router.Use(middleware.RequestId)
+	router.Use(middleware.Tracing)
+	return recorder, router
+}
+
+func TestTracing_NamesSpanByRouteAndRecordsStatus(t *testing.T) {
+	recorder, router := initTracingRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/balance/42", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "GET /balance/{userId}", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+
+	attributes := map[string]any{}
+	for _, attribute := range spans[0].Attributes() {
+		attributes[string(attribute.Key)] = attribute.Value.AsInterface()
+	}
+	assert.Equal(t, int64(http.StatusServiceUnavailable), attributes["http.response.status_code"])
+	assert.Equal(t, "/balance/42", attributes["url.path"])
+	assert.NotEmpty(t, attributes["request_id"])
+}
+
+func TestTracing_ClientErrorsAreNotSpanErrors(t *testing.T) {
+	recorder, router := initTracingRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusNotFound)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/balance/42", nil))
+
+	assert.Equal(t, codes.Unset, recorder.Ended()[0].Status().Code)
+}
+
+func TestTracing_KeepsStreamingAndHijacking(t *testing.T) {
+	var flushed, hijacked bool
+	_, router := initTracingRouter(t, func(w http.ResponseWriter, r *http.Request) {
+		flushed = http.NewResponseController(w).Flush() == nil
+		_, ok := w.(http.Hijacker)
+		hijacked = ok
+	})
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL + "/balance/42")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.True(t, flushed, "event streams can flush")
+	assert.True(t, hijacked, "WebSocket upgrades can hijack")
+}
@@ -0,0 +1,63 @@
+package migrate_tests
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+	"fmt"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/migrate"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/migrations"
+	"os"
+	"testing"
+	"time"
+)
+
+// legacyInitSQL is the init.sql databases were created from before the
+// migrations existed.
+//
+//go:embed testdata/legacy_init.sql
+var legacyInitSQL string
+
+// TestMigrator_Up_AdoptsLegacySchema needs a Postgres database, given by
+// TEST_POSTGRES_URL; it works in a schema of its own and drops it afterwards.
+func TestMigrator_Up_AdoptsLegacySchema(t *testing.T) {
+	dsn := os.Getenv("TEST_POSTGRES_URL")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_URL is not set")
+	}
+
+	ctx := context.Background()
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	// One connection, so the search_path below applies to every statement.
+	db.SetMaxOpenConns(1)
+
+	schema := fmt.Sprintf("legacy_%d", time.Now().UnixNano())
+	_, err = db.ExecContext(ctx, `CREATE SCHEMA `+schema+`; SET search_path TO `+schema+`, public`)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.ExecContext(context.Background(), `DROP SCHEMA `+schema+` CASCADE`) })
+
+	_, err = db.ExecContext(ctx, legacyInitSQL)
+	require.NoError(t, err)
+
+	all, err := migrate.Load(migrations.FS)
+	require.NoError(t, err)
+	_, err = migrate.NewMigrator(db, all, newLogger()).Up(ctx)
+	require.NoError(t, err)
+
+	ledgers, err := repository.NewLedgerRepository(db).GetAccountLedgers(ctx)
+	require.NoError(t, err)
+	require.Len(t, ledgers, 8)
+	for _, ledger := range ledgers {
+		assert.Equal(t, ledger.ExpectedBalance(), ledger.Balance, "account %s", ledger.UserId)
+	}
+
+	var alice float64
+	require.NoError(t, db.QueryRowContext(ctx, `SELECT initial_balance FROM users WHERE email = 'alice@example.com'`).Scan(&alice))
+	assert.Equal(t, 1010.0, alice, "the balance before Alice's transfer to Joe")
+}
@@ -0,0 +1,150 @@
+package migrate_tests
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/migrate"
+	"moneyTransfer/migrations"
+	"moneyTransfer/tests"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestLoad_SortsAndPairsFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_second.up.sql":   {Data: []byte("CREATE TABLE b ();")},
+		"0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+		"0001_first.up.sql":    {Data: []byte("CREATE TABLE a ();")},
+		"0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+		"README.md":            {Data: []byte("ignored")},
+	}
+
+	all, err := migrate.Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, int64(1), all[0].Version)
+	assert.Equal(t, "first", all[0].Name)
+	assert.Equal(t, "DROP TABLE a;", all[0].Down)
+	assert.Equal(t, int64(2), all[1].Version)
+}
+
+func TestLoad_RequiresDownFile(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0001_first.up.sql": {Data: []byte("CREATE TABLE a ();")},
+	}
+
+	_, err := migrate.Load(fsys)
+	require.Error(t, err)
+}
+
+func TestLoad_EmbeddedMigrations(t *testing.T) {
+	all, err := migrate.Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+
+	for i, m := range all {
+		assert.Equal(t, int64(i+1), m.Version, "migration versions must be contiguous")
+	}
+}
+
+func TestMigrator_Up_AppliesPendingOnly(t *testing.T) {
+	db, dbMock := tests.SetupMockDB(t)
+	migrator := migrate.NewMigrator(db, testMigrations(), newLogger())
+
+	dbMock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery(`SELECT version FROM schema_migrations WHERE version = \$1`).WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(1))
+	dbMock.ExpectCommit()
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery(`SELECT version FROM schema_migrations WHERE version = \$1`).WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	dbMock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b ();")).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs(int64(2), "second", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	applied, err := migrator.Up(context.Background())
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, int64(2), applied[0].Version)
+	require.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestMigrator_Up_RollsBackFailedMigration(t *testing.T) {
+	db, dbMock := tests.SetupMockDB(t)
+	migrator := migrate.NewMigrator(db, testMigrations()[:1], newLogger())
+
+	dbMock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery(`SELECT version FROM schema_migrations`).WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	dbMock.ExpectExec(regexp.QuoteMeta("CREATE TABLE a ();")).WillReturnError(assert.AnError)
+	dbMock.ExpectRollback()
+
+	applied, err := migrator.Up(context.Background())
+	require.ErrorIs(t, err, assert.AnError)
+	assert.Empty(t, applied)
+	require.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestMigrator_Down_RevertsNewestApplied(t *testing.T) {
+	db, dbMock := tests.SetupMockDB(t)
+	migrator := migrate.NewMigrator(db, testMigrations(), newLogger())
+
+	dbMock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery(`SELECT version FROM schema_migrations WHERE version = \$1`).WithArgs(int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	dbMock.ExpectExec(regexp.QuoteMeta("DROP TABLE b;")).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectExec(`DELETE FROM schema_migrations WHERE version = \$1`).WithArgs(int64(2)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectCommit()
+
+	reverted, err := migrator.Down(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, "second", reverted[0].Name)
+	require.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestMigrator_Status(t *testing.T) {
+	db, dbMock := tests.SetupMockDB(t)
+	migrator := migrate.NewMigrator(db, testMigrations(), newLogger())
+
+	appliedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	dbMock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery(`SELECT version, applied_at FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, appliedAt))
+
+	statuses, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.True(t, statuses[0].Applied)
+	assert.Equal(t, appliedAt, *statuses[0].AppliedAt)
+	assert.False(t, statuses[1].Applied)
+	require.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func testMigrations() []migrate.Migration {
+	return []migrate.Migration{
+		{Version: 1, Name: "first", Up: "CREATE TABLE a ();", Down: "DROP TABLE a;"},
+		{Version: 2, Name: "second", Up: "CREATE TABLE b ();", Down: "DROP TABLE b;"},
+	}
+}
+
+func newLogger() *tests.MockLogger {
+	logger := new(tests.MockLogger)
+	logger.On("Info", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	return logger
+}
@@ -0,0 +1,290 @@
+package outbox_tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/outbox"
+	"moneyTransfer/internal/repository/memory"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingSink keeps what it accepted and rejects events of the accounts in
+// failing.
+type recordingSink struct {
+	mu        sync.Mutex
+	failing   map[string]bool
+	published []model.DomainEvent
+}
+
+func (s *recordingSink) Publish(_ context.Context, event model.DomainEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failing[event.AccountId] {
+		return errors.New("sink unavailable")
+	}
+	s.published = append(s.published, event)
+	return nil
+}
+
+func (s *recordingSink) Close() error { return nil }
+
+func (s *recordingSink) setFailing(accountId string, failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing[accountId] = failing
+}
+
+func (s *recordingSink) sequences() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sequences []int64
+	for _, event := range s.published {
+		sequences = append(sequences, event.Sequence)
+	}
+	return sequences
+}
+
+func (s *recordingSink) accountSequences() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sequences []int64
+	for _, event := range s.published {
+		sequences = append(sequences, event.AccountSequence)
+	}
+	return sequences
+}
+
+func appendEvents(ctx context.Context, t *testing.T, repo *memory.OutboxRepo, accounts ...string) {
+	t.Helper()
+	for _, account := range accounts {
+		event, err := model.NewDomainEvent(model.EventBalanceChanged, account, model.BalanceChangedPayload{AccountId: account})
+		require.NoError(t, err)
+		_, err = repo.Append(ctx, event)
+		require.NoError(t, err)
+	}
+}
+
+func newRelay(store *memory.Store, sink outbox.Sink, batchSize int) *outbox.Relay {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return outbox.NewRelay(memory.NewOutboxRepository(store), memory.NewTxManager(store), sink, batchSize, 10*time.Millisecond, time.Minute, log)
+}
+
+func TestRelay_PublishesInSequenceOrderPerAccount(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewOutboxRepository(store)
+	appendEvents(context.Background(), t, repo, "alice", "bob", "alice")
+
+	sink := &recordingSink{failing: map[string]bool{}}
+	published, err := newRelay(store, sink, 10).RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, published)
+	assert.Equal(t, []int64{1, 3, 2}, sink.sequences(), "alice's events, then bob's")
+	assert.Equal(t, []int64{1, 2, 1}, sink.accountSequences())
+
+	pending, err := repo.Pending(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestRelay_FailedEventHoldsBackOnlyItsAccount(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewOutboxRepository(store)
+	appendEvents(context.Background(), t, repo, "alice", "bob", "alice", "bob")
+
+	sink := &recordingSink{failing: map[string]bool{"alice": true}}
+	relay := newRelay(store, sink, 10)
+
+	published, err := relay.RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []int64{2, 4}, sink.sequences())
+
+	// Alice is held back for a poll interval, so the next batch skips her.
+	sink.setFailing("alice", false)
+	published, err = relay.RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, published)
+
+	// Then her events go out in their original order.
+	time.Sleep(20 * time.Millisecond)
+	published, err = relay.RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+	assert.Equal(t, []int64{2, 4, 1, 3}, sink.sequences())
+}
+
+// failingMarks fails to mark the events of one account published.
+type failingMarks struct {
+	*memory.OutboxRepo
+	account string
+}
+
+func (r failingMarks) MarkPublished(ctx context.Context, sequence int64, publishedAt time.Time) error {
+	pending, err := r.OutboxRepo.Pending(ctx, 100)
+	if err != nil {
+		return err
+	}
+	for _, event := range pending {
+		if event.Sequence == sequence && event.AccountId == r.account {
+			return errors.New("database unavailable")
+		}
+	}
+	return r.OutboxRepo.MarkPublished(ctx, sequence, publishedAt)
+}
+
+func TestRelay_FailedMarkStillReleasesEveryAccount(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewOutboxRepository(store)
+	appendEvents(context.Background(), t, repo, "alice", "bob", "alice")
+
+	sink := &recordingSink{failing: map[string]bool{}}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	relay := outbox.NewRelay(failingMarks{OutboxRepo: repo, account: "alice"}, memory.NewTxManager(store), sink, 10, 10*time.Millisecond, time.Minute, log)
+
+	published, err := relay.RelayBatch(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, 1, published, "bob's event")
+	assert.Equal(t, []int64{1, 2}, sink.sequences(), "alice's second event waits behind her first")
+
+	// Both accounts were released, so after a poll interval, well before the
+	// claim timeout, alice's events can be claimed again.
+	time.Sleep(20 * time.Millisecond)
+	now := time.Now().UTC()
+	claimed, err := repo.Claim(context.Background(), now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2)
+	assert.Equal(t, "alice", claimed[0].AccountId)
+}
+
+func TestRelay_BatchSizeCountsAccounts(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewOutboxRepository(store)
+	appendEvents(context.Background(), t, repo, "alice", "alice", "bob")
+
+	sink := &recordingSink{failing: map[string]bool{}}
+	published, err := newRelay(store, sink, 1).RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, published, "every pending event of the oldest account")
+	assert.Equal(t, []int64{1, 2}, sink.sequences())
+}
+
+func TestRelay_SkipsAccountsClaimedByAnotherRelay(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewOutboxRepository(store)
+	appendEvents(context.Background(), t, repo, "alice", "bob")
+
+	now := time.Now().UTC()
+	claimed, err := repo.Claim(context.Background(), now, now.Add(time.Minute), 1)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+
+	sink := &recordingSink{failing: map[string]bool{}}
+	published, err := newRelay(store, sink, 10).RelayBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []int64{2}, sink.sequences(), "alice is leased to the other relay")
+}
+
+func TestOutbox_AppendNumbersEventsPerAccount(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewOutboxRepository(store)
+	appendEvents(context.Background(), t, repo, "alice", "bob", "alice")
+
+	err := memory.NewTxManager(store).WithinTransaction(context.Background(), func(ctx context.Context) error {
+		appendEvents(ctx, t, repo, "alice")
+		return errors.New("transfer failed")
+	})
+	require.Error(t, err)
+	appendEvents(context.Background(), t, repo, "alice")
+
+	pending, err := repo.Pending(context.Background(), 10)
+	require.NoError(t, err)
+	var numbers []int64
+	for _, event := range pending {
+		numbers = append(numbers, event.AccountSequence)
+	}
+	assert.Equal(t, []int64{1, 1, 2, 3}, numbers, "a rolled-back append leaves no gap")
+}
+
+func TestRelay_RunDrainsOnShutdown(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewOutboxRepository(store)
+	sink := &recordingSink{failing: map[string]bool{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	appendEvents(context.Background(), t, repo, "alice")
+
+	require.NoError(t, newRelay(store, sink, 10).Run(ctx))
+	assert.Equal(t, []int64{1}, sink.sequences())
+}
+
+func TestOutbox_RolledBackWriteLeavesNoEvent(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewOutboxRepository(store)
+
+	err := memory.NewTxManager(store).WithinTransaction(context.Background(), func(ctx context.Context) error {
+		appendEvents(ctx, t, repo, "alice")
+		return errors.New("transfer failed")
+	})
+	require.Error(t, err)
+
+	pending, err := repo.Pending(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestWriterSink_WritesOneJSONLinePerEvent(t *testing.T) {
+	var buf bytes.Buffer
+	sink := outbox.NewWriterSink(&buf)
+
+	event, err := model.NewDomainEvent(model.EventTransferCreated, "alice", map[string]string{"id": "tx"})
+	require.NoError(t, err)
+	require.NoError(t, sink.Publish(context.Background(), event))
+	require.NoError(t, sink.Publish(context.Background(), event))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var decoded model.DomainEvent
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &decoded))
+	assert.Equal(t, event.Id, decoded.Id)
+	assert.JSONEq(t, `{"id":"tx"}`, string(decoded.Payload))
+}
+
+func TestBroker_FansOutToSubscribers(t *testing.T) {
+	broker := outbox.NewBroker(time.Second)
+	first, unsubscribeFirst := broker.Subscribe(1)
+	second, unsubscribeSecond := broker.Subscribe(1)
+	defer unsubscribeFirst()
+	defer unsubscribeSecond()
+
+	event := model.DomainEvent{Sequence: 1, Type: model.EventTransferCreated}
+	require.NoError(t, broker.Publish(context.Background(), event))
+
+	assert.Equal(t, event, <-first)
+	assert.Equal(t, event, <-second)
+}
+
+func TestBroker_DropsSlowSubscriber(t *testing.T) {
+	broker := outbox.NewBroker(10 * time.Millisecond)
+	slow, _ := broker.Subscribe(0)
+
+	require.NoError(t, broker.Publish(context.Background(), model.DomainEvent{Sequence: 1}))
+
+	_, open := <-slow
+	assert.False(t, open)
+}
@@ -0,0 +1,16 @@
+package tests
+
+import (
+	"context"
+	"github.com/stretchr/testify/mock"
+	"moneyTransfer/internal/queue"
+)
+
+type MockPublisher struct {
+	mock.Mock
+}
+
+func (m *MockPublisher) Publish(ctx context.Context, job queue.TransferJob) error {
+	args := m.Called(ctx, job)
+	return args.Error(0)
+}
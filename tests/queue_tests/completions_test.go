@@ -0,0 +1,47 @@
+package queue_tests
+
+import (
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"moneyTransfer/internal/queue"
+	"testing"
+)
+
+func TestCompletions_WakesWaitersOfThatTransaction(t *testing.T) {
+	completions := queue.NewCompletions()
+	id, other := uuid.New(), uuid.New()
+
+	first, stopFirst := completions.Wait(id)
+	second, stopSecond := completions.Wait(id)
+	unrelated, stopUnrelated := completions.Wait(other)
+	defer stopFirst()
+	defer stopSecond()
+	defer stopUnrelated()
+
+	completions.Complete(id)
+
+	assert.True(t, isClosed(first))
+	assert.True(t, isClosed(second))
+	assert.False(t, isClosed(unrelated))
+}
+
+func TestCompletions_StoppedWaiterIsNotWoken(t *testing.T) {
+	completions := queue.NewCompletions()
+	id := uuid.New()
+
+	done, stop := completions.Wait(id)
+	stop()
+	completions.Complete(id)
+
+	assert.False(t, isClosed(done))
+	completions.Complete(uuid.New()) // nobody waiting is fine
+}
+
+func isClosed(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,69 @@
+package queue_tests
+
+import (
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/queue"
+	"moneyTransfer/pkg/metrics"
+	"moneyTransfer/tests"
+	"testing"
+	"time"
+)
+
+func TestProcessJob_CountsFailureReason(t *testing.T) {
+	ctx, userRepo, transferRepo, logger := initWorker()
+
+	job := queue.TransferJob{Amount: 80, SenderId: uuid.New(), ReceiverId: uuid.New(), TransactionId: uuid.New()}
+
+	userRepo.On("Debit", ctx, job.SenderId.String(), job.Amount).Return(0.0, 0.0, apperrors.InsufficientFunds("insufficient funds"))
+	userRepo.On("Credit", ctx, job.ReceiverId.String(), job.Amount).Return(0.0, 80.0, nil).Maybe()
+	transferRepo.On("FinishTransaction", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
+	logger.On("Error", "insufficient funds", "amount", job.Amount).Return()
+
+	failed := metrics.TransfersTotal.WithLabelValues(model.StatusFailed, "insufficient_funds")
+	before := testutil.ToFloat64(failed)
+
+	require.NoError(t, queue.ProcessJob(ctx, job, userRepo, transferRepo, tests.PassthroughTxManager{}, logger))
+
+	require.Equal(t, before+1, testutil.ToFloat64(failed))
+}
+
+func TestWorker_Handle_RecordsMetrics(t *testing.T) {
+	ctx, userRepo, transferRepo, logger := initWorker()
+	worker := queue.NewWorker(nil, userRepo, transferRepo, tests.PassthroughTxManager{}, queue.NewCompletions(), 0, logger)
+
+	job := queue.TransferJob{Amount: 80, SenderId: uuid.New(), ReceiverId: uuid.New(), TransactionId: uuid.New()}
+	createdAt := time.Now().Add(-2 * time.Second)
+
+	transferRepo.On("GetTransactionById", mock.Anything, job.TransactionId.String()).
+		Return(model.Transaction{Id: job.TransactionId, Status: model.StatusPending, CreatedAt: createdAt}, nil)
+	userRepo.On("Debit", mock.Anything, job.SenderId.String(), job.Amount).Return(100.0, 20.0, nil)
+	userRepo.On("Credit", mock.Anything, job.ReceiverId.String(), job.Amount).Return(0.0, 80.0, nil)
+	transferRepo.On("FinishTransaction", mock.Anything, job.TransactionId.String(), model.StatusSuccess).Return(nil)
+	logger.On("Info", "transfer completed", "transaction_id", job.TransactionId, "user_id", job.SenderId).Return()
+
+	succeeded := metrics.TransfersTotal.WithLabelValues(model.StatusSuccess, "")
+	before := testutil.ToFloat64(succeeded)
+	count, sum := processingDuration(t)
+
+	require.NoError(t, worker.Handle(ctx, job))
+
+	require.Equal(t, before+1, testutil.ToFloat64(succeeded))
+	require.Equal(t, 0.0, testutil.ToFloat64(metrics.JobsInFlight))
+
+	// The latency runs from the transaction's creation, not from the job's delivery.
+	newCount, newSum := processingDuration(t)
+	require.Equal(t, count+1, newCount)
+	require.GreaterOrEqual(t, newSum-sum, 2.0)
+}
+
+func processingDuration(t *testing.T) (uint64, float64) {
+	var m dto.Metric
+	require.NoError(t, metrics.TransferProcessingDuration.Write(&m))
+	return m.GetHistogram().GetSampleCount(), m.GetHistogram().GetSampleSum()
+}
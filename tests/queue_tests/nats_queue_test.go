@@ -0,0 +1,121 @@
+package queue_tests
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/queue"
+	"moneyTransfer/pkg/metrics"
+	"moneyTransfer/tests"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startNATS runs an embedded JetStream server for the test.
+func startNATS(t *testing.T) string {
+	t.Helper()
+
+	srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, JetStream: true, StoreDir: t.TempDir(), NoLog: true, NoSigs: true})
+	require.NoError(t, err)
+	go srv.Start()
+	t.Cleanup(srv.Shutdown)
+	require.True(t, srv.ReadyForConnections(5*time.Second))
+
+	return srv.ClientURL()
+}
+
+func newNATSQueue(t *testing.T, size int, ackWait time.Duration, logger *tests.MockLogger) *queue.NATSQueue {
+	t.Helper()
+
+	q, err := queue.NewNATSQueue(context.Background(), startNATS(t), "TRANSFERS", size, ackWait, 2, logger)
+	require.NoError(t, err)
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+func TestNATSQueue_PublishAndConsume(t *testing.T) {
+	q := newNATSQueue(t, 10, time.Second, new(tests.MockLogger))
+	job := queue.TransferJob{TransactionId: uuid.New(), SenderId: uuid.New(), ReceiverId: uuid.New(), Amount: 42}
+
+	require.NoError(t, q.Publish(context.Background(), job))
+
+	require.Equal(t, job, consumeOne(t, q))
+}
+
+func TestNATSQueue_QueueFull(t *testing.T) {
+	q := newNATSQueue(t, 1, time.Second, new(tests.MockLogger))
+
+	require.NoError(t, q.Publish(context.Background(), queue.TransferJob{TransactionId: uuid.New()}))
+
+	err := q.Publish(context.Background(), queue.TransferJob{TransactionId: uuid.New()})
+	require.ErrorIs(t, err, queue.ErrQueueFull)
+}
+
+func TestNATSQueue_RedeliversFailedJob(t *testing.T) {
+	logger := new(tests.MockLogger)
+	logger.On("Warn", "transfer job failed, it will be retried", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	q := newNATSQueue(t, 10, time.Second, logger)
+	job := queue.TransferJob{TransactionId: uuid.New(), Amount: 1}
+
+	require.NoError(t, q.Publish(context.Background(), job))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var deliveries atomic.Int32
+	done := make(chan struct{})
+	go q.Consume(ctx, func(_ context.Context, got queue.TransferJob) error {
+		if deliveries.Add(1) == 1 {
+			return errors.New("transient failure")
+		}
+		require.Equal(t, job, got)
+		close(done)
+		return nil
+	}, noDeadLetter(t))
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("job was not redelivered")
+	}
+	require.EqualValues(t, 2, deliveries.Load())
+}
+
+func TestNATSQueue_DeadLettersJobAfterMaxAttempts(t *testing.T) {
+	logger := new(tests.MockLogger)
+	logger.On("Warn", "transfer job failed, it will be retried", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+	logger.On("Error", "transfer job exhausted its attempts, dead-lettering it",
+		mock.Anything, mock.Anything, "attempts", uint64(2), mock.Anything, mock.Anything).Return()
+	q := newNATSQueue(t, 10, time.Second, logger)
+	deadLettered := testutil.ToFloat64(metrics.JobsDeadLettered.WithLabelValues("nats"))
+
+	job := queue.TransferJob{TransactionId: uuid.New()}
+	require.NoError(t, q.Publish(context.Background(), job))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var deliveries atomic.Int32
+	deadLetters := make(chan queue.TransferJob, 1)
+	go q.Consume(ctx, func(context.Context, queue.TransferJob) error {
+		deliveries.Add(1)
+		return errors.New("poison job")
+	}, func(_ context.Context, got queue.TransferJob, reason string) error {
+		require.Equal(t, "poison job", reason)
+		deadLetters <- got
+		return nil
+	})
+
+	require.Eventually(t, func() bool {
+		depth, err := q.Depth(ctx)
+		return err == nil && depth == 0
+	}, 5*time.Second, 10*time.Millisecond, "the terminated job leaves the stream")
+	require.EqualValues(t, 2, deliveries.Load())
+	require.Equal(t, job, <-deadLetters)
+	require.Equal(t, deadLettered+1, testutil.ToFloat64(metrics.JobsDeadLettered.WithLabelValues("nats")))
+}
@@ -0,0 +1,207 @@
+package queue_tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/queue"
+	"moneyTransfer/pkg/metrics"
+	"moneyTransfer/tests"
+	"testing"
+	"time"
+)
+
+const claimQuery = `UPDATE transfer_jobs SET attempts = attempts \+ 1`
+
+func TestPostgresQueue_Publish(t *testing.T) {
+	db, dbMock := tests.SetupMockDB(t)
+	q := queue.NewPostgresQueue(db, 10, time.Millisecond, time.Minute, 5, new(tests.MockLogger))
+
+	job := queue.TransferJob{TransactionId: uuid.New(), SenderId: uuid.New(), ReceiverId: uuid.New(), Amount: 12.5}
+	payload, _ := json.Marshal(job)
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery(`SELECT COUNT\(\*\) FROM transfer_jobs`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(9))
+	dbMock.ExpectExec(`INSERT INTO transfer_jobs \(payload\) VALUES \(\$1\)`).
+		WithArgs(string(payload)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.ExpectCommit()
+
+	require.NoError(t, q.Publish(context.Background(), job))
+	require.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestPostgresQueue_PublishRejectsJobWhenFull(t *testing.T) {
+	db, dbMock := tests.SetupMockDB(t)
+	q := queue.NewPostgresQueue(db, 10, time.Millisecond, time.Minute, 5, new(tests.MockLogger))
+
+	dbMock.ExpectBegin()
+	dbMock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	dbMock.ExpectQuery(`SELECT COUNT\(\*\) FROM transfer_jobs`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+	dbMock.ExpectRollback()
+
+	err := q.Publish(context.Background(), queue.TransferJob{TransactionId: uuid.New()})
+	require.ErrorIs(t, err, queue.ErrQueueFull)
+	require.NoError(t, dbMock.ExpectationsWereMet())
+}
+
+func TestPostgresQueue_ConsumeDeletesHandledJob(t *testing.T) {
+	db, dbMock := tests.SetupMockDB(t)
+	q := queue.NewPostgresQueue(db, 10, time.Hour, time.Minute, 5, new(tests.MockLogger))
+
+	job := queue.TransferJob{TransactionId: uuid.New(), Amount: 5}
+	payload, _ := json.Marshal(job)
+
+	dbMock.ExpectQuery(claimQuery).
+		WithArgs(time.Minute.Milliseconds()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "attempts"}).AddRow(7, string(payload), 1))
+	dbMock.ExpectExec(`DELETE FROM transfer_jobs WHERE id = \$1`).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectQuery(claimQuery).WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "attempts"}))
+
+	require.Equal(t, job, consumeOne(t, q))
+	require.Eventually(t, func() bool { return dbMock.ExpectationsWereMet() == nil }, time.Second, time.Millisecond)
+}
+
+func TestPostgresQueue_ConsumeLeavesFailedJobForRetry(t *testing.T) {
+	db, dbMock := tests.SetupMockDB(t)
+	logger := new(tests.MockLogger)
+	q := queue.NewPostgresQueue(db, 10, time.Hour, time.Minute, 5, logger)
+
+	payload, _ := json.Marshal(queue.TransferJob{TransactionId: uuid.New()})
+	failure := errors.New("db down")
+
+	dbMock.ExpectQuery(claimQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "attempts"}).AddRow(7, string(payload), 2))
+	dbMock.ExpectQuery(claimQuery).WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "attempts"}))
+	logger.On("Warn", "transfer job failed, it will be retried", "job_id", int64(7), "attempts", 2, "error", failure).Return()
+	retries := testutil.ToFloat64(metrics.JobRetries.WithLabelValues("postgres"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	handled := make(chan struct{})
+	go q.Consume(ctx, func(context.Context, queue.TransferJob) error {
+		close(handled)
+		return failure
+	}, noDeadLetter(t))
+
+	<-handled
+	require.Eventually(t, func() bool { return dbMock.ExpectationsWereMet() == nil }, time.Second, time.Millisecond)
+	logger.AssertCalled(t, "Warn", "transfer job failed, it will be retried", "job_id", int64(7), "attempts", 2, "error", mock.Anything)
+	require.Equal(t, retries+1, testutil.ToFloat64(metrics.JobRetries.WithLabelValues("postgres")))
+}
+
+func TestPostgresQueue_DeadLettersJobFailingItsLastAttempt(t *testing.T) {
+	db, dbMock := tests.SetupMockDB(t)
+	logger := new(tests.MockLogger)
+	q := queue.NewPostgresQueue(db, 10, time.Hour, time.Minute, 5, logger)
+
+	job := queue.TransferJob{TransactionId: uuid.New()}
+	payload, _ := json.Marshal(job)
+	failure := errors.New("poison job")
+
+	dbMock.ExpectQuery(claimQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "attempts"}).AddRow(7, string(payload), 5))
+	dbMock.ExpectExec(`INSERT INTO transfer_jobs_dead`).
+		WithArgs(7, failure.Error()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectQuery(claimQuery).WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "attempts"}))
+	logger.On("Error", "transfer job exhausted its attempts, dead-lettering it", "job_id", int64(7), "attempts", 5, "error", failure).Return()
+	deadLettered := testutil.ToFloat64(metrics.JobsDeadLettered.WithLabelValues("postgres"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	deadLetters := make(chan string, 1)
+	go q.Consume(ctx, func(context.Context, queue.TransferJob) error {
+		return failure
+	}, func(_ context.Context, got queue.TransferJob, reason string) error {
+		require.Equal(t, job, got)
+		deadLetters <- reason
+		return nil
+	})
+
+	require.Eventually(t, func() bool { return dbMock.ExpectationsWereMet() == nil }, time.Second, time.Millisecond)
+	require.Equal(t, failure.Error(), <-deadLetters)
+	require.Equal(t, deadLettered+1, testutil.ToFloat64(metrics.JobsDeadLettered.WithLabelValues("postgres")))
+}
+
+func TestPostgresQueue_KeepsJobWhenDeadLetterHandlerFails(t *testing.T) {
+	db, dbMock := tests.SetupMockDB(t)
+	logger := new(tests.MockLogger)
+	q := queue.NewPostgresQueue(db, 10, time.Hour, time.Minute, 5, logger)
+
+	payload, _ := json.Marshal(queue.TransferJob{TransactionId: uuid.New()})
+	failure := errors.New("db down")
+
+	dbMock.ExpectQuery(claimQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "attempts"}).AddRow(7, string(payload), 6))
+	logger.On("Error", "transfer job exhausted its attempts, dead-lettering it", "job_id", int64(7), "attempts", 5).Return()
+	kept := make(chan struct{})
+	logger.On("Error", "failed to claim transfer job", "error", failure).Run(func(mock.Arguments) { close(kept) }).Return().Once()
+	logger.On("Error", "failed to claim transfer job", "error", mock.Anything).Return().Maybe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Consume(ctx, func(context.Context, queue.TransferJob) error {
+		t.Error("an exhausted job must not be handled again")
+		return nil
+	}, func(context.Context, queue.TransferJob, string) error {
+		return failure
+	})
+
+	select {
+	case <-kept:
+		// Moving the row would have failed on sqlmock with a different error.
+	case <-time.After(time.Second):
+		t.Fatal("the dead-letter failure was not reported")
+	}
+}
+
+func TestPostgresQueue_DeadLettersJobWhoseWorkerDied(t *testing.T) {
+	db, dbMock := tests.SetupMockDB(t)
+	logger := new(tests.MockLogger)
+	q := queue.NewPostgresQueue(db, 10, time.Hour, time.Minute, 5, logger)
+
+	payload, _ := json.Marshal(queue.TransferJob{TransactionId: uuid.New()})
+
+	dbMock.ExpectQuery(claimQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "attempts"}).AddRow(7, string(payload), 6))
+	dbMock.ExpectExec(`INSERT INTO transfer_jobs_dead`).
+		WithArgs(7, "worker did not report back").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	dbMock.ExpectQuery(claimQuery).WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "attempts"}))
+	logger.On("Error", "transfer job exhausted its attempts, dead-lettering it", "job_id", int64(7), "attempts", 5).Return()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go q.Consume(ctx, func(context.Context, queue.TransferJob) error {
+		t.Error("an exhausted job must not be handled again")
+		return nil
+	}, func(_ context.Context, _ queue.TransferJob, reason string) error {
+		require.Equal(t, "worker did not report back", reason)
+		return nil
+	})
+
+	require.Eventually(t, func() bool { return dbMock.ExpectationsWereMet() == nil }, time.Second, time.Millisecond)
+}
+
+func TestPostgresQueue_Depth(t *testing.T) {
+	db, dbMock := tests.SetupMockDB(t)
+	q := queue.NewPostgresQueue(db, 10, time.Hour, time.Minute, 5, new(tests.MockLogger))
+
+	dbMock.ExpectQuery(`SELECT COUNT\(\*\) FROM transfer_jobs`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	depth, err := q.Depth(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, depth)
+}
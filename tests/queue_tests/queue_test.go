@@ -0,0 +1,100 @@
+package queue_tests
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/queue"
+	"testing"
+	"time"
+)
+
+func TestChannelQueue_PublishAndConsume(t *testing.T) {
+	q := queue.NewChannelQueue(1, 10*time.Millisecond)
+	job := queue.TransferJob{TransactionId: uuid.New(), Amount: 10}
+
+	require.NoError(t, q.Publish(context.Background(), job))
+
+	require.Equal(t, job, consumeOne(t, q))
+}
+
+func TestChannelQueue_QueueFull(t *testing.T) {
+	q := queue.NewChannelQueue(1, 10*time.Millisecond)
+
+	require.NoError(t, q.Publish(context.Background(), queue.TransferJob{}))
+
+	start := time.Now()
+	err := q.Publish(context.Background(), queue.TransferJob{})
+	require.ErrorIs(t, err, queue.ErrQueueFull)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestChannelQueue_Depth(t *testing.T) {
+	q := queue.NewChannelQueue(2, 10*time.Millisecond)
+	require.NoError(t, q.Publish(context.Background(), queue.TransferJob{}))
+
+	depth, err := q.Depth(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, depth)
+}
+
+func TestChannelQueue_ContextCancelled(t *testing.T) {
+	q := queue.NewChannelQueue(0, time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := q.Publish(ctx, queue.TransferJob{})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestChannelQueue_ConsumeStopsWithContext(t *testing.T) {
+	q := queue.NewChannelQueue(1, time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- q.Consume(ctx, func(context.Context, queue.TransferJob) error { return nil }, noDeadLetter(t))
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("consumer did not stop")
+	}
+}
+
+// consumeOne runs a consumer until it receives a job and acknowledges it.
+func consumeOne(t *testing.T, consumer queue.Consumer) queue.TransferJob {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	received := make(chan queue.TransferJob, 1)
+	go consumer.Consume(ctx, func(_ context.Context, job queue.TransferJob) error {
+		select {
+		case received <- job:
+		default:
+		}
+		return nil
+	}, noDeadLetter(t))
+
+	select {
+	case job := <-received:
+		return job
+	case <-ctx.Done():
+		t.Fatal("no job consumed")
+		return queue.TransferJob{}
+	}
+}
+
+// noDeadLetter fails the test if the consumer gives up on a job.
+func noDeadLetter(t *testing.T) queue.DeadLetterHandler {
+	return func(_ context.Context, job queue.TransferJob, reason string) error {
+		t.Errorf("job %s was dead-lettered: %s", job.TransactionId, reason)
+		return nil
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
 	"moneyTransfer/internal/domain/model"
 	"moneyTransfer/internal/queue"
 	"moneyTransfer/tests"
@@ -22,17 +23,17 @@ func TestProcessJob_InvalidAmount(t *testing.T) {
 		TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552"),
 	}
 
-	transferRepo.On("UpdateTransactionStatus", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
+	transferRepo.On("FinishTransaction", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
 	logger.On("Error", "amount must be greater than zero", "amount", job.Amount).Return()
 
-	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, logger)
+	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, tests.PassthroughTxManager{}, logger)
 
 	require.NoError(t, err)
-	transferRepo.AssertCalled(t, "UpdateTransactionStatus", ctx, job.TransactionId.String(), model.StatusFailed)
+	transferRepo.AssertCalled(t, "FinishTransaction", ctx, job.TransactionId.String(), model.StatusFailed)
 	logger.AssertExpectations(t)
 }
 
-func TestProcessJob_FailedToGetSenderBalance(t *testing.T) {
+func TestProcessJob_UnknownSender(t *testing.T) {
 	ctx, userRepo, transferRepo, logger := initWorker()
 
 	job := queue.TransferJob{
@@ -42,19 +43,21 @@ func TestProcessJob_FailedToGetSenderBalance(t *testing.T) {
 		TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552"),
 	}
 
-	userRepo.On("GetBalance", ctx, job.SenderId.String()).Return(0.0, errors.New("db error"))
-	transferRepo.On("UpdateTransactionStatus", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
+	userRepo.On("Debit", ctx, job.SenderId.String(), job.Amount).
+		Return(0.0, 0.0, apperrors.NotFound(apperrors.CodeUserNotFound, "user not found"))
+	transferRepo.On("FinishTransaction", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
 	logger.On("Error", "failed to get sender balance", "error", mock.Anything).Return()
 
-	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, logger)
+	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, tests.PassthroughTxManager{}, logger)
 
 	require.NoError(t, err)
+	userRepo.AssertNotCalled(t, "Credit", mock.Anything, mock.Anything, mock.Anything)
 	userRepo.AssertExpectations(t)
 	transferRepo.AssertExpectations(t)
 	logger.AssertExpectations(t)
 }
 
-func TestProcessJob_FailedToGetReceiverBalance(t *testing.T) {
+func TestProcessJob_UnknownReceiver(t *testing.T) {
 	ctx, userRepo, transferRepo, logger := initWorker()
 
 	job := queue.TransferJob{
@@ -64,12 +67,13 @@ func TestProcessJob_FailedToGetReceiverBalance(t *testing.T) {
 		TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552"),
 	}
 
-	userRepo.On("GetBalance", ctx, job.SenderId.String()).Return(100.0, nil)
-	userRepo.On("GetBalance", ctx, job.ReceiverId.String()).Return(0.0, errors.New("db error"))
-	transferRepo.On("UpdateTransactionStatus", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
+	userRepo.On("Debit", ctx, job.SenderId.String(), job.Amount).Return(100.0, 20.0, nil)
+	userRepo.On("Credit", ctx, job.ReceiverId.String(), job.Amount).
+		Return(0.0, 0.0, apperrors.NotFound(apperrors.CodeUserNotFound, "user not found"))
+	transferRepo.On("FinishTransaction", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
 	logger.On("Error", "failed to get receiver balance", "error", mock.Anything).Return()
 
-	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, logger)
+	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, tests.PassthroughTxManager{}, logger)
 
 	require.NoError(t, err)
 	userRepo.AssertExpectations(t)
@@ -87,15 +91,15 @@ func TestProcessJob_InsufficientFunds(t *testing.T) {
 		TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552"),
 	}
 
-	userRepo.On("GetBalance", ctx, job.SenderId.String()).Return(50.0, nil)
-	transferRepo.On("UpdateTransactionStatus", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
-	logger.On("Error", "insufficient funds", "balance", 50.0, "amount", job.Amount).Return()
+	userRepo.On("Debit", ctx, job.SenderId.String(), job.Amount).Return(0.0, 0.0, apperrors.InsufficientFunds("insufficient funds"))
+	transferRepo.On("FinishTransaction", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
+	logger.On("Error", "insufficient funds", "amount", job.Amount).Return()
 
-	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, logger)
+	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, tests.PassthroughTxManager{}, logger)
 
 	require.NoError(t, err)
-	userRepo.AssertCalled(t, "GetBalance", ctx, job.SenderId.String())
-	transferRepo.AssertCalled(t, "UpdateTransactionStatus", ctx, job.TransactionId.String(), model.StatusFailed)
+	userRepo.AssertNotCalled(t, "Credit", mock.Anything, mock.Anything, mock.Anything)
+	transferRepo.AssertCalled(t, "FinishTransaction", ctx, job.TransactionId.String(), model.StatusFailed)
 	logger.AssertExpectations(t)
 }
 
@@ -109,13 +113,11 @@ func TestProcessJob_FailedToUpdateSenderBalance(t *testing.T) {
 		TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552"),
 	}
 
-	userRepo.On("GetBalance", ctx, job.SenderId.String()).Return(100.0, nil)
-	userRepo.On("GetBalance", ctx, job.ReceiverId.String()).Return(50.0, nil)
-	userRepo.On("UpdateBalance", ctx, job.SenderId.String(), 20.0).Return(errors.New("update failed"))
-	transferRepo.On("UpdateTransactionStatus", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
+	userRepo.On("Debit", ctx, job.SenderId.String(), job.Amount).Return(0.0, 0.0, errors.New("update failed"))
+	transferRepo.On("FinishTransaction", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
 	logger.On("Error", "failed to update sender balance", "error", mock.Anything).Return()
 
-	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, logger)
+	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, tests.PassthroughTxManager{}, logger)
 
 	require.NoError(t, err)
 	userRepo.AssertExpectations(t)
@@ -133,15 +135,13 @@ func TestProcessJob_FailedToUpdateReceiverBalance(t *testing.T) {
 		TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552"),
 	}
 
-	userRepo.On("GetBalance", ctx, job.SenderId.String()).Return(100.0, nil)
-	userRepo.On("GetBalance", ctx, job.ReceiverId.String()).Return(50.0, nil)
-	userRepo.On("UpdateBalance", ctx, job.SenderId.String(), 20.0).Return(nil)
-	userRepo.On("UpdateBalance", ctx, job.ReceiverId.String(), 130.0).Return(errors.New("update failed"))
+	userRepo.On("Debit", ctx, job.SenderId.String(), job.Amount).Return(100.0, 20.0, nil)
+	userRepo.On("Credit", ctx, job.ReceiverId.String(), job.Amount).Return(0.0, 0.0, errors.New("update failed"))
 
-	transferRepo.On("UpdateTransactionStatus", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
+	transferRepo.On("FinishTransaction", ctx, job.TransactionId.String(), model.StatusFailed).Return(nil)
 	logger.On("Error", "failed to update receiver balance", "error", mock.Anything).Return()
 
-	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, logger)
+	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, tests.PassthroughTxManager{}, logger)
 
 	require.NoError(t, err)
 	userRepo.AssertExpectations(t)
@@ -149,7 +149,7 @@ func TestProcessJob_FailedToUpdateReceiverBalance(t *testing.T) {
 	logger.AssertExpectations(t)
 }
 
-func TestProcessJob_FailedToUpdateTransactionStatusSuccess(t *testing.T) {
+func TestProcessJob_FailedToFinishTransactionSuccess(t *testing.T) {
 	ctx, userRepo, transferRepo, logger := initWorker()
 
 	job := queue.TransferJob{
@@ -159,17 +159,15 @@ func TestProcessJob_FailedToUpdateTransactionStatusSuccess(t *testing.T) {
 		TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552"),
 	}
 
-	userRepo.On("GetBalance", ctx, job.SenderId.String()).Return(100.0, nil)
-	userRepo.On("GetBalance", ctx, job.ReceiverId.String()).Return(50.0, nil)
-	userRepo.On("UpdateBalance", ctx, job.SenderId.String(), 20.0).Return(nil)
-	userRepo.On("UpdateBalance", ctx, job.ReceiverId.String(), 130.0).Return(nil)
+	userRepo.On("Debit", ctx, job.SenderId.String(), job.Amount).Return(100.0, 20.0, nil)
+	userRepo.On("Credit", ctx, job.ReceiverId.String(), job.Amount).Return(50.0, 130.0, nil)
 
-	transferRepo.On("UpdateTransactionStatus", ctx, job.TransactionId.String(), model.StatusSuccess).
+	transferRepo.On("FinishTransaction", ctx, job.TransactionId.String(), model.StatusSuccess).
 		Return(errors.New("update status failed"))
 
 	logger.On("Error", "failed to update transaction status", "error", mock.Anything).Return()
 
-	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, logger)
+	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, tests.PassthroughTxManager{}, logger)
 
 	require.Error(t, err)
 	userRepo.AssertExpectations(t)
@@ -177,10 +175,221 @@ func TestProcessJob_FailedToUpdateTransactionStatusSuccess(t *testing.T) {
 	logger.AssertExpectations(t)
 }
 
+func TestProcessJob_AlreadySettledRollsBack(t *testing.T) {
+	ctx, userRepo, transferRepo, logger := initWorker()
+
+	job := queue.TransferJob{
+		Amount:        80,
+		SenderId:      uuid.MustParse("d489b057-aa2e-4d34-9020-d2b42294dc42"),
+		ReceiverId:    uuid.MustParse("ed9c2b61-3908-413b-b355-a6c36d1a0cb3"),
+		TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552"),
+	}
+
+	userRepo.On("Debit", ctx, job.SenderId.String(), job.Amount).Return(100.0, 20.0, nil)
+	userRepo.On("Credit", ctx, job.ReceiverId.String(), job.Amount).Return(0.0, 80.0, nil)
+	transferRepo.On("FinishTransaction", ctx, job.TransactionId.String(), model.StatusSuccess).
+		Return(apperrors.Conflict(apperrors.CodeTransactionSettled, "transaction is no longer pending"))
+	logger.On("Info", "transfer already processed", "transaction_id", job.TransactionId).Return()
+
+	rolledBack := false
+	txManager := rollbackTxManager{rolledBack: &rolledBack}
+	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, txManager, logger)
+
+	require.NoError(t, err)
+	require.True(t, rolledBack, "the debit and credit must not commit")
+	transferRepo.AssertNotCalled(t, "FinishTransaction", mock.Anything, mock.Anything, model.StatusFailed)
+	logger.AssertExpectations(t)
+}
+
+// rollbackTxManager records whether the callback failed, i.e. whether a real
+// transaction would have rolled back.
+type rollbackTxManager struct {
+	rolledBack *bool
+}
+
+func (m rollbackTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(ctx)
+	*m.rolledBack = err != nil
+	return err
+}
+
+func TestWorker_Handle_SkipsProcessedTransaction(t *testing.T) {
+	ctx, userRepo, transferRepo, logger := initWorker()
+	worker := queue.NewWorker(nil, userRepo, transferRepo, tests.PassthroughTxManager{}, queue.NewCompletions(), 0, logger)
+
+	job := queue.TransferJob{Amount: 80, TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552")}
+
+	transferRepo.On("GetTransactionById", mock.Anything, job.TransactionId.String()).
+		Return(model.Transaction{Id: job.TransactionId, Status: model.StatusSuccess}, nil)
+	logger.On("Info", "skipping already processed job", "transaction_id", job.TransactionId, "status", model.StatusSuccess, "user_id", job.SenderId).Return()
+
+	require.NoError(t, worker.Handle(ctx, job))
+
+	userRepo.AssertNotCalled(t, "Debit", mock.Anything, mock.Anything, mock.Anything)
+	transferRepo.AssertNotCalled(t, "FinishTransaction", mock.Anything, mock.Anything, mock.Anything)
+	logger.AssertExpectations(t)
+}
+
+func TestWorker_Handle_LogsUnderRequestId(t *testing.T) {
+	ctx, userRepo, transferRepo, logger := initWorker()
+	worker := queue.NewWorker(nil, userRepo, transferRepo, tests.PassthroughTxManager{}, queue.NewCompletions(), 0, logger)
+
+	job := queue.TransferJob{SenderId: uuid.New(), TransactionId: uuid.New(), RequestId: "req-1"}
+
+	transferRepo.On("GetTransactionById", mock.Anything, job.TransactionId.String()).
+		Return(model.Transaction{Id: job.TransactionId, Status: model.StatusFailed}, nil)
+	logger.On("Info", "skipping already processed job", "transaction_id", job.TransactionId, "status", model.StatusFailed,
+		"user_id", job.SenderId, "request_id", "req-1").Return()
+
+	require.NoError(t, worker.Handle(ctx, job))
+	logger.AssertExpectations(t)
+}
+
+func TestWorker_Handle_SkipsUnknownTransaction(t *testing.T) {
+	ctx, userRepo, transferRepo, logger := initWorker()
+	worker := queue.NewWorker(nil, userRepo, transferRepo, tests.PassthroughTxManager{}, queue.NewCompletions(), 0, logger)
+
+	job := queue.TransferJob{Amount: 80, TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552")}
+
+	transferRepo.On("GetTransactionById", mock.Anything, job.TransactionId.String()).
+		Return(model.Transaction{}, apperrors.NotFound(apperrors.CodeTransactionNotFound, "transaction not found"))
+	logger.On("Warn", "skipping job for unknown transaction", "transaction_id", job.TransactionId, "user_id", job.SenderId).Return()
+
+	require.NoError(t, worker.Handle(ctx, job))
+	logger.AssertExpectations(t)
+}
+
+func TestWorker_Handle_ProcessesPendingTransaction(t *testing.T) {
+	ctx, userRepo, transferRepo, logger := initWorker()
+	worker := queue.NewWorker(nil, userRepo, transferRepo, tests.PassthroughTxManager{}, queue.NewCompletions(), 0, logger)
+
+	job := queue.TransferJob{
+		Amount:        80,
+		SenderId:      uuid.MustParse("d489b057-aa2e-4d34-9020-d2b42294dc42"),
+		ReceiverId:    uuid.MustParse("ed9c2b61-3908-413b-b355-a6c36d1a0cb3"),
+		TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552"),
+	}
+
+	transferRepo.On("GetTransactionById", mock.Anything, job.TransactionId.String()).
+		Return(model.Transaction{Id: job.TransactionId, Status: model.StatusPending}, nil)
+	userRepo.On("Debit", mock.Anything, job.SenderId.String(), job.Amount).Return(100.0, 20.0, nil)
+	userRepo.On("Credit", mock.Anything, job.ReceiverId.String(), job.Amount).Return(0.0, 80.0, nil)
+	transferRepo.On("FinishTransaction", mock.Anything, job.TransactionId.String(), model.StatusSuccess).Return(nil)
+	logger.On("Info", "transfer completed", "transaction_id", job.TransactionId, "user_id", job.SenderId).Return()
+
+	require.NoError(t, worker.Handle(ctx, job))
+
+	userRepo.AssertExpectations(t)
+	transferRepo.AssertExpectations(t)
+}
+
+func TestProcessJob_ConcurrentUpdateIsRetried(t *testing.T) {
+	ctx, userRepo, transferRepo, logger := initWorker()
+
+	job := queue.TransferJob{
+		Amount:        80,
+		SenderId:      uuid.MustParse("ed9c2b61-3908-413b-b355-a6c36d1a0cb3"),
+		ReceiverId:    uuid.MustParse("eeb552ab-bea8-4183-8f62-9e4fe9281759"),
+		TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552"),
+	}
+
+	aborted := apperrors.Unavailable(apperrors.CodeConcurrentUpdate, "transaction aborted by a concurrent update, retry it", 0)
+	userRepo.On("Debit", ctx, job.SenderId.String(), job.Amount).Return(100.0, 20.0, nil)
+	userRepo.On("Credit", ctx, job.ReceiverId.String(), job.Amount).Return(0.0, 0.0, aborted)
+	logger.On("Error", "failed to update receiver balance", "error", mock.Anything).Return()
+	logger.On("Warn", "transfer aborted by a concurrent update, retrying", "transaction_id", job.TransactionId, "error", mock.Anything).Return()
+
+	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, tests.PassthroughTxManager{}, logger)
+
+	require.ErrorIs(t, err, apperrors.ErrUnavailable, "the job is delivered again")
+	transferRepo.AssertNotCalled(t, "FinishTransaction", mock.Anything, mock.Anything, mock.Anything)
+	logger.AssertExpectations(t)
+}
+
+func TestProcessJob_LocksBothAccountsFirst(t *testing.T) {
+	ctx, _, transferRepo, logger := initWorker()
+	userRepo := new(tests.MockUserRepo)
+
+	job := queue.TransferJob{
+		Amount:        80,
+		SenderId:      uuid.MustParse("ed9c2b61-3908-413b-b355-a6c36d1a0cb3"),
+		ReceiverId:    uuid.MustParse("eeb552ab-bea8-4183-8f62-9e4fe9281759"),
+		TransactionId: uuid.MustParse("f5c184f5-38f1-46d0-b9c4-47da6ad55552"),
+	}
+
+	var calls []string
+	userRepo.On("Lock", ctx, []string{job.SenderId.String(), job.ReceiverId.String()}).
+		Run(func(mock.Arguments) { calls = append(calls, "Lock") }).Return(nil)
+	userRepo.On("Debit", ctx, job.SenderId.String(), job.Amount).
+		Run(func(mock.Arguments) { calls = append(calls, "Debit") }).Return(100.0, 20.0, nil)
+	userRepo.On("Credit", ctx, job.ReceiverId.String(), job.Amount).
+		Run(func(mock.Arguments) { calls = append(calls, "Credit") }).Return(0.0, 80.0, nil)
+	transferRepo.On("FinishTransaction", ctx, job.TransactionId.String(), model.StatusSuccess).Return(nil)
+	logger.On("Info", "transfer completed", "transaction_id", job.TransactionId).Return()
+
+	err := queue.ProcessJob(ctx, job, userRepo, transferRepo, tests.PassthroughTxManager{}, logger)
+
+	require.NoError(t, err)
+	require.Equal(t, []string{"Lock", "Debit", "Credit"}, calls)
+}
+
 func initWorker() (context.Context, *tests.MockUserRepo, *tests.MockTransferRepo, *tests.MockLogger) {
 	ctx := context.Background()
 	userRepo := new(tests.MockUserRepo)
+	userRepo.On("Lock", mock.Anything, mock.Anything).Return(nil).Maybe()
 	transferRepo := new(tests.MockTransferRepo)
 	logger := new(tests.MockLogger)
 	return ctx, userRepo, transferRepo, logger
 }
+
+func TestWorker_DeadLetter_FailsPendingTransaction(t *testing.T) {
+	ctx, userRepo, transferRepo, logger := initWorker()
+	completions := queue.NewCompletions()
+	worker := queue.NewWorker(nil, userRepo, transferRepo, tests.PassthroughTxManager{}, completions, 0, logger)
+
+	job := queue.TransferJob{Amount: 80, SenderId: uuid.New(), TransactionId: uuid.New()}
+	done, stop := completions.Wait(job.TransactionId)
+	defer stop()
+
+	transferRepo.On("FinishTransaction", mock.Anything, job.TransactionId.String(), model.StatusFailed).Return(nil)
+	logger.On("Warn", "transfer failed after its job was dead-lettered", "transaction_id", job.TransactionId,
+		"reason", "poison job", "user_id", job.SenderId).Return()
+
+	require.NoError(t, worker.DeadLetter(ctx, job, "poison job"))
+
+	transferRepo.AssertExpectations(t)
+	logger.AssertExpectations(t)
+	select {
+	case <-done:
+	default:
+		t.Fatal("waiters were not told the transfer finished")
+	}
+}
+
+func TestWorker_DeadLetter_LeavesSettledTransaction(t *testing.T) {
+	ctx, userRepo, transferRepo, logger := initWorker()
+	worker := queue.NewWorker(nil, userRepo, transferRepo, tests.PassthroughTxManager{}, queue.NewCompletions(), 0, logger)
+
+	job := queue.TransferJob{Amount: 80, TransactionId: uuid.New()}
+
+	transferRepo.On("FinishTransaction", mock.Anything, job.TransactionId.String(), model.StatusFailed).
+		Return(apperrors.Conflict(apperrors.CodeTransactionSettled, "transaction is no longer pending"))
+
+	require.NoError(t, worker.DeadLetter(ctx, job, "worker did not report back"))
+	logger.AssertNotCalled(t, "Warn", mock.Anything, mock.Anything)
+}
+
+func TestWorker_DeadLetter_ReportsFailureToFinish(t *testing.T) {
+	ctx, userRepo, transferRepo, logger := initWorker()
+	worker := queue.NewWorker(nil, userRepo, transferRepo, tests.PassthroughTxManager{}, queue.NewCompletions(), 0, logger)
+
+	job := queue.TransferJob{Amount: 80, TransactionId: uuid.New()}
+	failure := errors.New("db down")
+
+	transferRepo.On("FinishTransaction", mock.Anything, job.TransactionId.String(), model.StatusFailed).Return(failure)
+	logger.On("Error", "failed to mark dead-lettered transfer failed", "transaction_id", job.TransactionId, "error", failure,
+		"user_id", job.SenderId).Return()
+
+	require.ErrorIs(t, worker.DeadLetter(ctx, job, "poison job"), failure)
+	logger.AssertExpectations(t)
+}
@@ -0,0 +1,83 @@
+package ratelimit_tests
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/pkg/ratelimit"
+	"testing"
+	"time"
+)
+
+func TestLimiter_AllowsBurstThenRejects(t *testing.T) {
+	limiter, _ := initLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		result := limiter.Allow("client")
+		require.True(t, result.Allowed)
+		assert.Equal(t, 2-i, result.Remaining)
+	}
+
+	result := limiter.Allow("client")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.Reset)
+}
+
+func TestLimiter_RefillsOverTime(t *testing.T) {
+	limiter, clock := initLimiter(2, 2)
+
+	limiter.Allow("client")
+	limiter.Allow("client")
+	require.False(t, limiter.Allow("client").Allowed)
+
+	*clock = clock.Add(500 * time.Millisecond)
+
+	result := limiter.Allow("client")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
+
+func TestLimiter_KeysAreIndependent(t *testing.T) {
+	limiter, _ := initLimiter(1, 1)
+
+	require.True(t, limiter.Allow("a").Allowed)
+	require.False(t, limiter.Allow("a").Allowed)
+	assert.True(t, limiter.Allow("b").Allowed)
+}
+
+func TestLimiter_NeverExceedsBurst(t *testing.T) {
+	limiter, clock := initLimiter(10, 2)
+
+	limiter.Allow("client")
+	*clock = clock.Add(time.Hour)
+
+	result := limiter.Allow("client")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+}
+
+func initLimiter(rate float64, burst int) (*ratelimit.Limiter, *time.Time) {
+	clock := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := ratelimit.NewLimiter(rate, burst)
+	limiter.Now = func() time.Time { return clock }
+	return limiter, &clock
+}
+
+func TestAllowAll_TakesNothingWhenOneBucketIsEmpty(t *testing.T) {
+	client, _ := initLimiter(1, 5)
+	account, _ := initLimiter(1, 1)
+
+	results := ratelimit.AllowAll(ratelimit.Take{Limiter: client, Key: "client"}, ratelimit.Take{Limiter: account, Key: "alice"})
+	require.True(t, results[0].Allowed)
+	require.True(t, results[1].Allowed)
+
+	results = ratelimit.AllowAll(ratelimit.Take{Limiter: client, Key: "client"}, ratelimit.Take{Limiter: account, Key: "alice"})
+	assert.False(t, results[0].Allowed)
+	assert.False(t, results[1].Allowed)
+	assert.Equal(t, 4, results[0].Remaining, "the refused request leaves the client bucket untouched")
+	assert.Zero(t, results[0].RetryAfter)
+	assert.Equal(t, time.Second, results[1].RetryAfter)
+
+	assert.Equal(t, 3, client.Allow("client").Remaining)
+}
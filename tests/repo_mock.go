@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/stretchr/testify/mock"
 	"moneyTransfer/internal/domain/model"
+	"time"
 )
 
 type MockUserRepo struct {
@@ -25,6 +26,21 @@ func (m *MockUserRepo) UpdateBalance(ctx context.Context, userId string, newBala
 	return args.Error(0)
 }
 
+func (m *MockUserRepo) Debit(ctx context.Context, userId string, amount float64) (float64, float64, error) {
+	args := m.Called(ctx, userId, amount)
+	return args.Get(0).(float64), args.Get(1).(float64), args.Error(2)
+}
+
+func (m *MockUserRepo) Credit(ctx context.Context, userId string, amount float64) (float64, float64, error) {
+	args := m.Called(ctx, userId, amount)
+	return args.Get(0).(float64), args.Get(1).(float64), args.Error(2)
+}
+
+func (m *MockUserRepo) Lock(ctx context.Context, userIds ...string) error {
+	args := m.Called(ctx, userIds)
+	return args.Error(0)
+}
+
 type MockTransferRepo struct {
 	mock.Mock
 }
@@ -34,12 +50,179 @@ func (m *MockTransferRepo) GetTransactionsByUserId(ctx context.Context, userId s
 	return args.Get(0).([]model.Transaction), args.Error(1)
 }
 
+func (m *MockTransferRepo) GetTransactionById(ctx context.Context, txId string) (model.Transaction, error) {
+	args := m.Called(ctx, txId)
+	return args.Get(0).(model.Transaction), args.Error(1)
+}
+
 func (m *MockTransferRepo) CreateTransfer(ctx context.Context, tx model.Transaction) error {
 	args := m.Called(ctx, tx)
 	return args.Error(0)
 }
 
-func (m *MockTransferRepo) UpdateTransactionStatus(ctx context.Context, txId, status string) error {
+func (m *MockTransferRepo) FinishTransaction(ctx context.Context, txId, status string) error {
 	args := m.Called(ctx, txId, status)
 	return args.Error(0)
 }
+
+func (m *MockTransferRepo) DeletePendingTransaction(ctx context.Context, txId string) error {
+	args := m.Called(ctx, txId)
+	return args.Error(0)
+}
+
+type MockAPIKeyRepo struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepo) Create(ctx context.Context, key model.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) GetById(ctx context.Context, id string) (model.APIKey, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) GetByHash(ctx context.Context, keyHash string) (model.APIKey, error) {
+	args := m.Called(ctx, keyHash)
+	return args.Get(0).(model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) List(ctx context.Context) ([]model.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) Revoke(ctx context.Context, id string, revokedAt time.Time) error {
+	args := m.Called(ctx, id, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) UpdateLastUsed(ctx context.Context, id string, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
+
+type MockAuditRepo struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepo) Append(ctx context.Context, entry model.AuditEntry) (model.AuditEntry, error) {
+	args := m.Called(ctx, entry)
+	return args.Get(0).(model.AuditEntry), args.Error(1)
+}
+
+func (m *MockAuditRepo) List(ctx context.Context, afterId int64, limit int) ([]model.AuditEntry, error) {
+	args := m.Called(ctx, afterId, limit)
+	return args.Get(0).([]model.AuditEntry), args.Error(1)
+}
+
+// PassthroughTxManager runs the callback directly, for tests that do not
+// exercise a real database transaction.
+type PassthroughTxManager struct{}
+
+func (PassthroughTxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type MockLedgerRepo struct {
+	mock.Mock
+}
+
+func (m *MockLedgerRepo) GetAccountLedgers(ctx context.Context) ([]model.AccountLedger, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.AccountLedger), args.Error(1)
+}
+
+type MockOutboxRepo struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepo) Append(ctx context.Context, event model.DomainEvent) (model.DomainEvent, error) {
+	args := m.Called(ctx, event)
+	return args.Get(0).(model.DomainEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepo) Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.DomainEvent, error) {
+	args := m.Called(ctx, now, leaseUntil, limit)
+	return args.Get(0).([]model.DomainEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepo) Pending(ctx context.Context, limit int) ([]model.DomainEvent, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]model.DomainEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepo) MarkPublished(ctx context.Context, sequence int64, publishedAt time.Time) error {
+	args := m.Called(ctx, sequence, publishedAt)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepo) Release(ctx context.Context, accountId string, notBefore time.Time) error {
+	args := m.Called(ctx, accountId, notBefore)
+	return args.Error(0)
+}
+
+type MockWebhookRepo struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepo) Create(ctx context.Context, webhook model.Webhook) error {
+	args := m.Called(ctx, webhook)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) GetById(ctx context.Context, id string) (model.Webhook, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepo) List(ctx context.Context) ([]model.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepo) ListByOwner(ctx context.Context, ownerId string) ([]model.Webhook, error) {
+	args := m.Called(ctx, ownerId)
+	return args.Get(0).([]model.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookRepo) Reassign(ctx context.Context, fromOwnerId, toOwnerId string) error {
+	args := m.Called(ctx, fromOwnerId, toOwnerId)
+	return args.Error(0)
+}
+
+func (m *MockWebhookRepo) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+type MockWebhookDeliveryRepo struct {
+	mock.Mock
+}
+
+func (m *MockWebhookDeliveryRepo) Create(ctx context.Context, delivery model.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
+
+func (m *MockWebhookDeliveryRepo) GetById(ctx context.Context, id string) (model.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepo) ListByWebhook(ctx context.Context, webhookId string, limit int) ([]model.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookId, limit)
+	return args.Get(0).([]model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepo) Claim(ctx context.Context, now, leaseUntil time.Time, limit int) ([]model.WebhookDelivery, error) {
+	args := m.Called(ctx, now, leaseUntil, limit)
+	return args.Get(0).([]model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookDeliveryRepo) Update(ctx context.Context, delivery model.WebhookDelivery) error {
+	args := m.Called(ctx, delivery)
+	return args.Error(0)
+}
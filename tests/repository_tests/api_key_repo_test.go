@@ -0,0 +1,168 @@
+package repository_tests
+
+import (
+	"context"
+	"database/sql"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/tests"
+	"testing"
+	"time"
+)
+
+var apiKeyRowColumns = []string{"id", "name", "prefix", "key_hash", "scopes", "created_at", "last_used_at", "revoked_at"}
+
+func TestAPIKeyRepo_Create_Success(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewAPIKeyRepository(db)
+
+	key := model.APIKey{
+		Id:        uuid.MustParse("7141b92f-a8c8-471e-83e5-7fc72da61cb9"),
+		Name:      "payments",
+		Prefix:    "mtk_abcdefgh",
+		KeyHash:   "hash",
+		Scopes:    []string{model.ScopeTransfersWrite, model.ScopeBalancesRead},
+		CreatedAt: time.Now(),
+	}
+
+	mock.ExpectExec(`INSERT INTO api_keys \(id, name, prefix, key_hash, scopes, created_at\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6\)`).
+		WithArgs(key.Id, key.Name, key.Prefix, key.KeyHash, "transfers:write balances:read", key.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.Create(context.Background(), key)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepo_GetByHash_Success(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewAPIKeyRepository(db)
+
+	id := uuid.MustParse("7141b92f-a8c8-471e-83e5-7fc72da61cb9")
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	usedAt := createdAt.Add(time.Hour)
+
+	mock.ExpectQuery(`SELECT id, name, prefix, key_hash, scopes, created_at, last_used_at, revoked_at FROM api_keys WHERE key_hash = \$1`).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(apiKeyRowColumns).
+			AddRow(id, "payments", "mtk_abcdefgh", "hash", "transfers:write", createdAt, usedAt, nil))
+
+	key, err := repo.GetByHash(context.Background(), "hash")
+	require.NoError(t, err)
+	require.Equal(t, model.APIKey{
+		Id:         id,
+		Name:       "payments",
+		Prefix:     "mtk_abcdefgh",
+		KeyHash:    "hash",
+		Scopes:     []string{model.ScopeTransfersWrite},
+		CreatedAt:  createdAt,
+		LastUsedAt: &usedAt,
+	}, key)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepo_GetByHash_Error(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewAPIKeyRepository(db)
+
+	mock.ExpectQuery(`SELECT .* FROM api_keys WHERE key_hash = \$1`).
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.GetByHash(context.Background(), "missing")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepo_List_Success(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewAPIKeyRepository(db)
+
+	revokedAt := time.Now()
+	mock.ExpectQuery(`SELECT .* FROM api_keys ORDER BY created_at`).
+		WillReturnRows(sqlmock.NewRows(apiKeyRowColumns).
+			AddRow(uuid.New(), "a", "mtk_a", "h1", "admin", time.Now(), nil, nil).
+			AddRow(uuid.New(), "b", "mtk_b", "h2", "balances:read", time.Now(), nil, revokedAt))
+
+	keys, err := repo.List(context.Background())
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	require.False(t, keys[0].IsRevoked())
+	require.True(t, keys[1].IsRevoked())
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepo_Revoke_Success(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewAPIKeyRepository(db)
+
+	revokedAt := time.Now()
+	mock.ExpectExec(`UPDATE api_keys SET revoked_at = \$1 WHERE id = \$2 AND revoked_at IS NULL`).
+		WithArgs(revokedAt, "key-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.Revoke(context.Background(), "key-id", revokedAt)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepo_Revoke_NotFound(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewAPIKeyRepository(db)
+
+	revokedAt := time.Now()
+	mock.ExpectExec(`UPDATE api_keys SET revoked_at = \$1 WHERE id = \$2 AND revoked_at IS NULL`).
+		WithArgs(revokedAt, "missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT .* FROM api_keys WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	err := repo.Revoke(context.Background(), "missing", revokedAt)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepo_Revoke_AlreadyRevoked(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewAPIKeyRepository(db)
+
+	revokedAt := time.Now()
+	mock.ExpectExec(`UPDATE api_keys SET revoked_at = \$1 WHERE id = \$2 AND revoked_at IS NULL`).
+		WithArgs(revokedAt, "key-id").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT .* FROM api_keys WHERE id = \$1`).
+		WithArgs("key-id").
+		WillReturnRows(sqlmock.NewRows(apiKeyRowColumns).
+			AddRow(uuid.New(), "payments", "mtk_abc", "hash", "transfers:write", revokedAt, nil, revokedAt))
+
+	err := repo.Revoke(context.Background(), "key-id", revokedAt)
+	require.ErrorIs(t, err, apperrors.ErrConflict)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyRepo_UpdateLastUsed_Success(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewAPIKeyRepository(db)
+
+	usedAt := time.Now()
+	mock.ExpectExec(`UPDATE api_keys SET last_used_at = \$1 WHERE id = \$2`).
+		WithArgs(usedAt, "key-id").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.UpdateLastUsed(context.Background(), "key-id", usedAt)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
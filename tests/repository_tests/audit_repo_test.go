@@ -0,0 +1,101 @@
+package repository_tests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/tests"
+	"testing"
+	"time"
+)
+
+func TestAuditRepo_Append_ChainsToPreviousEntry(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewAuditRepository(db)
+
+	entry := model.AuditEntry{
+		Actor:      model.ActorWorker,
+		Action:     model.AuditActionBalanceUpdated,
+		EntityType: model.AuditEntityUser,
+		EntityId:   "7141b92f-a8c8-471e-83e5-7fc72da61cb9",
+		Before:     json.RawMessage(`{"balance":100}`),
+		After:      json.RawMessage(`{"balance":90}`),
+		CreatedAt:  time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	expected := entry
+	expected.PrevHash = "previous-hash"
+	expected.Hash = expected.ComputeHash()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("previous-hash"))
+	mock.ExpectQuery(`INSERT INTO audit_log .* RETURNING id`).
+		WithArgs(entry.Actor, entry.Action, entry.EntityType, entry.EntityId,
+			`{"balance":100}`, `{"balance":90}`, entry.CreatedAt, "previous-hash", expected.Hash).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+	mock.ExpectCommit()
+
+	appended, err := repo.Append(context.Background(), entry)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), appended.Id)
+	require.Equal(t, "previous-hash", appended.PrevHash)
+	require.Equal(t, expected.Hash, appended.Hash)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditRepo_Append_FirstEntry(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewAuditRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT hash FROM audit_log`).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(`INSERT INTO audit_log`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	appended, err := repo.Append(context.Background(), model.AuditEntry{Action: model.AuditActionAPIKeyCreated})
+	require.NoError(t, err)
+	require.Equal(t, "", appended.PrevHash)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditRepo_Append_RollsBackOnError(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewAuditRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT hash FROM audit_log`).WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	_, err := repo.Append(context.Background(), model.AuditEntry{})
+	require.ErrorIs(t, err, sql.ErrConnDone)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAuditRepo_List_Success(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewAuditRepository(db)
+
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT id, actor, action, entity_type, entity_id, before_value, after_value, created_at, prev_hash, hash\s+FROM audit_log WHERE id > \$1 ORDER BY id LIMIT \$2`).
+		WithArgs(int64(10), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "actor", "action", "entity_type", "entity_id", "before_value", "after_value", "created_at", "prev_hash", "hash"}).
+			AddRow(11, "system", model.AuditActionTransactionCreated, model.AuditEntityTransaction, "tx", nil, `{"status":"PENDING"}`, createdAt, "h10", "h11"))
+
+	entries, err := repo.List(context.Background(), 10, 2)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Nil(t, entries[0].Before)
+	require.JSONEq(t, `{"status":"PENDING"}`, string(entries[0].After))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
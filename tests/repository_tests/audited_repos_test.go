@@ -0,0 +1,146 @@
+package repository_tests
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/tests"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditedUserRepo_UpdateBalance_RecordsBeforeAndAfter(t *testing.T) {
+	ctx := model.WithActor(context.Background(), model.ActorWorker)
+	inner, audit := new(tests.MockUserRepo), new(tests.MockAuditRepo)
+	repo := repository.NewAuditedUserRepository(inner, audit, tests.PassthroughTxManager{})
+
+	userId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+	inner.On("GetBalance", ctx, userId).Return(100.0, nil)
+	inner.On("UpdateBalance", ctx, userId, 90.0).Return(nil)
+	audit.On("Append", ctx, mock.MatchedBy(func(e model.AuditEntry) bool {
+		return e.Actor == model.ActorWorker &&
+			e.Action == model.AuditActionBalanceUpdated &&
+			e.EntityId == userId &&
+			string(e.Before) == `{"balance":100}` &&
+			string(e.After) == `{"balance":90}`
+	})).Return(model.AuditEntry{}, nil)
+
+	err := repo.UpdateBalance(ctx, userId, 90)
+	require.NoError(t, err)
+
+	inner.AssertExpectations(t)
+	audit.AssertExpectations(t)
+}
+
+func TestAuditedUserRepo_UpdateBalance_AuditFailureFailsWrite(t *testing.T) {
+	ctx := context.Background()
+	inner, audit := new(tests.MockUserRepo), new(tests.MockAuditRepo)
+	repo := repository.NewAuditedUserRepository(inner, audit, tests.PassthroughTxManager{})
+
+	inner.On("GetBalance", ctx, "user").Return(100.0, nil)
+	inner.On("UpdateBalance", ctx, "user", 90.0).Return(nil)
+	audit.On("Append", ctx, mock.Anything).Return(model.AuditEntry{}, errors.New("audit down"))
+
+	err := repo.UpdateBalance(ctx, "user", 90)
+	require.EqualError(t, err, "audit down")
+}
+
+func TestAuditedTransferRepo_FinishTransaction_RecordsStatusChange(t *testing.T) {
+	ctx := context.Background()
+	inner, audit := new(tests.MockTransferRepo), new(tests.MockAuditRepo)
+	repo := repository.NewAuditedTransferRepository(inner, audit, tests.PassthroughTxManager{})
+
+	tx := model.Transaction{Id: uuid.New(), Amount: 10, Status: model.StatusPending}
+	inner.On("GetTransactionById", ctx, tx.Id.String()).Return(tx, nil)
+	inner.On("FinishTransaction", ctx, tx.Id.String(), model.StatusSuccess).Return(nil)
+	audit.On("Append", ctx, mock.MatchedBy(func(e model.AuditEntry) bool {
+		return e.Action == model.AuditActionTransactionStatusChanged &&
+			e.Actor == model.ActorSystem &&
+			strings.Contains(string(e.Before), `"status":"PENDING"`) &&
+			strings.Contains(string(e.After), `"status":"SUCCESS"`)
+	})).Return(model.AuditEntry{}, nil)
+
+	err := repo.FinishTransaction(ctx, tx.Id.String(), model.StatusSuccess)
+	require.NoError(t, err)
+
+	audit.AssertExpectations(t)
+}
+
+func TestAuditedTransferRepo_DeletePendingTransaction_SkipsMissing(t *testing.T) {
+	ctx := context.Background()
+	inner, audit := new(tests.MockTransferRepo), new(tests.MockAuditRepo)
+	repo := repository.NewAuditedTransferRepository(inner, audit, tests.PassthroughTxManager{})
+
+	inner.On("GetTransactionById", ctx, "missing").Return(model.Transaction{}, apperrors.NotFound(apperrors.CodeTransactionNotFound, "transaction not found"))
+
+	err := repo.DeletePendingTransaction(ctx, "missing")
+	require.NoError(t, err)
+
+	inner.AssertNotCalled(t, "DeletePendingTransaction", mock.Anything, mock.Anything)
+	audit.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+}
+
+func TestAuditedAPIKeyRepo_Revoke_RecordsRevocation(t *testing.T) {
+	ctx := model.WithActor(context.Background(), "apikey:admin")
+	inner, audit := new(tests.MockAPIKeyRepo), new(tests.MockAuditRepo)
+	repo := repository.NewAuditedAPIKeyRepository(inner, audit, tests.PassthroughTxManager{})
+
+	key := model.APIKey{Id: uuid.New(), Name: "payments", KeyHash: "secret-hash"}
+	revokedAt := time.Now()
+	inner.On("GetById", ctx, key.Id.String()).Return(key, nil)
+	inner.On("Revoke", ctx, key.Id.String(), revokedAt).Return(nil)
+	audit.On("Append", ctx, mock.MatchedBy(func(e model.AuditEntry) bool {
+		return e.Action == model.AuditActionAPIKeyRevoked &&
+			e.Actor == "apikey:admin" &&
+			!strings.Contains(string(e.After), "secret-hash") &&
+			strings.Contains(string(e.After), "revoked_at")
+	})).Return(model.AuditEntry{}, nil)
+
+	err := repo.Revoke(ctx, key.Id.String(), revokedAt)
+	require.NoError(t, err)
+
+	audit.AssertExpectations(t)
+}
+
+func TestAuditedWebhookRepo_Create_RecordsRegistrationWithoutSecret(t *testing.T) {
+	ctx := model.WithActor(context.Background(), "apikey:integrations")
+	inner, audit := new(tests.MockWebhookRepo), new(tests.MockAuditRepo)
+	repo := repository.NewAuditedWebhookRepository(inner, audit, tests.PassthroughTxManager{})
+
+	webhook := model.Webhook{Id: uuid.New(), OwnerId: uuid.New(), URL: "https://example.com/hook", Secret: "whsec_secret"}
+	inner.On("Create", ctx, webhook).Return(nil)
+	audit.On("Append", ctx, mock.MatchedBy(func(e model.AuditEntry) bool {
+		return e.Action == model.AuditActionWebhookRegistered &&
+			e.EntityId == webhook.Id.String() &&
+			e.Actor == "apikey:integrations" &&
+			strings.Contains(string(e.After), webhook.OwnerId.String()) &&
+			!strings.Contains(string(e.After), "whsec_secret")
+	})).Return(model.AuditEntry{}, nil)
+
+	require.NoError(t, repo.Create(ctx, webhook))
+	audit.AssertExpectations(t)
+}
+
+func TestAuditedWebhookRepo_Delete_RecordsRemovedWebhook(t *testing.T) {
+	ctx := model.WithActor(context.Background(), "apikey:integrations")
+	inner, audit := new(tests.MockWebhookRepo), new(tests.MockAuditRepo)
+	repo := repository.NewAuditedWebhookRepository(inner, audit, tests.PassthroughTxManager{})
+
+	webhook := model.Webhook{Id: uuid.New(), URL: "https://example.com/hook"}
+	inner.On("GetById", ctx, webhook.Id.String()).Return(webhook, nil)
+	inner.On("Delete", ctx, webhook.Id.String()).Return(nil)
+	audit.On("Append", ctx, mock.MatchedBy(func(e model.AuditEntry) bool {
+		return e.Action == model.AuditActionWebhookDeleted &&
+			strings.Contains(string(e.Before), "https://example.com/hook") &&
+			e.After == nil
+	})).Return(model.AuditEntry{}, nil)
+
+	require.NoError(t, repo.Delete(ctx, webhook.Id.String()))
+	audit.AssertExpectations(t)
+}
@@ -0,0 +1,115 @@
+package repository_tests
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/tests"
+	"testing"
+)
+
+func TestEventedUserRepo_UpdateBalance_EmitsBalanceChanged(t *testing.T) {
+	ctx := context.Background()
+	inner, outbox := new(tests.MockUserRepo), new(tests.MockOutboxRepo)
+	repo := repository.NewEventedUserRepository(inner, outbox, tests.PassthroughTxManager{})
+
+	userId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+	inner.On("GetBalance", ctx, userId).Return(100.0, nil)
+	inner.On("UpdateBalance", ctx, userId, 90.0).Return(nil)
+	outbox.On("Append", ctx, mock.MatchedBy(func(e model.DomainEvent) bool {
+		return e.Type == model.EventBalanceChanged &&
+			e.AccountId == userId &&
+			string(e.Payload) == `{"account_id":"`+userId+`","previous_balance":100,"balance":90}`
+	})).Return(model.DomainEvent{}, nil)
+
+	err := repo.UpdateBalance(ctx, userId, 90)
+	require.NoError(t, err)
+
+	inner.AssertExpectations(t)
+	outbox.AssertExpectations(t)
+}
+
+func TestEventedUserRepo_Debit_EmitsBalanceChanged(t *testing.T) {
+	ctx := context.Background()
+	inner, outbox := new(tests.MockUserRepo), new(tests.MockOutboxRepo)
+	repo := repository.NewEventedUserRepository(inner, outbox, tests.PassthroughTxManager{})
+
+	userId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+	inner.On("Debit", ctx, userId, 10.0).Return(100.0, 90.0, nil)
+	outbox.On("Append", ctx, mock.MatchedBy(func(e model.DomainEvent) bool {
+		return e.Type == model.EventBalanceChanged &&
+			string(e.Payload) == `{"account_id":"`+userId+`","previous_balance":100,"balance":90}`
+	})).Return(model.DomainEvent{}, nil)
+
+	previous, balance, err := repo.Debit(ctx, userId, 10)
+	require.NoError(t, err)
+	require.Equal(t, 100.0, previous)
+	require.Equal(t, 90.0, balance)
+
+	inner.AssertExpectations(t)
+	outbox.AssertExpectations(t)
+}
+
+func TestEventedUserRepo_UpdateBalance_OutboxFailureFailsWrite(t *testing.T) {
+	ctx := context.Background()
+	inner, outbox := new(tests.MockUserRepo), new(tests.MockOutboxRepo)
+	repo := repository.NewEventedUserRepository(inner, outbox, tests.PassthroughTxManager{})
+
+	inner.On("GetBalance", ctx, "user").Return(100.0, nil)
+	inner.On("UpdateBalance", ctx, "user", 90.0).Return(nil)
+	outbox.On("Append", ctx, mock.Anything).Return(model.DomainEvent{}, errors.New("outbox down"))
+
+	err := repo.UpdateBalance(ctx, "user", 90)
+	require.EqualError(t, err, "outbox down")
+}
+
+func TestEventedTransferRepo_CreateTransfer_EmitsTransferCreated(t *testing.T) {
+	ctx := context.Background()
+	inner, outbox := new(tests.MockTransferRepo), new(tests.MockOutboxRepo)
+	repo := repository.NewEventedTransferRepository(inner, outbox, tests.PassthroughTxManager{})
+
+	tx := model.Transaction{Id: uuid.New(), SenderId: uuid.New(), ReceiverId: uuid.New(), Amount: 10, Status: model.StatusPending}
+	inner.On("CreateTransfer", ctx, tx).Return(nil)
+	outbox.On("Append", ctx, mock.MatchedBy(func(e model.DomainEvent) bool {
+		return e.Type == model.EventTransferCreated && e.AccountId == tx.SenderId.String()
+	})).Return(model.DomainEvent{}, nil)
+
+	require.NoError(t, repo.CreateTransfer(ctx, tx))
+	outbox.AssertExpectations(t)
+}
+
+func TestEventedTransferRepo_FinishTransaction_EmitsOutcome(t *testing.T) {
+	ctx := context.Background()
+	inner, outbox := new(tests.MockTransferRepo), new(tests.MockOutboxRepo)
+	repo := repository.NewEventedTransferRepository(inner, outbox, tests.PassthroughTxManager{})
+
+	tx := model.Transaction{Id: uuid.New(), SenderId: uuid.New(), ReceiverId: uuid.New(), Amount: 10, Status: model.StatusPending}
+	inner.On("GetTransactionById", ctx, tx.Id.String()).Return(tx, nil)
+	inner.On("FinishTransaction", ctx, tx.Id.String(), model.StatusFailed).Return(nil)
+	outbox.On("Append", ctx, mock.MatchedBy(func(e model.DomainEvent) bool {
+		return e.Type == model.EventTransferFailed
+	})).Return(model.DomainEvent{}, nil)
+
+	require.NoError(t, repo.FinishTransaction(ctx, tx.Id.String(), model.StatusFailed))
+	outbox.AssertExpectations(t)
+}
+
+func TestEventedTransferRepo_FinishTransaction_SettledEmitsNothing(t *testing.T) {
+	ctx := context.Background()
+	inner, outbox := new(tests.MockTransferRepo), new(tests.MockOutboxRepo)
+	repo := repository.NewEventedTransferRepository(inner, outbox, tests.PassthroughTxManager{})
+
+	tx := model.Transaction{Id: uuid.New(), Status: model.StatusSuccess}
+	settled := apperrors.Conflict(apperrors.CodeTransactionSettled, "transaction is no longer pending")
+	inner.On("GetTransactionById", ctx, tx.Id.String()).Return(tx, nil)
+	inner.On("FinishTransaction", ctx, tx.Id.String(), model.StatusSuccess).Return(settled)
+
+	err := repo.FinishTransaction(ctx, tx.Id.String(), model.StatusSuccess)
+	require.ErrorIs(t, err, apperrors.ErrConflict)
+	outbox.AssertNotCalled(t, "Append", mock.Anything, mock.Anything)
+}
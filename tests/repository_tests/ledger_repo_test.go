@@ -0,0 +1,49 @@
+package repository_tests
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/tests"
+	"testing"
+)
+
+func TestLedgerRepo_GetAccountLedgers_Success(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewLedgerRepository(db)
+
+	userId := uuid.MustParse("7141b92f-a8c8-471e-83e5-7fc72da61cb9")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT u.id, u.balance, u.initial_balance,.*FROM users u ORDER BY u.id`).
+		WithArgs(model.StatusSuccess).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "balance", "initial_balance", "incoming", "outgoing"}).
+			AddRow(userId, 1000.0, 1010.0, 0.0, 10.0))
+	mock.ExpectCommit()
+
+	ledgers, err := repo.GetAccountLedgers(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []model.AccountLedger{
+		{UserId: userId, Balance: 1000, InitialBalance: 1010, Incoming: 0, Outgoing: 10},
+	}, ledgers)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLedgerRepo_GetAccountLedgers_Error(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewLedgerRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT u.id`).WillReturnError(errors.New("db error"))
+	mock.ExpectRollback()
+
+	_, err := repo.GetAccountLedgers(context.Background())
+	require.EqualError(t, err, "db error")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,110 @@
+package repository_tests
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/tests"
+	"testing"
+	"time"
+)
+
+func TestOutboxRepo_Append_NumbersEventPerAccount(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewOutboxRepository(db)
+
+	event := model.DomainEvent{
+		Id:         uuid.New(),
+		Type:       model.EventBalanceChanged,
+		AccountId:  "7141b92f-a8c8-471e-83e5-7fc72da61cb9",
+		Payload:    json.RawMessage(`{"balance":90}`),
+		OccurredAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	mock.ExpectQuery(`INSERT INTO outbox_accounts .* ON CONFLICT \(account_id\) DO UPDATE SET last_sequence = outbox_accounts.last_sequence \+ 1 RETURNING last_sequence`).
+		WithArgs(event.AccountId).
+		WillReturnRows(sqlmock.NewRows([]string{"last_sequence"}).AddRow(4))
+	mock.ExpectQuery(`INSERT INTO outbox .* RETURNING id`).
+		WithArgs(event.Id, event.Type, event.AccountId, int64(4), `{"balance":90}`, event.OccurredAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	appended, err := repo.Append(context.Background(), event)
+	require.NoError(t, err)
+	require.Equal(t, int64(7), appended.Sequence)
+	require.Equal(t, int64(4), appended.AccountSequence)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepo_Claim_LeasesAccountsThenReadsTheirEvents(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewOutboxRepository(db)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	leaseUntil := now.Add(30 * time.Second)
+	eventId := uuid.New()
+	mock.ExpectQuery(`UPDATE outbox_accounts SET claimed_until = \$1 WHERE account_id IN \(.*ORDER BY MIN\(o.id\) LIMIT \$3\) AND \(claimed_until IS NULL OR claimed_until <= \$2\) RETURNING account_id`).
+		WithArgs(leaseUntil, now, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id"}).AddRow("sender"))
+	mock.ExpectQuery(`SELECT .* FROM outbox WHERE published_at IS NULL AND account_id IN \(\$1\) ORDER BY account_id, account_sequence`).
+		WithArgs("sender").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "event_type", "account_id", "account_sequence", "payload", "occurred_at"}).
+			AddRow(3, eventId, model.EventTransferCreated, "sender", 2, `{"id":"tx"}`, now))
+
+	events, err := repo.Claim(context.Background(), now, leaseUntil, 10)
+	require.NoError(t, err)
+	require.Equal(t, []model.DomainEvent{{
+		Sequence:        3,
+		Id:              eventId,
+		Type:            model.EventTransferCreated,
+		AccountId:       "sender",
+		AccountSequence: 2,
+		Payload:         json.RawMessage(`{"id":"tx"}`),
+		OccurredAt:      now,
+	}}, events)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepo_Claim_NothingToClaim(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewOutboxRepository(db)
+
+	mock.ExpectQuery(`UPDATE outbox_accounts SET claimed_until`).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id"}))
+
+	events, err := repo.Claim(context.Background(), time.Now(), time.Now().Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Empty(t, events)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepo_MarkPublished(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewOutboxRepository(db)
+
+	publishedAt := time.Date(2025, 1, 1, 0, 0, 1, 0, time.UTC)
+	mock.ExpectExec(`UPDATE outbox SET published_at = \$1 WHERE id = \$2`).
+		WithArgs(publishedAt, int64(3)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.MarkPublished(context.Background(), 3, publishedAt))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepo_Release(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewOutboxRepository(db)
+
+	notBefore := time.Date(2025, 1, 1, 0, 0, 1, 0, time.UTC)
+	mock.ExpectExec(`UPDATE outbox_accounts SET claimed_until = \$1 WHERE account_id = \$2`).
+		WithArgs(notBefore, "sender").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.Release(context.Background(), "sender", notBefore))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
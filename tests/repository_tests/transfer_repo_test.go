@@ -7,6 +7,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
 	"moneyTransfer/internal/domain/model"
 	"moneyTransfer/internal/repository"
 	"moneyTransfer/tests"
@@ -146,37 +147,113 @@ func TestTransferRepo_CreateTransfer_Error(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestTransferRepo_UpdateTransactionStatus_Success(t *testing.T) {
+func TestTransferRepo_FinishTransaction_Success(t *testing.T) {
 	db, mock := tests.SetupMockDB(t)
 	repo := repository.NewTransferRepository(db)
 
 	txId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
 	newStatus := model.StatusSuccess
 
-	mock.ExpectExec(`UPDATE transactions SET status = \$1 WHERE id = \$2`).
-		WithArgs(newStatus, txId).
+	mock.ExpectExec(`UPDATE transactions SET status = \$1 WHERE id = \$2 AND status = \$3`).
+		WithArgs(newStatus, txId, model.StatusPending).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err := repo.UpdateTransactionStatus(context.Background(), txId, newStatus)
+	err := repo.FinishTransaction(context.Background(), txId, newStatus)
 	require.NoError(t, err)
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestTransferRepo_UpdateTransactionStatus_Error(t *testing.T) {
+func TestTransferRepo_FinishTransaction_Error(t *testing.T) {
 	db, mock := tests.SetupMockDB(t)
 	repo := repository.NewTransferRepository(db)
 
 	txId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
 	newStatus := model.StatusSuccess
 
-	mock.ExpectExec(`UPDATE transactions SET status = \$1 WHERE id = \$2`).
-		WithArgs(newStatus, txId).
+	mock.ExpectExec(`UPDATE transactions SET status = \$1 WHERE id = \$2 AND status = \$3`).
+		WithArgs(newStatus, txId, model.StatusPending).
 		WillReturnError(errors.New("update failed"))
 
-	err := repo.UpdateTransactionStatus(context.Background(), txId, newStatus)
+	err := repo.FinishTransaction(context.Background(), txId, newStatus)
 	require.Error(t, err)
 	require.EqualError(t, err, "update failed")
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestTransferRepo_FinishTransaction_AlreadySettled(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewTransferRepository(db)
+
+	txId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+
+	mock.ExpectExec(`UPDATE transactions SET status = \$1 WHERE id = \$2 AND status = \$3`).
+		WithArgs(model.StatusSuccess, txId, model.StatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT (.+) FROM transactions WHERE id = \$1`).
+		WithArgs(txId).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sender_id", "receiver_id", "amount", "status", "created_at"}).
+			AddRow(txId, uuid.NewString(), uuid.NewString(), 10.0, model.StatusSuccess, time.Now()))
+
+	err := repo.FinishTransaction(context.Background(), txId, model.StatusSuccess)
+	require.ErrorIs(t, err, apperrors.ErrConflict)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransferRepo_DeletePendingTransaction_Success(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewTransferRepository(db)
+
+	txId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+
+	mock.ExpectExec(`DELETE FROM transactions WHERE id = \$1 AND status = \$2`).
+		WithArgs(txId, model.StatusPending).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := repo.DeletePendingTransaction(context.Background(), txId)
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransferRepo_GetTransactionById_Success(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewTransferRepository(db)
+
+	txId := "a5bceab4-9dab-4d7a-8cd5-4ba832ebf899"
+	senderId := "c775d967-7b54-463f-9923-90f219d8224d"
+	receiverId := "ed9c2b61-3908-413b-b355-a6c36d1a0cb3"
+
+	mock.ExpectQuery(`SELECT id, sender_id, receiver_id, amount, status, created_at FROM transactions WHERE id = \$1`).
+		WithArgs(txId).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sender_id", "receiver_id", "amount", "status", "created_at"}).
+			AddRow(txId, senderId, receiverId, 100.0, model.StatusPending, time.Time{}))
+
+	transaction, err := repo.GetTransactionById(context.Background(), txId)
+	require.NoError(t, err)
+	require.Equal(t, model.Transaction{
+		Id:         uuid.MustParse(txId),
+		SenderId:   uuid.MustParse(senderId),
+		ReceiverId: uuid.MustParse(receiverId),
+		Amount:     100,
+		Status:     model.StatusPending,
+	}, transaction)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransferRepo_GetTransactionById_Error(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewTransferRepository(db)
+
+	mock.ExpectQuery(`SELECT id, sender_id, receiver_id, amount, status, created_at FROM transactions WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.GetTransactionById(context.Background(), "missing")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
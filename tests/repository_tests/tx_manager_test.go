@@ -0,0 +1,94 @@
+package repository_tests
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/pkg/metrics"
+	"moneyTransfer/tests"
+	"testing"
+)
+
+func TestTxManager_WithinTransaction_CommitsAndJoins(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	txManager := repository.NewTxManager(db)
+	repo := repository.NewUserRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE users SET balance = \$1 WHERE id = \$2`).WithArgs(10.0, "a").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE users SET balance = \$1 WHERE id = \$2`).WithArgs(20.0, "b").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := txManager.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		if err := repo.UpdateBalance(ctx, "a", 10); err != nil {
+			return err
+		}
+		// A nested call reuses the outer transaction instead of beginning a new one.
+		return txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			return repo.UpdateBalance(ctx, "b", 20)
+		})
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTxManager_WithinTransaction_RollsBackOnError(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	txManager := repository.NewTxManager(db)
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err := txManager.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+	require.EqualError(t, err, "boom")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTxManager_WithinTransaction_DeadlockIsRetryable(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	txManager := repository.NewTxManager(db)
+	repo := repository.NewUserRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE users SET balance = \$1 WHERE id = \$2`).WithArgs(10.0, "a").
+		WillReturnError(&pq.Error{Code: "40P01", Message: "deadlock detected"})
+	mock.ExpectRollback()
+
+	err := txManager.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		return repo.UpdateBalance(ctx, "a", 10)
+	})
+	require.ErrorIs(t, err, apperrors.ErrUnavailable)
+	var pqErr *pq.Error
+	require.ErrorAs(t, err, &pqErr, "the database error stays in the chain")
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTxManager_TimesStatements(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewUserRepository(db)
+
+	mock.ExpectExec(`UPDATE users SET balance = \$1 WHERE id = \$2`).WithArgs(10.0, "a").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	before := histogramCount(t, "update")
+	require.NoError(t, repo.UpdateBalance(context.Background(), "a", 10))
+
+	require.Equal(t, before+1, histogramCount(t, "update"))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func histogramCount(t *testing.T, operation string) uint64 {
+	var m dto.Metric
+	require.NoError(t, metrics.DBQueryDuration.WithLabelValues(operation).(prometheus.Histogram).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
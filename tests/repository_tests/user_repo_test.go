@@ -7,6 +7,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
 	"moneyTransfer/internal/domain/model"
 	"moneyTransfer/internal/repository"
 	"moneyTransfer/tests"
@@ -43,6 +44,7 @@ func TestUserRepo_GetBalance_Error(t *testing.T) {
 	require.Error(t, err)
 	require.Equal(t, 0.0, balance)
 	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
 	require.NoError(t, mock.ExpectationsWereMet())
 
 	require.Error(t, err)
@@ -105,6 +107,21 @@ func TestUserRepo_UpdateBalance_Success(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUserRepo_UpdateBalance_NotFound(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+
+	repo := repository.NewUserRepository(db)
+
+	mock.ExpectExec(`UPDATE users SET balance = \$1 WHERE id = \$2`).
+		WithArgs(300.0, "missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.UpdateBalance(context.Background(), "missing", 300.0)
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUserRepo_UpdateBalance_Error(t *testing.T) {
 	db, mock := tests.SetupMockDB(t)
 
@@ -121,3 +138,87 @@ func TestUserRepo_UpdateBalance_Error(t *testing.T) {
 
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestUserRepo_Debit_Success(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+
+	repo := repository.NewUserRepository(db)
+	userId := "ed9c2b61-3908-413b-b355-a6c36d1a0cb3"
+
+	mock.ExpectQuery(`UPDATE users SET balance = balance - \$1 WHERE id = \$2 AND balance >= \$1`).
+		WithArgs(80.0, userId).
+		WillReturnRows(sqlmock.NewRows([]string{"previous", "balance"}).AddRow(100.0, 20.0))
+
+	previous, balance, err := repo.Debit(context.Background(), userId, 80)
+	require.NoError(t, err)
+	require.Equal(t, 100.0, previous)
+	require.Equal(t, 20.0, balance)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepo_Debit_InsufficientFunds(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+
+	repo := repository.NewUserRepository(db)
+	userId := "ed9c2b61-3908-413b-b355-a6c36d1a0cb3"
+
+	mock.ExpectQuery(`UPDATE users SET balance = balance - \$1`).
+		WithArgs(80.0, userId).
+		WillReturnRows(sqlmock.NewRows([]string{"previous", "balance"}))
+	mock.ExpectQuery(`SELECT balance FROM users WHERE id = \$1`).
+		WithArgs(userId).
+		WillReturnRows(sqlmock.NewRows([]string{"balance"}).AddRow(50.0))
+
+	_, _, err := repo.Debit(context.Background(), userId, 80)
+	require.ErrorIs(t, err, apperrors.ErrInsufficientFunds)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepo_Debit_NotFound(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+
+	repo := repository.NewUserRepository(db)
+
+	mock.ExpectQuery(`UPDATE users SET balance = balance - \$1`).
+		WithArgs(80.0, "missing").
+		WillReturnRows(sqlmock.NewRows([]string{"previous", "balance"}))
+	mock.ExpectQuery(`SELECT balance FROM users WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnError(sql.ErrNoRows)
+
+	_, _, err := repo.Debit(context.Background(), "missing", 80)
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepo_Credit_NotFound(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+
+	repo := repository.NewUserRepository(db)
+
+	mock.ExpectQuery(`UPDATE users SET balance = balance \+ \$1 WHERE id = \$2`).
+		WithArgs(80.0, "missing").
+		WillReturnRows(sqlmock.NewRows([]string{"previous", "balance"}))
+
+	_, _, err := repo.Credit(context.Background(), "missing", 80)
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepo_Lock(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+
+	repo := repository.NewUserRepository(db)
+
+	mock.ExpectQuery(`SELECT id FROM users WHERE id IN \(\$1, \$2\) ORDER BY id FOR UPDATE`).
+		WithArgs("b", "a").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("a").AddRow("b"))
+
+	require.NoError(t, repo.Lock(context.Background(), "b", "a"))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,120 @@
+package repository_tests
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/tests"
+	"testing"
+	"time"
+)
+
+func TestWebhookRepo_GetById_SplitsEvents(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewWebhookRepository(db)
+
+	id, ownerId, account := uuid.New(), uuid.New(), uuid.New()
+	createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT id, owner_id, url, events, accounts, secret, created_at FROM webhooks WHERE id = \$1`).
+		WithArgs(id.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "url", "events", "accounts", "secret", "created_at"}).
+			AddRow(id, ownerId, "https://example.com/hook", "TransferFailed TransferSucceeded", account.String(), "whsec_secret", createdAt))
+
+	webhook, err := repo.GetById(context.Background(), id.String())
+	require.NoError(t, err)
+	assert.Equal(t, ownerId, webhook.OwnerId)
+	assert.Equal(t, []string{model.EventTransferFailed, model.EventTransferSucceeded}, webhook.Events)
+	assert.Equal(t, []uuid.UUID{account}, webhook.Accounts)
+	assert.Equal(t, "whsec_secret", webhook.Secret)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepo_ListByOwner_FiltersByOwner(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewWebhookRepository(db)
+
+	ownerId := uuid.New()
+	mock.ExpectQuery(`SELECT .* FROM webhooks WHERE owner_id = \$1 ORDER BY created_at`).
+		WithArgs(ownerId.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "owner_id", "url", "events", "accounts", "secret", "created_at"}).
+			AddRow(uuid.New(), ownerId, "https://example.com/hook", "TransferFailed", "", "whsec_secret", time.Now()))
+
+	webhooks, err := repo.ListByOwner(context.Background(), ownerId.String())
+	require.NoError(t, err)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, ownerId, webhooks[0].OwnerId)
+	assert.Empty(t, webhooks[0].Accounts)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepo_Reassign_MovesOwnersWebhooks(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewWebhookRepository(db)
+
+	from, to := uuid.NewString(), uuid.NewString()
+	mock.ExpectExec(`UPDATE webhooks SET owner_id = \$2 WHERE owner_id = \$1`).
+		WithArgs(from, to).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	require.NoError(t, repo.Reassign(context.Background(), from, to))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepo_Delete_NotFound(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewWebhookRepository(db)
+
+	mock.ExpectExec(`DELETE FROM webhooks WHERE id = \$1`).
+		WithArgs("missing").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Delete(context.Background(), "missing")
+	require.ErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func TestWebhookDeliveryRepo_Create_IgnoresDuplicateEvent(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewWebhookDeliveryRepository(db)
+
+	mock.ExpectExec(`INSERT INTO webhook_deliveries .* ON CONFLICT \(webhook_id, event_id\) DO NOTHING`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err := repo.Create(context.Background(), model.WebhookDelivery{Id: uuid.New(), WebhookId: uuid.New(), EventId: uuid.New()})
+	require.NoError(t, err)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookDeliveryRepo_Claim_SkipsLockedRows(t *testing.T) {
+	db, mock := tests.SetupMockDB(t)
+	repo := repository.NewWebhookDeliveryRepository(db)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	leaseUntil := now.Add(10 * time.Second)
+	id, webhookId, eventId := uuid.New(), uuid.New(), uuid.New()
+
+	mock.ExpectQuery(`UPDATE webhook_deliveries SET next_attempt_at = \$1 .* FOR UPDATE SKIP LOCKED\) RETURNING`).
+		WithArgs(leaseUntil, now, 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "webhook_id", "event_id", "event_type", "payload", "status", "attempts",
+			"response_status", "last_error", "next_attempt_at", "created_at", "delivered_at"}).
+			AddRow(id, webhookId, eventId, model.EventTransferSucceeded, `{"type":"TransferSucceeded"}`, model.DeliveryPending, 1,
+				500, "unexpected response status 500", leaseUntil, now, nil))
+
+	deliveries, err := repo.Claim(context.Background(), now, leaseUntil, 20)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, id, deliveries[0].Id)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Nil(t, deliveries[0].DeliveredAt)
+	assert.JSONEq(t, `{"type":"TransferSucceeded"}`, string(deliveries[0].Payload))
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
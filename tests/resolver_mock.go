@@ -0,0 +1,22 @@
+package tests
+
+import (
+	"context"
+	"net"
+)
+
+// StaticResolver resolves the hosts it lists to their addresses and fails
+// for any other host, so tests need no DNS.
+type StaticResolver map[string][]string
+
+func (r StaticResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	addrs := make([]net.IPAddr, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
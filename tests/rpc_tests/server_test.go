@@ -0,0 +1,258 @@
+package rpc_tests
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"io"
+	"log/slog"
+	"moneyTransfer/api"
+	"moneyTransfer/api/handler"
+	"moneyTransfer/api/middleware"
+	"moneyTransfer/api/rpc"
+	"moneyTransfer/api/rpc/transferv1"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/pkg/logger"
+	"moneyTransfer/pkg/metrics"
+	"moneyTransfer/pkg/ratelimit"
+	"moneyTransfer/tests"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testKey = "mtk_test_key"
+
+type fixture struct {
+	transfers *tests.MockTransferService
+	users     *tests.MockUserService
+	keys      *tests.MockAPIKeyService
+	limiter   *middleware.RateLimiter
+	metrics   *metrics.GRPCMetrics
+	client    transferv1.TransferServiceClient
+}
+
+func newFixture(t *testing.T, scopes ...string) *fixture {
+	f := &fixture{
+		transfers: new(tests.MockTransferService),
+		users:     new(tests.MockUserService),
+		keys:      new(tests.MockAPIKeyService),
+		metrics:   metrics.NewGRPCMetrics(prometheus.NewRegistry()),
+	}
+	f.keys.On("Authenticate", mock.Anything, testKey).Return(model.APIKey{Id: uuid.New(), Scopes: scopes}, nil).Maybe()
+	f.keys.On("Authenticate", mock.Anything, mock.Anything).Return(model.APIKey{}, apperrors.Unauthorized(apperrors.CodeInvalidAPIKey, "invalid api key")).Maybe()
+
+	log := logger.New(io.Discard, logger.Options{Level: slog.LevelDebug})
+	f.limiter = middleware.NewRateLimiter(log)
+	server := rpc.NewGRPCServer(rpc.NewServer(f.transfers, f.users, 10*time.Second, log), middleware.NewAuthenticator(f.keys, log), f.limiter, f.metrics, log)
+
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	f.client = transferv1.NewTransferServiceClient(conn)
+	return f
+}
+
+func withKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+}
+
+func errorInfo(t *testing.T, err error) *errdetails.ErrorInfo {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	t.Fatalf("no ErrorInfo in %v", err)
+	return nil
+}
+
+func TestServer_GetBalance(t *testing.T) {
+	f := newFixture(t, model.ScopeBalancesRead)
+	f.users.On("GetBalance", mock.Anything, "user-1").Return(120.5, nil)
+
+	ctx := metadata.AppendToOutgoingContext(withKey(testKey), "x-request-id", "req-42")
+	var header metadata.MD
+	resp, err := f.client.GetBalance(ctx, &transferv1.GetBalanceRequest{UserId: "user-1"}, grpc.Header(&header))
+
+	require.NoError(t, err)
+	assert.Equal(t, 120.5, resp.GetBalance())
+	assert.Equal(t, []string{"req-42"}, header.Get("x-request-id"))
+	assert.Equal(t, 1.0, testutil.ToFloat64(f.metrics.Handled.WithLabelValues("moneytransfer.v1.TransferService", "GetBalance", "OK")))
+}
+
+func TestServer_RequiresAPIKey(t *testing.T) {
+	f := newFixture(t, model.ScopeBalancesRead)
+
+	_, err := f.client.GetBalance(context.Background(), &transferv1.GetBalanceRequest{UserId: "user-1"})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, apperrors.CodeAuthenticationMissing, errorInfo(t, err).GetReason())
+	f.users.AssertNotCalled(t, "GetBalance", mock.Anything, mock.Anything)
+}
+
+func TestServer_RequiresScope(t *testing.T) {
+	f := newFixture(t, model.ScopeBalancesRead)
+
+	_, err := f.client.CreateTransfer(withKey(testKey), &transferv1.CreateTransferRequest{From: "a", To: "b", Amount: 1})
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, apperrors.CodeInsufficientScope, errorInfo(t, err).GetReason())
+}
+
+func TestServer_RateLimitedPerAccount(t *testing.T) {
+	f := newFixture(t, model.ScopeBalancesRead)
+	f.limiter.AddPolicy(api.RouteGetBalance, middleware.RateLimitPolicy{Name: middleware.PolicyAccount, Limiter: ratelimit.NewLimiter(1, 1), Key: middleware.PathAccountKey("userId")})
+	alice, bob := uuid.NewString(), uuid.NewString()
+	f.users.On("GetBalance", mock.Anything, mock.Anything).Return(10.0, nil)
+
+	_, err := f.client.GetBalance(withKey(testKey), &transferv1.GetBalanceRequest{UserId: alice})
+	require.NoError(t, err)
+
+	_, err = f.client.GetBalance(withKey(testKey), &transferv1.GetBalanceRequest{UserId: alice})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, apperrors.CodeRateLimited, errorInfo(t, err).GetReason())
+
+	_, err = f.client.GetBalance(withKey(testKey), &transferv1.GetBalanceRequest{UserId: bob})
+	require.NoError(t, err, "other accounts have their own bucket")
+}
+
+func TestServer_RateLimitedByIPBeforeAuthentication(t *testing.T) {
+	f := newFixture(t, model.ScopeBalancesRead)
+	f.limiter.AddPolicy(api.RouteAuthenticate, middleware.RateLimitPolicy{Name: middleware.PolicyIP, Limiter: ratelimit.NewLimiter(1, 1), Key: middleware.IPKey})
+
+	_, err := f.client.GetBalance(withKey("mtk_guess"), &transferv1.GetBalanceRequest{UserId: "user-1"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = f.client.GetBalance(withKey("mtk_guess"), &transferv1.GetBalanceRequest{UserId: "user-1"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	f.keys.AssertNumberOfCalls(t, "Authenticate", 1)
+}
+
+func TestServer_TracesCalls(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+
+	f := newFixture(t, model.ScopeBalancesRead)
+	f.users.On("GetBalance", mock.Anything, "user-1").Return(1.0, nil)
+
+	_, err := f.client.GetBalance(withKey(testKey), &transferv1.GetBalanceRequest{UserId: "user-1"})
+	require.NoError(t, err)
+
+	// The server span ends once the response has been written.
+	require.Eventually(t, func() bool { return len(recorder.Ended()) == 1 }, time.Second, time.Millisecond)
+	span := recorder.Ended()[0]
+	assert.Equal(t, "moneytransfer.v1.TransferService/GetBalance", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+}
+
+func TestServer_CreateTransfer_ValidationFields(t *testing.T) {
+	f := newFixture(t, model.ScopeTransfersWrite)
+	validationErr := apperrors.Validation(apperrors.CodeValidationFailed, "transfer request is invalid").WithFields(
+		apperrors.FieldError{Field: "amount", Code: apperrors.CodeInvalidAmount, Message: "amount must be greater than zero"})
+	f.transfers.On("CreateTransfer", mock.Anything, "a", "b", 0.0).Return(uuid.Nil, validationErr)
+
+	_, err := f.client.CreateTransfer(withKey(testKey), &transferv1.CreateTransferRequest{From: "a", To: "b"})
+
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	var badRequest *errdetails.BadRequest
+	for _, detail := range status.Convert(err).Details() {
+		if d, ok := detail.(*errdetails.BadRequest); ok {
+			badRequest = d
+		}
+	}
+	require.NotNil(t, badRequest)
+	require.Len(t, badRequest.GetFieldViolations(), 1)
+	assert.Equal(t, "amount", badRequest.GetFieldViolations()[0].GetField())
+	assert.Equal(t, apperrors.CodeInvalidAmount, badRequest.GetFieldViolations()[0].GetReason())
+}
+
+func TestServer_CreateTransfer_Waits(t *testing.T) {
+	f := newFixture(t, model.ScopeTransfersWrite)
+	id := uuid.New()
+	f.transfers.On("CreateTransfer", mock.Anything, "a", "b", 10.0).Return(id, nil)
+	f.transfers.On("WaitForTransfer", mock.Anything, id, 2*time.Second).Return(model.Transaction{Id: id, Status: model.StatusFailed}, nil)
+
+	resp, err := f.client.CreateTransfer(withKey(testKey), &transferv1.CreateTransferRequest{From: "a", To: "b", Amount: 10, Wait: durationpb.New(2 * time.Second)})
+
+	require.NoError(t, err)
+	assert.Equal(t, id.String(), resp.GetTransactionId())
+	assert.Equal(t, transferv1.TransactionStatus_TRANSACTION_STATUS_FAILED, resp.GetStatus())
+	assert.Equal(t, "Transaction failed", resp.GetMessage())
+}
+
+func TestServer_GetTransaction_InternalErrorIsHidden(t *testing.T) {
+	f := newFixture(t, model.ScopeTransfersRead)
+	id := uuid.New()
+	f.transfers.On("GetTransaction", mock.Anything, id).Return(model.Transaction{}, errors.New("connection reset"))
+
+	_, err := f.client.GetTransaction(withKey(testKey), &transferv1.GetTransactionRequest{Id: id.String()})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "Error fetching transaction", status.Convert(err).Message())
+	assert.NotEmpty(t, errorInfo(t, err).GetMetadata()["request_id"])
+}
+
+func TestServer_WatchTransfer(t *testing.T) {
+	f := newFixture(t, model.ScopeTransfersRead)
+	id := uuid.New()
+	pending := model.Transaction{Id: id, Amount: 5, Status: model.StatusPending, CreatedAt: time.Now()}
+	done := pending
+	done.Status = model.StatusSuccess
+	f.transfers.On("GetTransaction", mock.Anything, id).Return(pending, nil)
+	f.transfers.On("WaitForTransfer", mock.Anything, id, mock.Anything).Return(pending, nil).Once()
+	f.transfers.On("WaitForTransfer", mock.Anything, id, mock.Anything).Return(done, nil).Once()
+
+	stream, err := f.client.WatchTransfer(withKey(testKey), &transferv1.WatchTransferRequest{Id: id.String()})
+	require.NoError(t, err)
+
+	var statuses []transferv1.TransactionStatus
+	for {
+		tx, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		statuses = append(statuses, tx.GetStatus())
+	}
+
+	assert.Equal(t, []transferv1.TransactionStatus{
+		transferv1.TransactionStatus_TRANSACTION_STATUS_PENDING,
+		transferv1.TransactionStatus_TRANSACTION_STATUS_SUCCESS,
+	}, statuses)
+}
+
+func TestCodeFor_MatchesHTTPStatus(t *testing.T) {
+	for kind := apperrors.KindInternal; kind <= apperrors.KindRateLimited; kind++ {
+		serverError := handler.StatusFor(kind) >= http.StatusInternalServerError
+		assert.Equal(t, serverError, rpc.CodeFor(kind) == codes.Internal || rpc.CodeFor(kind) == codes.Unavailable, "kind %d", kind)
+	}
+}
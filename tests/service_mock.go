@@ -5,6 +5,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"moneyTransfer/internal/domain/model"
+	"time"
 )
 
 type MockUserService struct {
@@ -30,7 +31,103 @@ func (m *MockTransferService) GetTransactionsByUserId(ctx context.Context, userI
 	return args.Get(0).([]model.Transaction), args.Error(1)
 }
 
+func (m *MockTransferService) GetTransaction(ctx context.Context, id uuid.UUID) (model.Transaction, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Transaction), args.Error(1)
+}
+
 func (m *MockTransferService) CreateTransfer(ctx context.Context, from, to string, amount float64) (uuid.UUID, error) {
 	args := m.Called(ctx, from, to, amount)
 	return args.Get(0).(uuid.UUID), args.Error(1)
 }
+
+func (m *MockTransferService) WaitForTransfer(ctx context.Context, id uuid.UUID, timeout time.Duration) (model.Transaction, error) {
+	args := m.Called(ctx, id, timeout)
+	return args.Get(0).(model.Transaction), args.Error(1)
+}
+
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) Issue(ctx context.Context, name string, scopes []string) (model.APIKey, string, error) {
+	args := m.Called(ctx, name, scopes)
+	return args.Get(0).(model.APIKey), args.String(1), args.Error(2)
+}
+
+func (m *MockAPIKeyService) EnsureKey(ctx context.Context, name, rawKey string, scopes []string) error {
+	args := m.Called(ctx, name, rawKey, scopes)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyService) Authenticate(ctx context.Context, rawKey string) (model.APIKey, error) {
+	args := m.Called(ctx, rawKey)
+	return args.Get(0).(model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) List(ctx context.Context) ([]model.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) Revoke(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyService) Rotate(ctx context.Context, id string) (model.APIKey, string, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.APIKey), args.String(1), args.Error(2)
+}
+
+type MockReconciliationService struct {
+	mock.Mock
+}
+
+func (m *MockReconciliationService) Reconcile(ctx context.Context) (model.ReconciliationReport, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(model.ReconciliationReport), args.Error(1)
+}
+
+type MockWebhookService struct {
+	mock.Mock
+}
+
+func (m *MockWebhookService) Register(ctx context.Context, endpoint string, events, accounts []string) (model.Webhook, error) {
+	args := m.Called(ctx, endpoint, events, accounts)
+	return args.Get(0).(model.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookService) List(ctx context.Context) ([]model.Webhook, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Webhook), args.Error(1)
+}
+
+func (m *MockWebhookService) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, webhookId string) ([]model.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookId)
+	return args.Get(0).([]model.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookService) Redeliver(ctx context.Context, webhookId, deliveryId string) (model.WebhookDelivery, error) {
+	args := m.Called(ctx, webhookId, deliveryId)
+	return args.Get(0).(model.WebhookDelivery), args.Error(1)
+}
+
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Record(ctx context.Context, action, entityType, entityId string, before, after any) error {
+	args := m.Called(ctx, action, entityType, entityId, before, after)
+	return args.Error(0)
+}
+
+func (m *MockAuditService) Verify(ctx context.Context) (model.AuditVerification, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(model.AuditVerification), args.Error(1)
+}
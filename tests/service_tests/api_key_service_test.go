@@ -0,0 +1,192 @@
+package service_tests
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/tests"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyService_Issue_Success(t *testing.T) {
+	ctx, repo, svc, logger := initAPIKeyService()
+
+	var stored model.APIKey
+	repo.On("Create", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(model.APIKey)
+	}).Return(nil)
+	logger.On("Info", "api key issued", "apiKeyId", mock.Anything, "name", "payments", "scopes", mock.Anything).Return()
+
+	key, rawKey, err := svc.Issue(ctx, "payments", []string{model.ScopeTransfersWrite})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(rawKey, "mtk_"))
+	assert.Equal(t, service.HashAPIKey(rawKey), stored.KeyHash)
+	assert.NotContains(t, stored.KeyHash, rawKey)
+	assert.Equal(t, rawKey[:12], key.Prefix)
+	assert.Equal(t, []string{model.ScopeTransfersWrite}, key.Scopes)
+	repo.AssertExpectations(t)
+	logger.AssertExpectations(t)
+}
+
+func TestAPIKeyService_Issue_UnknownScope(t *testing.T) {
+	ctx, repo, svc, _ := initAPIKeyService()
+
+	_, _, err := svc.Issue(ctx, "payments", []string{"transfers:delete"})
+	require.ErrorIs(t, err, service.ErrInvalidAPIKeyRequest)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAPIKeyService_Authenticate_Success(t *testing.T) {
+	ctx, repo, svc, _ := initAPIKeyService()
+
+	rawKey := "mtk_test_key_0123456789abcdefghijklmnop"
+	stored := model.APIKey{Id: uuid.New(), Name: "payments", Scopes: []string{model.ScopeBalancesRead}}
+
+	repo.On("GetByHash", ctx, service.HashAPIKey(rawKey)).Return(stored, nil)
+	repo.On("UpdateLastUsed", ctx, stored.Id.String(), mock.Anything).Return(nil)
+
+	key, err := svc.Authenticate(ctx, rawKey)
+	require.NoError(t, err)
+	assert.Equal(t, stored.Id, key.Id)
+	assert.NotNil(t, key.LastUsedAt)
+	repo.AssertExpectations(t)
+}
+
+func TestAPIKeyService_Authenticate_RecentlyUsedSkipsUpdate(t *testing.T) {
+	ctx, repo, svc, _ := initAPIKeyService()
+
+	rawKey := "mtk_test_key_0123456789abcdefghijklmnop"
+	usedAt := time.Now().Add(-5 * time.Second)
+	stored := model.APIKey{Id: uuid.New(), Scopes: []string{model.ScopeBalancesRead}, LastUsedAt: &usedAt}
+
+	repo.On("GetByHash", ctx, service.HashAPIKey(rawKey)).Return(stored, nil)
+
+	_, err := svc.Authenticate(ctx, rawKey)
+	require.NoError(t, err)
+	repo.AssertNotCalled(t, "UpdateLastUsed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAPIKeyService_Authenticate_UnknownKey(t *testing.T) {
+	ctx, repo, svc, _ := initAPIKeyService()
+
+	repo.On("GetByHash", ctx, mock.Anything).Return(model.APIKey{}, apperrors.NotFound(apperrors.CodeAPIKeyNotFound, "api key not found"))
+
+	_, err := svc.Authenticate(ctx, "mtk_unknown")
+	require.ErrorIs(t, err, service.ErrInvalidAPIKey)
+}
+
+func TestAPIKeyService_Authenticate_Revoked(t *testing.T) {
+	ctx, repo, svc, logger := initAPIKeyService()
+
+	revokedAt := time.Now()
+	stored := model.APIKey{Id: uuid.New(), RevokedAt: &revokedAt}
+
+	repo.On("GetByHash", ctx, mock.Anything).Return(stored, nil)
+	logger.On("Warn", "revoked api key used", "apiKeyId", stored.Id).Return()
+
+	_, err := svc.Authenticate(ctx, "mtk_revoked")
+	require.ErrorIs(t, err, service.ErrAPIKeyRevoked)
+	logger.AssertExpectations(t)
+}
+
+func TestAPIKeyService_Revoke_NotFound(t *testing.T) {
+	ctx, repo, svc, _ := initAPIKeyService()
+
+	repo.On("Revoke", ctx, "missing", mock.Anything).Return(apperrors.NotFound(apperrors.CodeAPIKeyNotFound, "api key not found"))
+
+	err := svc.Revoke(ctx, "missing")
+	require.ErrorIs(t, err, service.ErrAPIKeyNotFound)
+}
+
+func TestAPIKeyService_Rotate_Success(t *testing.T) {
+	ctx, repo, webhooks, svc, logger := initAPIKeyServiceWithWebhooks()
+
+	old := model.APIKey{Id: uuid.New(), Name: "payments", Scopes: []string{model.ScopeTransfersWrite}}
+
+	repo.On("GetById", ctx, old.Id.String()).Return(old, nil)
+	repo.On("Create", ctx, mock.Anything).Return(nil)
+	webhooks.On("Reassign", ctx, old.Id.String(), mock.Anything).Return(nil)
+	repo.On("Revoke", ctx, old.Id.String(), mock.Anything).Return(nil)
+	logger.On("Info", "api key rotated", "oldApiKeyId", old.Id.String(), "apiKeyId", mock.Anything, "name", old.Name, "scopes", old.Scopes).Return()
+
+	key, rawKey, err := svc.Rotate(ctx, old.Id.String())
+	require.NoError(t, err)
+	assert.NotEqual(t, old.Id, key.Id)
+	assert.Equal(t, old.Name, key.Name)
+	assert.Equal(t, old.Scopes, key.Scopes)
+	assert.NotEmpty(t, rawKey)
+	webhooks.AssertCalled(t, "Reassign", ctx, old.Id.String(), key.Id.String())
+	repo.AssertExpectations(t)
+	logger.AssertExpectations(t)
+}
+
+func TestAPIKeyService_Rotate_LosesRaceToConcurrentRotation(t *testing.T) {
+	ctx, repo, webhooks, svc, _ := initAPIKeyServiceWithWebhooks()
+
+	old := model.APIKey{Id: uuid.New(), Name: "payments", Scopes: []string{model.ScopeTransfersWrite}}
+
+	repo.On("GetById", ctx, old.Id.String()).Return(old, nil)
+	repo.On("Create", ctx, mock.Anything).Return(nil)
+	webhooks.On("Reassign", ctx, old.Id.String(), mock.Anything).Return(nil)
+	// Another rotation revoked the key after it was read.
+	repo.On("Revoke", ctx, old.Id.String(), mock.Anything).Return(apperrors.Conflict(apperrors.CodeAPIKeyRevoked, "api key already revoked"))
+
+	_, _, err := svc.Rotate(ctx, old.Id.String())
+	require.ErrorIs(t, err, service.ErrAPIKeyAlreadyRevoked)
+}
+
+func TestAPIKeyService_Revoke_AlreadyRevoked(t *testing.T) {
+	ctx, repo, svc, _ := initAPIKeyService()
+
+	repo.On("Revoke", ctx, "revoked", mock.Anything).Return(apperrors.Conflict(apperrors.CodeAPIKeyRevoked, "api key already revoked"))
+
+	err := svc.Revoke(ctx, "revoked")
+	require.ErrorIs(t, err, service.ErrAPIKeyAlreadyRevoked)
+	require.ErrorIs(t, err, apperrors.ErrConflict)
+}
+
+func TestAPIKeyService_EnsureKey_AlreadyExists(t *testing.T) {
+	ctx, repo, svc, _ := initAPIKeyService()
+
+	rawKey := "mtk_bootstrap_0123456789abcdefghijklmnop"
+	repo.On("GetByHash", ctx, service.HashAPIKey(rawKey)).Return(model.APIKey{Id: uuid.New()}, nil)
+
+	err := svc.EnsureKey(ctx, "bootstrap-admin", rawKey, []string{model.ScopeAdmin})
+	require.NoError(t, err)
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestAPIKeyService_EnsureKey_LookupError(t *testing.T) {
+	ctx, repo, svc, logger := initAPIKeyService()
+
+	rawKey := "mtk_bootstrap_0123456789abcdefghijklmnop"
+	repo.On("GetByHash", ctx, mock.Anything).Return(model.APIKey{}, errors.New("db error"))
+	logger.On("Error", "failed to look up api key", "name", "bootstrap-admin", "error", mock.Anything).Return()
+
+	err := svc.EnsureKey(ctx, "bootstrap-admin", rawKey, []string{model.ScopeAdmin})
+	require.Error(t, err)
+	logger.AssertExpectations(t)
+}
+
+func initAPIKeyService() (context.Context, *tests.MockAPIKeyRepo, service.APIKeyService, *tests.MockLogger) {
+	ctx, repo, _, svc, logger := initAPIKeyServiceWithWebhooks()
+	return ctx, repo, svc, logger
+}
+
+func initAPIKeyServiceWithWebhooks() (context.Context, *tests.MockAPIKeyRepo, *tests.MockWebhookRepo, service.APIKeyService, *tests.MockLogger) {
+	ctx := context.Background()
+	repo := new(tests.MockAPIKeyRepo)
+	webhooks := new(tests.MockWebhookRepo)
+	logger := new(tests.MockLogger)
+	svc := service.NewAPIKeyService(repo, webhooks, tests.PassthroughTxManager{}, logger)
+	return ctx, repo, webhooks, svc, logger
+}
@@ -0,0 +1,134 @@
+package service_tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/tests"
+	"testing"
+	"time"
+)
+
+func TestAuditService_Verify_ValidChain(t *testing.T) {
+	ctx, repo, svc, logger := initAuditService()
+
+	entries := buildAuditChain(3)
+	repo.On("List", ctx, int64(0), mock.Anything).Return(entries, nil)
+	logger.On("Info", "audit chain verified", "entries", int64(3)).Return()
+
+	result, err := svc.Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, int64(3), result.EntriesChecked)
+	assert.Nil(t, result.BrokenAtId)
+	logger.AssertExpectations(t)
+}
+
+func TestAuditService_Verify_TamperedEntry(t *testing.T) {
+	ctx, repo, svc, logger := initAuditService()
+
+	entries := buildAuditChain(3)
+	entries[1].After = json.RawMessage(`{"balance":1000000}`)
+
+	repo.On("List", ctx, int64(0), mock.Anything).Return(entries, nil)
+	logger.On("Error", "audit chain broken", "id", int64(2), "reason", "hash does not match entry contents").Return()
+
+	result, err := svc.Verify(ctx)
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, int64(2), *result.BrokenAtId)
+	assert.Equal(t, int64(1), result.EntriesChecked)
+	logger.AssertExpectations(t)
+}
+
+func TestAuditService_Verify_DeletedEntry(t *testing.T) {
+	ctx, repo, svc, logger := initAuditService()
+
+	entries := buildAuditChain(3)
+	entries = append(entries[:1], entries[2])
+
+	repo.On("List", ctx, int64(0), mock.Anything).Return(entries, nil)
+	logger.On("Error", "audit chain broken", "id", int64(3), "reason", "prev_hash does not match the hash of the previous entry").Return()
+
+	result, err := svc.Verify(ctx)
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, int64(3), *result.BrokenAtId)
+	logger.AssertExpectations(t)
+}
+
+func TestAuditService_Verify_RepoError(t *testing.T) {
+	ctx, repo, svc, logger := initAuditService()
+
+	repo.On("List", ctx, int64(0), mock.Anything).Return([]model.AuditEntry{}, errors.New("db error"))
+	logger.On("Error", "failed to list audit entries", "afterId", int64(0), "error", mock.Anything).Return()
+
+	_, err := svc.Verify(ctx)
+	require.Error(t, err)
+	logger.AssertExpectations(t)
+}
+
+func buildAuditChain(n int) []model.AuditEntry {
+	entries := make([]model.AuditEntry, 0, n)
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		entry := model.AuditEntry{
+			Id:         int64(i),
+			Actor:      model.ActorWorker,
+			Action:     model.AuditActionBalanceUpdated,
+			EntityType: model.AuditEntityUser,
+			EntityId:   "7141b92f-a8c8-471e-83e5-7fc72da61cb9",
+			Before:     json.RawMessage(`{"balance":100}`),
+			After:      json.RawMessage(`{"balance":90}`),
+			CreatedAt:  time.Date(2025, 1, 1, 0, 0, i, 0, time.UTC),
+			PrevHash:   prevHash,
+		}
+		entry.Hash = entry.ComputeHash()
+		prevHash = entry.Hash
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func initAuditService() (context.Context, *tests.MockAuditRepo, service.AuditService, *tests.MockLogger) {
+	ctx := context.Background()
+	repo := new(tests.MockAuditRepo)
+	logger := new(tests.MockLogger)
+	svc := service.NewAuditService(repo, logger)
+	return ctx, repo, svc, logger
+}
+
+func TestAuditService_Record(t *testing.T) {
+	ctx, repo, svc, _ := initAuditService()
+	ctx = model.WithActor(ctx, "apikey:admin")
+
+	repo.On("Append", ctx, mock.MatchedBy(func(entry model.AuditEntry) bool {
+		return entry.Actor == "apikey:admin" &&
+			entry.Action == model.AuditActionLogLevelChanged &&
+			entry.EntityType == model.AuditEntitySetting &&
+			entry.EntityId == "log.level" &&
+			string(entry.Before) == `{"level":"info"}` &&
+			string(entry.After) == `{"level":"debug"}`
+	})).Return(model.AuditEntry{}, nil)
+
+	err := svc.Record(ctx, model.AuditActionLogLevelChanged, model.AuditEntitySetting, "log.level",
+		map[string]string{"level": "info"}, map[string]string{"level": "debug"})
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestAuditService_Record_RepoError(t *testing.T) {
+	ctx, repo, svc, logger := initAuditService()
+
+	repo.On("Append", ctx, mock.Anything).Return(model.AuditEntry{}, errors.New("db error"))
+	logger.On("Error", "failed to append audit entry", "action", model.AuditActionLogLevelChanged, "error", mock.Anything).Return()
+
+	err := svc.Record(ctx, model.AuditActionLogLevelChanged, model.AuditEntitySetting, "log.level", nil, nil)
+	require.Error(t, err)
+	logger.AssertExpectations(t)
+}
@@ -0,0 +1,91 @@
+package service_tests
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/tests"
+	"testing"
+)
+
+func TestReconciliationService_Reconcile_Balanced(t *testing.T) {
+	ctx, repo, svc, logger := initReconciliationService()
+
+	repo.On("GetAccountLedgers", ctx).Return([]model.AccountLedger{
+		{UserId: uuid.New(), Balance: 990, InitialBalance: 1000, Outgoing: 10},
+		{UserId: uuid.New(), Balance: 510, InitialBalance: 500, Incoming: 10},
+	}, nil)
+	logger.On("Info", "reconciliation passed", "accounts", 2).Return()
+
+	report, err := svc.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.Balanced)
+	assert.True(t, report.MoneyConserved)
+	assert.Empty(t, report.Discrepancies)
+	assert.Equal(t, 1500.0, report.TotalBalance)
+	logger.AssertExpectations(t)
+}
+
+func TestReconciliationService_Reconcile_HalfAppliedTransfer(t *testing.T) {
+	ctx, repo, svc, logger := initReconciliationService()
+
+	// The sender was debited but the transfer ended up FAILED, so the
+	// transactions table does not account for the missing 10.
+	sender := uuid.New()
+	repo.On("GetAccountLedgers", ctx).Return([]model.AccountLedger{
+		{UserId: sender, Balance: 990, InitialBalance: 1000},
+		{UserId: uuid.New(), Balance: 500, InitialBalance: 500},
+	}, nil)
+	logger.On("Warn", "reconciliation found discrepancies", "accounts", 2, "discrepancies", 1, "moneyConserved", false).Return()
+
+	report, err := svc.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.False(t, report.Balanced)
+	assert.False(t, report.MoneyConserved)
+	require.Len(t, report.Discrepancies, 1)
+	assert.Equal(t, model.AccountDiscrepancy{
+		UserId:          sender,
+		StoredBalance:   990,
+		ExpectedBalance: 1000,
+		Difference:      -10,
+	}, report.Discrepancies[0])
+	logger.AssertExpectations(t)
+}
+
+func TestReconciliationService_Reconcile_IgnoresFloatNoise(t *testing.T) {
+	ctx, repo, svc, logger := initReconciliationService()
+
+	repo.On("GetAccountLedgers", ctx).Return([]model.AccountLedger{
+		{UserId: uuid.New(), Balance: 0.3, InitialBalance: 0.1, Incoming: 0.2},
+		{UserId: uuid.New(), Balance: 0, InitialBalance: 0.2, Outgoing: 0.2},
+	}, nil)
+	logger.On("Info", "reconciliation passed", "accounts", 2).Return()
+
+	report, err := svc.Reconcile(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.Balanced)
+}
+
+func TestReconciliationService_Reconcile_RepoError(t *testing.T) {
+	ctx, repo, svc, logger := initReconciliationService()
+
+	repo.On("GetAccountLedgers", ctx).Return([]model.AccountLedger{}, errors.New("db error"))
+	logger.On("Error", "failed to get account ledgers", "error", mock.Anything).Return()
+
+	_, err := svc.Reconcile(ctx)
+	require.Error(t, err)
+	logger.AssertExpectations(t)
+}
+
+func initReconciliationService() (context.Context, *tests.MockLedgerRepo, service.ReconciliationService, *tests.MockLogger) {
+	ctx := context.Background()
+	repo := new(tests.MockLedgerRepo)
+	logger := new(tests.MockLogger)
+	svc := service.NewReconciliationService(repo, logger)
+	return ctx, repo, svc, logger
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
 	"moneyTransfer/internal/domain/model"
 	"moneyTransfer/internal/domain/service"
 	"moneyTransfer/internal/queue"
@@ -31,8 +32,8 @@ func TestTransferService_GetTransactionsByUserId_Success(t *testing.T) {
 		},
 	}
 
-	transferRepo.On("GetTransactionsByUserId", ctx, userId).Return(expectedTransactions, nil)
-	logger.On("Info", "transactions retrieved", "transactions", expectedTransactions).Return()
+	transferRepo.On("GetTransactionsByUserId", mock.Anything, userId).Return(expectedTransactions, nil)
+	logger.On("Info", "transactions retrieved", "transactions", expectedTransactions, "user_id", userId).Return()
 
 	transactions, err := svc.GetTransactionsByUserId(ctx, userId)
 	require.NoError(t, err)
@@ -47,10 +48,10 @@ func TestTransferService_GetTransactionsByUserId_Error(t *testing.T) {
 
 	userId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
 
-	transferRepo.On("GetTransactionsByUserId", ctx, userId).
+	transferRepo.On("GetTransactionsByUserId", mock.Anything, userId).
 		Return(make([]model.Transaction, 0), errors.New("db error"))
 
-	logger.On("Error", "failed to get transactions by user id", "userId", userId, "error", mock.Anything).Return()
+	logger.On("Error", "failed to get transactions by user id", "error", mock.Anything, "user_id", userId).Return()
 
 	transactions, err := svc.GetTransactionsByUserId(ctx, userId)
 	assert.Error(t, err)
@@ -60,29 +61,46 @@ func TestTransferService_GetTransactionsByUserId_Error(t *testing.T) {
 	logger.AssertExpectations(t)
 }
 
+func TestTransferService_GetTransaction_NotFound(t *testing.T) {
+	ctx, transferRepo, svc, logger := inittransferService()
+
+	id := uuid.New()
+	transferRepo.On("GetTransactionById", mock.Anything, id.String()).
+		Return(model.Transaction{}, apperrors.NotFound(apperrors.CodeTransactionNotFound, "transaction not found"))
+	logger.On("Error", "failed to get transaction", "error", mock.Anything, "transaction_id", id).Return()
+
+	_, err := svc.GetTransaction(ctx, id)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	transferRepo.AssertExpectations(t)
+	logger.AssertExpectations(t)
+}
+
 func TestTransferService_CreateTransfer_AmountLessOrEqualZero(t *testing.T) {
 	ctx, _, svc, logger := inittransferService()
 
 	fromId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
 	toId := "ed9c2b61-3908-413b-b355-a6c36d1a0cb3"
 
-	logger.On("Warn", "invalid transfer amount", "amount", 0.0, "from", fromId, "to", toId).Return()
+	logger.On("Warn", "invalid transfer request", "from", fromId, "to", toId, "amount", 0.0, mock.Anything, mock.Anything).Return()
 
 	id, err := svc.CreateTransfer(ctx, fromId, toId, 0)
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, apperrors.ErrValidation)
 	assert.Equal(t, uuid.Nil, id)
 	logger.AssertExpectations(t)
 }
 
 func TestTransferService_TransferService_CreateTransfer_RepoError(t *testing.T) {
-	ctx, transferRepo, svc, logger := inittransferService()
+	ctx, transferRepo, userRepo, svc, logger := inittransferServiceWithUsers()
 
 	fromId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
 	toId := "ed9c2b61-3908-413b-b355-a6c36d1a0cb3"
+	stubAccount(userRepo, fromId, 500)
+	stubAccount(userRepo, toId, 0)
 	amount := 100.0
 
-	transferRepo.On("CreateTransfer", ctx, mock.Anything).Return(errors.New("db error")).Once()
-	logger.On("Error", "failed to create transfer", "tx", mock.Anything, "error", mock.Anything).Return()
+	transferRepo.On("CreateTransfer", mock.Anything, mock.Anything).Return(errors.New("db error")).Once()
+	logger.On("Error", "failed to create transfer", "tx", mock.Anything, "error", mock.Anything, "user_id", mock.Anything, "transaction_id", mock.Anything).Return()
 
 	id, err := svc.CreateTransfer(ctx, fromId, toId, amount)
 	assert.Error(t, err)
@@ -93,44 +111,205 @@ func TestTransferService_TransferService_CreateTransfer_RepoError(t *testing.T)
 }
 
 func TestTransferService_TransferService_CreateTransfer_Success(t *testing.T) {
-	ctx, transferRepo, svc, logger := inittransferService()
+	ctx, transferRepo, userRepo, publisher, svc, logger := inittransferServiceWithPublisher()
 
 	fromId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
 	toId := "ed9c2b61-3908-413b-b355-a6c36d1a0cb3"
+	stubAccount(userRepo, fromId, 500)
+	stubAccount(userRepo, toId, 0)
 	amount := 100.0
 
-	transferRepo.On("CreateTransfer", ctx, mock.Anything).Return(nil).Once()
-	logger.On("Info", "transfer created", "tx", mock.Anything).Return()
-	logger.On("Info", "enqueuing transfer job", "job", mock.Anything).Return()
-
-	select {
-	case <-queue.JobsChan:
-	default:
-	}
+	var published queue.TransferJob
+	transferRepo.On("CreateTransfer", mock.Anything, mock.Anything).Return(nil).Once()
+	publisher.On("Publish", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		published = args.Get(1).(queue.TransferJob)
+	}).Return(nil).Once()
+	logger.On("Info", "transfer created", "tx", mock.Anything, "user_id", uuid.MustParse(fromId), "transaction_id", mock.Anything).Return()
+	logger.On("Info", "enqueuing transfer job", "job", mock.Anything, "user_id", uuid.MustParse(fromId), "transaction_id", mock.Anything).Return()
 
-	id, err := svc.CreateTransfer(ctx, fromId, toId, amount)
+	id, err := svc.CreateTransfer(model.WithRequestId(ctx, "req-1"), fromId, toId, amount)
 	require.NoError(t, err)
 	require.NotEqual(t, uuid.Nil, id)
 
-	select {
-	case job := <-queue.JobsChan:
-		assert.Equal(t, uuid.MustParse(fromId), job.SenderId)
-		assert.Equal(t, uuid.MustParse(toId), job.ReceiverId)
-		assert.Equal(t, amount, job.Amount)
-		assert.Equal(t, id, job.TransactionId)
-	case <-time.After(time.Second):
-		t.Fatal("expected job in queue but got none")
-	}
+	assert.Equal(t, uuid.MustParse(fromId), published.SenderId)
+	assert.Equal(t, uuid.MustParse(toId), published.ReceiverId)
+	assert.Equal(t, amount, published.Amount)
+	assert.Equal(t, id, published.TransactionId)
+	// The worker logs under the id of the request that created the transfer.
+	assert.Equal(t, "req-1", published.RequestId)
 
+	transferRepo.AssertExpectations(t)
+	publisher.AssertExpectations(t)
+	logger.AssertExpectations(t)
+}
+
+func TestTransferService_CreateTransfer_QueueFull(t *testing.T) {
+	ctx, transferRepo, userRepo, publisher, svc, logger := inittransferServiceWithPublisher()
+
+	fromId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+	toId := "ed9c2b61-3908-413b-b355-a6c36d1a0cb3"
+	stubAccount(userRepo, fromId, 500)
+	stubAccount(userRepo, toId, 0)
+
+	var created model.Transaction
+	transferRepo.On("CreateTransfer", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(1).(model.Transaction)
+	}).Return(nil).Once()
+	transferRepo.On("DeletePendingTransaction", mock.Anything, mock.Anything).Return(nil).Once()
+	publisher.On("Publish", mock.Anything, mock.Anything).Return(queue.ErrQueueFull).Once()
+	logger.On("Info", "transfer created", "tx", mock.Anything, "user_id", mock.Anything, "transaction_id", mock.Anything).Return()
+	logger.On("Info", "enqueuing transfer job", "job", mock.Anything, "user_id", mock.Anything, "transaction_id", mock.Anything).Return()
+	logger.On("Error", "failed to enqueue transfer job", "job", mock.Anything, "error", queue.ErrQueueFull, "user_id", mock.Anything, "transaction_id", mock.Anything).Return()
+
+	id, err := svc.CreateTransfer(ctx, fromId, toId, 100.0)
+	require.ErrorIs(t, err, queue.ErrQueueFull)
+	assert.Equal(t, uuid.Nil, id)
+
+	transferRepo.AssertCalled(t, "DeletePendingTransaction", mock.Anything, created.Id.String())
 	transferRepo.AssertExpectations(t)
 	logger.AssertExpectations(t)
 }
 
 func inittransferService() (context.Context, *tests.MockTransferRepo, service.TransferService, *tests.MockLogger) {
+	ctx, transferRepo, _, svc, logger := inittransferServiceWithUsers()
+	return ctx, transferRepo, svc, logger
+}
+
+func inittransferServiceWithUsers() (context.Context, *tests.MockTransferRepo, *tests.MockUserRepo, service.TransferService, *tests.MockLogger) {
+	ctx, transferRepo, userRepo, _, svc, logger := inittransferServiceWithPublisher()
+	return ctx, transferRepo, userRepo, svc, logger
+}
+
+func inittransferServiceWithPublisher() (context.Context, *tests.MockTransferRepo, *tests.MockUserRepo, *tests.MockPublisher, service.TransferService, *tests.MockLogger) {
 	ctx := context.Background()
 	transferRepo := new(tests.MockTransferRepo)
 	userRepo := new(tests.MockUserRepo)
+	publisher := new(tests.MockPublisher)
 	logger := new(tests.MockLogger)
-	svc := service.NewTransferService(transferRepo, userRepo, logger)
-	return ctx, transferRepo, svc, logger
+	svc := service.NewTransferService(transferRepo, userRepo, publisher, queue.NewCompletions(), logger)
+	return ctx, transferRepo, userRepo, publisher, svc, logger
+}
+
+func stubAccount(userRepo *tests.MockUserRepo, id string, balance float64) {
+	userRepo.On("GetById", mock.Anything, id).Return(model.User{Id: uuid.MustParse(id), Balance: balance}, nil)
+}
+
+func TestTransferService_CreateTransfer_InvalidFields(t *testing.T) {
+	cases := []struct {
+		name   string
+		from   string
+		to     string
+		amount float64
+		fields map[string]string
+	}{
+		{"malformed ids", "not-a-uuid", "", 10, map[string]string{"from": apperrors.CodeInvalidUUID, "to": apperrors.CodeRequired}},
+		{"nil uuid", uuid.Nil.String(), "ed9c2b61-3908-413b-b355-a6c36d1a0cb3", 10, map[string]string{"from": apperrors.CodeInvalidUUID}},
+		{"self transfer", "7141b92f-a8c8-471e-83e5-7fc72da61cb9", "7141b92f-a8c8-471e-83e5-7fc72da61cb9", 10, map[string]string{"to": apperrors.CodeSelfTransfer}},
+		{"too many decimals", "7141b92f-a8c8-471e-83e5-7fc72da61cb9", "ed9c2b61-3908-413b-b355-a6c36d1a0cb3", 10.005, map[string]string{"amount": apperrors.CodeTooManyDecimals}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, transferRepo, userRepo, svc, logger := inittransferServiceWithUsers()
+			logger.On("Warn", "invalid transfer request", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return()
+
+			id, err := svc.CreateTransfer(ctx, tc.from, tc.to, tc.amount)
+			require.ErrorIs(t, err, apperrors.ErrValidation)
+			assert.Equal(t, uuid.Nil, id)
+
+			appErr, ok := apperrors.As(err)
+			require.True(t, ok)
+			got := map[string]string{}
+			for _, f := range appErr.Fields {
+				got[f.Field] = f.Code
+			}
+			assert.Equal(t, tc.fields, got)
+
+			userRepo.AssertNotCalled(t, "GetById", mock.Anything, mock.Anything)
+			transferRepo.AssertNotCalled(t, "CreateTransfer", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestTransferService_CreateTransfer_UnknownAccount(t *testing.T) {
+	ctx, transferRepo, userRepo, svc, logger := inittransferServiceWithUsers()
+
+	fromId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+	toId := "ed9c2b61-3908-413b-b355-a6c36d1a0cb3"
+
+	stubAccount(userRepo, fromId, 500)
+	userRepo.On("GetById", mock.Anything, toId).Return(model.User{}, apperrors.NotFound(apperrors.CodeUserNotFound, "user not found"))
+	logger.On("Warn", "transfer references unknown accounts", "from", fromId, "to", toId, "fields", mock.Anything).Return()
+
+	_, err := svc.CreateTransfer(ctx, fromId, toId, 10)
+	require.ErrorIs(t, err, apperrors.ErrValidation)
+
+	appErr, _ := apperrors.As(err)
+	require.Len(t, appErr.Fields, 1)
+	assert.Equal(t, "to", appErr.Fields[0].Field)
+	assert.Equal(t, apperrors.CodeAccountNotFound, appErr.Fields[0].Code)
+	transferRepo.AssertNotCalled(t, "CreateTransfer", mock.Anything, mock.Anything)
+}
+
+func TestTransferService_CreateTransfer_InsufficientFunds(t *testing.T) {
+	ctx, transferRepo, userRepo, svc, logger := inittransferServiceWithUsers()
+
+	fromId := "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+	toId := "ed9c2b61-3908-413b-b355-a6c36d1a0cb3"
+
+	stubAccount(userRepo, fromId, 50)
+	stubAccount(userRepo, toId, 0)
+	logger.On("Warn", "insufficient funds for transfer", "from", fromId, "amount", 100.0).Return()
+
+	_, err := svc.CreateTransfer(ctx, fromId, toId, 100)
+	require.ErrorIs(t, err, apperrors.ErrInsufficientFunds)
+	transferRepo.AssertNotCalled(t, "CreateTransfer", mock.Anything, mock.Anything)
+}
+
+func TestTransferService_WaitForTransfer_WokenByWorker(t *testing.T) {
+	ctx := context.Background()
+	transferRepo := new(tests.MockTransferRepo)
+	completions := queue.NewCompletions()
+	svc := service.NewTransferService(transferRepo, new(tests.MockUserRepo), new(tests.MockPublisher), completions, new(tests.MockLogger))
+
+	id := uuid.New()
+	// The worker finishes right after the first read, which still sees PENDING.
+	transferRepo.On("GetTransactionById", mock.Anything, id.String()).
+		Run(func(mock.Arguments) { go completions.Complete(id) }).
+		Return(model.Transaction{Id: id, Status: model.StatusPending}, nil).Once()
+	transferRepo.On("GetTransactionById", mock.Anything, id.String()).Return(model.Transaction{Id: id, Status: model.StatusSuccess}, nil)
+
+	start := time.Now()
+	tx, err := svc.WaitForTransfer(ctx, id, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusSuccess, tx.Status)
+	assert.Less(t, time.Since(start), 250*time.Millisecond, "signalled, not polled")
+}
+
+func TestTransferService_WaitForTransfer_TimesOutPending(t *testing.T) {
+	ctx := context.Background()
+	transferRepo := new(tests.MockTransferRepo)
+	logger := new(tests.MockLogger)
+	svc := service.NewTransferService(transferRepo, new(tests.MockUserRepo), new(tests.MockPublisher), queue.NewCompletions(), logger)
+
+	id := uuid.New()
+	transferRepo.On("GetTransactionById", mock.Anything, id.String()).Return(model.Transaction{Id: id, Status: model.StatusPending}, nil)
+	logger.On("Info", "transfer still pending after wait", "timeout", 50*time.Millisecond, "transaction_id", id).Return()
+
+	tx, err := svc.WaitForTransfer(ctx, id, 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, model.StatusPending, tx.Status)
+	logger.AssertExpectations(t)
+}
+
+func TestTransferService_WaitForTransfer_NotFound(t *testing.T) {
+	ctx, transferRepo, _, svc, logger := inittransferServiceWithUsers()
+
+	id := uuid.New()
+	transferRepo.On("GetTransactionById", mock.Anything, id.String()).
+		Return(model.Transaction{}, apperrors.NotFound(apperrors.CodeTransactionNotFound, "transaction not found"))
+	logger.On("Error", "failed to get transaction while waiting", "error", mock.Anything, "transaction_id", id).Return()
+
+	_, err := svc.WaitForTransfer(ctx, id, time.Second)
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
 }
@@ -0,0 +1,215 @@
+package service_tests
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/internal/webhook"
+	"moneyTransfer/tests"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookService_Register_Success(t *testing.T) {
+	ctx, webhooks, _, svc, logger := initWebhookService()
+
+	var stored model.Webhook
+	webhooks.On("Create", ctx, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(model.Webhook)
+	}).Return(nil)
+	logger.On("Info", "webhook registered", "webhookId", mock.Anything, "ownerId", webhookOwner.Id, "url", "https://example.com/hook", "events", mock.Anything).Return()
+
+	account := uuid.New()
+	webhook, err := svc.Register(ctx, "https://example.com/hook",
+		[]string{model.EventTransferSucceeded, model.EventTransferFailed, model.EventTransferSucceeded},
+		[]string{account.String(), account.String()})
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(webhook.Secret, "whsec_"))
+	assert.Equal(t, webhook.Secret, stored.Secret)
+	assert.Equal(t, webhookOwner.Id, stored.OwnerId)
+	assert.Equal(t, []string{model.EventTransferFailed, model.EventTransferSucceeded}, stored.Events)
+	assert.Equal(t, []uuid.UUID{account}, stored.Accounts)
+	logger.AssertExpectations(t)
+}
+
+func TestWebhookService_Register_RejectsInvalidRequests(t *testing.T) {
+	ctx, webhooks, _, svc, _ := initWebhookService()
+
+	for name, request := range map[string]struct {
+		url      string
+		events   []string
+		accounts []string
+	}{
+		"relative url":    {"/hook", []string{model.EventTransferSucceeded}, nil},
+		"ftp url":         {"ftp://example.com/hook", []string{model.EventTransferSucceeded}, nil},
+		"no events":       {"https://example.com/hook", nil, nil},
+		"unknown event":   {"https://example.com/hook", []string{model.EventBalanceChanged}, nil},
+		"invalid account": {"https://example.com/hook", []string{model.EventTransferSucceeded}, []string{"alice"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.Register(ctx, request.url, request.events, request.accounts)
+			require.Error(t, err)
+			appErr, ok := apperrors.As(err)
+			require.True(t, ok)
+			assert.Equal(t, apperrors.CodeInvalidWebhookRequest, appErr.Code)
+		})
+	}
+	webhooks.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestWebhookService_Register_RejectsNonPublicHosts(t *testing.T) {
+	ctx, webhooks, _, svc, _ := initWebhookService()
+
+	for url, want := range map[string]error{
+		"http://127.0.0.1:8080/hook":               webhook.ErrNonPublicAddress,
+		"http://[::1]/hook":                        webhook.ErrNonPublicAddress,
+		"http://169.254.169.254/latest/meta-data/": webhook.ErrNonPublicAddress,
+		"http://192.168.1.10/hook":                 webhook.ErrNonPublicAddress,
+		"http://100.64.0.1/hook":                   webhook.ErrNonPublicAddress,
+		"http://0.0.0.0/hook":                      webhook.ErrNonPublicAddress,
+		"https://metadata.example/hook":            webhook.ErrNonPublicAddress,
+		"https://intranet.example/hook":            webhook.ErrNonPublicAddress,
+		"https://unknown.example/hook":             webhook.ErrUnresolvableHost,
+	} {
+		t.Run(url, func(t *testing.T) {
+			_, err := svc.Register(ctx, url, []string{model.EventTransferSucceeded}, nil)
+			require.ErrorIs(t, err, want)
+		})
+	}
+	webhooks.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestWebhookService_Register_AllowsAllowlistedHost(t *testing.T) {
+	ctx, webhooks, _, svc, logger := initWebhookService()
+
+	webhooks.On("Create", ctx, mock.Anything).Return(nil)
+	logger.On("Info", "webhook registered", "webhookId", mock.Anything, "ownerId", webhookOwner.Id, "url", "http://LOCALHOST:9000/hook", "events", mock.Anything).Return()
+
+	_, err := svc.Register(ctx, "http://LOCALHOST:9000/hook", []string{model.EventTransferSucceeded}, nil)
+	require.NoError(t, err)
+	webhooks.AssertExpectations(t)
+}
+
+func TestWebhookService_List_OwnWebhooksOnly(t *testing.T) {
+	ctx, webhooks, _, svc, _ := initWebhookService()
+
+	own := []model.Webhook{{Id: uuid.New(), OwnerId: webhookOwner.Id}}
+	webhooks.On("ListByOwner", ctx, webhookOwner.Id.String()).Return(own, nil)
+
+	listed, err := svc.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, own, listed)
+	webhooks.AssertNotCalled(t, "List", mock.Anything)
+}
+
+func TestWebhookService_List_AdminSeesAll(t *testing.T) {
+	_, webhooks, _, svc, _ := initWebhookService()
+	ctx := model.WithAPIKey(context.Background(), model.APIKey{Id: uuid.New(), Scopes: []string{model.ScopeAdmin}})
+
+	all := []model.Webhook{{Id: uuid.New(), OwnerId: webhookOwner.Id}, {Id: uuid.New(), OwnerId: uuid.New()}}
+	webhooks.On("List", ctx).Return(all, nil)
+
+	listed, err := svc.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, all, listed)
+}
+
+func TestWebhookService_Delete_NotFound(t *testing.T) {
+	ctx, webhooks, _, svc, _ := initWebhookService()
+
+	webhooks.On("GetById", ctx, "missing").Return(model.Webhook{}, apperrors.NotFound(apperrors.CodeWebhookNotFound, "webhook not found"))
+
+	err := svc.Delete(ctx, "missing")
+	require.ErrorIs(t, err, service.ErrWebhookNotFound)
+	webhooks.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestWebhookService_Delete_WebhookOfAnotherKey(t *testing.T) {
+	ctx, webhooks, _, svc, _ := initWebhookService()
+
+	webhook := model.Webhook{Id: uuid.New(), OwnerId: uuid.New()}
+	webhooks.On("GetById", ctx, webhook.Id.String()).Return(webhook, nil)
+
+	err := svc.Delete(ctx, webhook.Id.String())
+	require.ErrorIs(t, err, service.ErrWebhookNotFound, "another key's webhook is not revealed")
+	webhooks.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestWebhookService_ListDeliveries_UnknownWebhook(t *testing.T) {
+	ctx, webhooks, deliveries, svc, _ := initWebhookService()
+
+	webhooks.On("GetById", ctx, "missing").Return(model.Webhook{}, apperrors.NotFound(apperrors.CodeWebhookNotFound, "webhook not found"))
+
+	_, err := svc.ListDeliveries(ctx, "missing")
+	require.ErrorIs(t, err, service.ErrWebhookNotFound)
+	deliveries.AssertNotCalled(t, "ListByWebhook", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWebhookService_Redeliver_ResetsDelivery(t *testing.T) {
+	ctx, webhooks, deliveries, svc, logger := initWebhookService()
+
+	webhook := model.Webhook{Id: uuid.New(), OwnerId: webhookOwner.Id}
+	deliveredAt := time.Now()
+	delivery := model.WebhookDelivery{
+		Id:          uuid.New(),
+		WebhookId:   webhook.Id,
+		Status:      model.DeliveryFailed,
+		Attempts:    8,
+		LastError:   "unexpected response status 500",
+		DeliveredAt: &deliveredAt,
+	}
+
+	webhooks.On("GetById", ctx, webhook.Id.String()).Return(webhook, nil)
+	deliveries.On("GetById", ctx, delivery.Id.String()).Return(delivery, nil)
+	deliveries.On("Update", ctx, mock.MatchedBy(func(d model.WebhookDelivery) bool {
+		return d.Id == delivery.Id && d.Status == model.DeliveryPending && d.Attempts == 0 &&
+			d.LastError == "" && d.DeliveredAt == nil && !d.NextAttemptAt.After(time.Now())
+	})).Return(nil)
+	logger.On("Info", "webhook redelivery queued", "webhookId", webhook.Id.String(), "deliveryId", delivery.Id.String()).Return()
+
+	redelivered, err := svc.Redeliver(ctx, webhook.Id.String(), delivery.Id.String())
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryPending, redelivered.Status)
+	deliveries.AssertExpectations(t)
+}
+
+func TestWebhookService_Redeliver_DeliveryOfAnotherWebhook(t *testing.T) {
+	ctx, webhooks, deliveries, svc, _ := initWebhookService()
+
+	webhook := model.Webhook{Id: uuid.New(), OwnerId: webhookOwner.Id}
+	delivery := model.WebhookDelivery{Id: uuid.New(), WebhookId: uuid.New()}
+
+	webhooks.On("GetById", ctx, webhook.Id.String()).Return(webhook, nil)
+	deliveries.On("GetById", ctx, delivery.Id.String()).Return(delivery, nil)
+
+	_, err := svc.Redeliver(ctx, webhook.Id.String(), delivery.Id.String())
+	require.ErrorIs(t, err, service.ErrDeliveryNotFound)
+	deliveries.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// webhookHosts is the DNS the webhook service tests see.
+var webhookHosts = tests.StaticResolver{
+	"example.com":      {"93.184.215.14", "2606:2800:21f:cb07:6820:80da:af6b:8b2c"},
+	"localhost":        {"127.0.0.1", "::1"},
+	"intranet.example": {"93.184.215.15", "10.1.2.3"},
+	"metadata.example": {"169.254.169.254"},
+}
+
+// webhookOwner is the key the webhook service tests call with.
+var webhookOwner = model.APIKey{Id: uuid.MustParse("0f0c7a52-3c55-4b39-9b1e-6a4f3f4a8d11"), Scopes: []string{model.ScopeWebhooks}}
+
+func initWebhookService() (context.Context, *tests.MockWebhookRepo, *tests.MockWebhookDeliveryRepo, service.WebhookService, *tests.MockLogger) {
+	ctx := model.WithAPIKey(context.Background(), webhookOwner)
+	webhooks := new(tests.MockWebhookRepo)
+	deliveries := new(tests.MockWebhookDeliveryRepo)
+	logger := new(tests.MockLogger)
+	svc := service.NewWebhookService(webhooks, deliveries, webhook.NewGuard([]string{"localhost"}, webhookHosts), logger)
+	return ctx, webhooks, deliveries, svc, logger
+}
@@ -0,0 +1,323 @@
+package sqlite_tests
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/domain/apperrors"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/internal/outbox"
+	"moneyTransfer/internal/queue"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/internal/repository/sqlite"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	aliceId = uuid.MustParse("7141b92f-a8c8-471e-83e5-7fc72da61cb9")
+	joeId   = uuid.MustParse("861d7697-b717-43e8-95a2-1a74f9a36ab1")
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func initDB(t *testing.T) *sql.DB {
+	db, err := sqlite.NewSQLiteClient(config.SQLiteConfig{
+		Path:        filepath.Join(t.TempDir(), "test.db"),
+		BusyTimeout: 5 * time.Second,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := sqlite.NewMigrator(db, discardLog)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestMigrations_UpAndDown(t *testing.T) {
+	db := initDB(t)
+	ctx := context.Background()
+
+	migrator, err := sqlite.NewMigrator(db, discardLog)
+	require.NoError(t, err)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "second run is a no-op")
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.True(t, status.Applied, "migration %d", status.Version)
+	}
+
+	reverted, err := migrator.Down(ctx, len(statuses))
+	require.NoError(t, err)
+	assert.Len(t, reverted, len(statuses))
+
+	_, err = db.ExecContext(ctx, `SELECT 1 FROM users`)
+	assert.Error(t, err)
+}
+
+func TestUserRepo_SeededBalanceAndNotFound(t *testing.T) {
+	repo := repository.NewLocklessUserRepository(initDB(t))
+	ctx := context.Background()
+
+	balance, err := repo.GetBalance(ctx, aliceId.String())
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, balance)
+
+	_, err = repo.GetById(ctx, uuid.NewString())
+	assert.True(t, errors.Is(err, sql.ErrNoRows))
+	assert.True(t, errors.Is(err, apperrors.ErrNotFound))
+
+	err = repo.UpdateBalance(ctx, uuid.NewString(), 1)
+	assert.True(t, errors.Is(err, apperrors.ErrNotFound))
+}
+
+func TestTransferRepo_RejectsUnknownAccounts(t *testing.T) {
+	repo := repository.NewTransferRepository(initDB(t))
+
+	err := repo.CreateTransfer(context.Background(), model.Transaction{
+		Id:         uuid.New(),
+		SenderId:   uuid.New(),
+		ReceiverId: joeId,
+		Amount:     1,
+		Status:     model.StatusPending,
+		CreatedAt:  time.Now(),
+	})
+
+	assert.Error(t, err, "foreign keys are enforced")
+}
+
+func TestTxManager_RollsBackOnError(t *testing.T) {
+	db := initDB(t)
+	userRepo := repository.NewLocklessUserRepository(db)
+	txManager := repository.NewTxManager(db)
+	ctx := context.Background()
+
+	failure := errors.New("boom")
+	err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		require.NoError(t, userRepo.UpdateBalance(ctx, aliceId.String(), 0))
+		return failure
+	})
+
+	require.ErrorIs(t, err, failure)
+	balance, err := userRepo.GetBalance(ctx, aliceId.String())
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, balance)
+}
+
+func TestAuditLog_IsAppendOnly(t *testing.T) {
+	db := initDB(t)
+	auditRepo := repository.NewLocklessAuditRepository(db)
+	ctx := context.Background()
+
+	_, err := auditRepo.Append(ctx, model.AuditEntry{
+		Actor:      model.ActorSystem,
+		Action:     model.AuditActionBalanceUpdated,
+		EntityType: model.AuditEntityUser,
+		EntityId:   aliceId.String(),
+		CreatedAt:  time.Now(),
+	})
+	require.NoError(t, err)
+
+	_, err = db.ExecContext(ctx, `UPDATE audit_log SET actor = 'mallory'`)
+	assert.ErrorContains(t, err, "append-only")
+	_, err = db.ExecContext(ctx, `DELETE FROM audit_log`)
+	assert.ErrorContains(t, err, "append-only")
+}
+
+// TestConcurrentAuditedWrites checks that immediate transactions keep the
+// audit chain intact when writers race, as the advisory lock does on Postgres.
+func TestConcurrentAuditedWrites(t *testing.T) {
+	db := initDB(t)
+	auditRepo := repository.NewLocklessAuditRepository(db)
+	txManager := repository.NewTxManager(db)
+	userRepo := repository.NewAuditedUserRepository(repository.NewLocklessUserRepository(db), auditRepo, txManager)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+				balance, err := userRepo.GetBalance(ctx, joeId.String())
+				if err != nil {
+					return err
+				}
+				return userRepo.UpdateBalance(ctx, joeId.String(), balance+1)
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	balance, err := userRepo.GetBalance(ctx, joeId.String())
+	require.NoError(t, err)
+	assert.Equal(t, 10820.0, balance)
+
+	result, err := service.NewAuditService(auditRepo, discardLog).Verify(ctx)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+}
+
+// TestConcurrentDebits checks that racing debits neither lose an update nor
+// overdraw the account.
+func TestConcurrentDebits(t *testing.T) {
+	db := initDB(t)
+	txManager := repository.NewTxManager(db)
+	userRepo := repository.NewLocklessUserRepository(db)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	var debited atomic.Int32
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+				_, _, err := userRepo.Debit(ctx, aliceId.String(), 50)
+				return err
+			})
+			if err == nil {
+				debited.Add(1)
+				return
+			}
+			assert.ErrorIs(t, err, apperrors.ErrInsufficientFunds)
+		}()
+	}
+	wg.Wait()
+
+	balance, err := userRepo.GetBalance(ctx, aliceId.String())
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, balance)
+	assert.EqualValues(t, 20, debited.Load())
+}
+
+func TestWebhookDeliveries_ClaimAndCascade(t *testing.T) {
+	db := initDB(t)
+	ctx := context.Background()
+	webhooks := repository.NewWebhookRepository(db)
+	deliveries := repository.NewLocklessWebhookDeliveryRepository(db)
+
+	now := time.Now().UTC()
+	hook := model.Webhook{Id: uuid.New(), URL: "https://example.com/hook", Events: []string{model.EventTransferSucceeded}, Secret: "whsec_secret", CreatedAt: now}
+	require.NoError(t, webhooks.Create(ctx, hook))
+
+	due := model.WebhookDelivery{Id: uuid.New(), WebhookId: hook.Id, EventId: uuid.New(), EventType: model.EventTransferSucceeded,
+		Payload: []byte(`{}`), Status: model.DeliveryPending, NextAttemptAt: now.Add(-time.Second), CreatedAt: now}
+	later := due
+	later.Id, later.EventId, later.NextAttemptAt = uuid.New(), uuid.New(), now.Add(time.Hour)
+	require.NoError(t, deliveries.Create(ctx, due))
+	require.NoError(t, deliveries.Create(ctx, later))
+
+	duplicate := due
+	duplicate.Id = uuid.New()
+	require.NoError(t, deliveries.Create(ctx, duplicate), "a second delivery of the same event is ignored")
+
+	claimed, err := deliveries.Claim(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	assert.Equal(t, due.Id, claimed[0].Id)
+
+	claimed, err = deliveries.Claim(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Empty(t, claimed, "leased until the attempt is recorded")
+
+	require.NoError(t, webhooks.Delete(ctx, hook.Id.String()))
+	_, err = deliveries.GetById(ctx, due.Id.String())
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+}
+
+func TestOutbox_ClaimSkipsLeasedAccounts(t *testing.T) {
+	db := initDB(t)
+	ctx := context.Background()
+	repo := repository.NewOutboxRepository(db)
+
+	for _, account := range []string{"alice", "bob", "alice"} {
+		event, err := model.NewDomainEvent(model.EventBalanceChanged, account, model.BalanceChangedPayload{})
+		require.NoError(t, err)
+		_, err = repo.Append(ctx, event)
+		require.NoError(t, err)
+	}
+
+	now := time.Now().UTC()
+	claimed, err := repo.Claim(ctx, now, now.Add(time.Minute), 1)
+	require.NoError(t, err)
+	require.Len(t, claimed, 2, "every pending event of the oldest account")
+	assert.Equal(t, "alice", claimed[0].AccountId)
+	assert.Equal(t, []int64{1, 2}, []int64{claimed[0].AccountSequence, claimed[1].AccountSequence})
+
+	claimed, err = repo.Claim(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1, "alice is leased")
+	assert.Equal(t, "bob", claimed[0].AccountId)
+	assert.Equal(t, int64(1), claimed[0].AccountSequence)
+
+	require.NoError(t, repo.Release(ctx, "alice", now))
+	claimed, err = repo.Claim(ctx, now, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Len(t, claimed, 2, "alice is released, bob still leased")
+}
+
+func TestTransferFlow(t *testing.T) {
+	db := initDB(t)
+	auditRepo := repository.NewLocklessAuditRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	txManager := repository.NewTxManager(db)
+	userRepo := repository.NewAuditedUserRepository(
+		repository.NewEventedUserRepository(repository.NewLocklessUserRepository(db), outboxRepo, txManager), auditRepo, txManager)
+	transferRepo := repository.NewAuditedTransferRepository(
+		repository.NewEventedTransferRepository(repository.NewTransferRepository(db), outboxRepo, txManager), auditRepo, txManager)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	jobs := queue.NewChannelQueue(1, time.Second)
+	completions := queue.NewCompletions()
+	go queue.NewWorker(jobs, userRepo, transferRepo, txManager, completions, 0, discardLog).Run(ctx)
+	transferService := service.NewTransferService(transferRepo, userRepo, jobs, completions, discardLog)
+
+	txId, err := transferService.CreateTransfer(ctx, aliceId.String(), joeId.String(), 100)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		stored, err := transferRepo.GetTransactionById(ctx, txId.String())
+		return err == nil && stored.Status == model.StatusSuccess
+	}, 5*time.Second, 10*time.Millisecond)
+
+	transfers, err := transferService.GetTransactionsByUserId(ctx, joeId.String())
+	require.NoError(t, err)
+	assert.Len(t, transfers, 2, "seeded transfer and the new one")
+
+	report, err := service.NewReconciliationService(repository.NewLedgerRepository(db), discardLog).Reconcile(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.Balanced)
+
+	var published bytes.Buffer
+	relay := outbox.NewRelay(outboxRepo, txManager, outbox.NewWriterSink(&published), 10, time.Second, time.Minute, discardLog)
+	count, err := relay.RelayBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, count, "created, two balance changes and succeeded")
+	assert.Contains(t, published.String(), model.EventTransferSucceeded)
+
+	pending, err := outboxRepo.Pending(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
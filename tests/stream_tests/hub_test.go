@@ -0,0 +1,145 @@
+package stream_tests
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/stream"
+	"testing"
+	"time"
+)
+
+var (
+	aliceId = uuid.MustParse("7141b92f-a8c8-471e-83e5-7fc72da61cb9")
+	joeId   = uuid.MustParse("861d7697-b717-43e8-95a2-1a74f9a36ab1")
+)
+
+func transferEvent(t *testing.T, sequence int64, eventType string) model.DomainEvent {
+	event, err := model.NewTransferEvent(eventType, model.Transaction{
+		Id: uuid.New(), SenderId: aliceId, ReceiverId: joeId, Amount: 10, Status: model.StatusPending,
+	})
+	require.NoError(t, err)
+	event.Sequence = sequence
+	return event
+}
+
+func balanceEvent(t *testing.T, sequence int64, accountId uuid.UUID) model.DomainEvent {
+	event, err := model.NewDomainEvent(model.EventBalanceChanged, accountId.String(), model.BalanceChangedPayload{AccountId: accountId.String()})
+	require.NoError(t, err)
+	event.Sequence = sequence
+	return event
+}
+
+func receive(t *testing.T, sub *stream.Subscription) model.DomainEvent {
+	t.Helper()
+	select {
+	case event, ok := <-sub.Events:
+		require.True(t, ok, "subscription closed")
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return model.DomainEvent{}
+	}
+}
+
+func TestHub_RoutesTransfersToSenderAndReceiver(t *testing.T) {
+	hub := stream.NewHub(10, 10)
+	ctx := context.Background()
+
+	alice := hub.Subscribe(aliceId.String(), 0)
+	joe := hub.Subscribe(joeId.String(), 0)
+	defer alice.Close()
+	defer joe.Close()
+
+	require.NoError(t, hub.Publish(ctx, transferEvent(t, 1, model.EventTransferCreated)))
+	require.NoError(t, hub.Publish(ctx, balanceEvent(t, 2, aliceId)))
+
+	assert.Equal(t, int64(1), receive(t, alice).Sequence)
+	assert.Equal(t, int64(2), receive(t, alice).Sequence)
+	assert.Equal(t, int64(1), receive(t, joe).Sequence)
+	assert.Empty(t, joe.Events, "alice's balance is not joe's business")
+}
+
+func TestHub_ReplaysAfterResumePoint(t *testing.T) {
+	hub := stream.NewHub(10, 10)
+	ctx := context.Background()
+
+	for sequence := int64(1); sequence <= 3; sequence++ {
+		require.NoError(t, hub.Publish(ctx, balanceEvent(t, sequence, joeId)))
+	}
+	require.NoError(t, hub.Publish(ctx, balanceEvent(t, 4, aliceId)))
+
+	sub := hub.Subscribe(joeId.String(), 1)
+	defer sub.Close()
+
+	assert.True(t, sub.Complete)
+	require.Len(t, sub.Replay, 2)
+	assert.Equal(t, int64(2), sub.Replay[0].Sequence)
+	assert.Equal(t, int64(3), sub.Replay[1].Sequence)
+}
+
+func TestHub_IncompleteWhenHistoryWasDropped(t *testing.T) {
+	hub := stream.NewHub(2, 10)
+	ctx := context.Background()
+
+	assert.False(t, hub.Subscribe(joeId.String(), 5).Complete, "nothing seen yet")
+
+	for sequence := int64(10); sequence <= 13; sequence++ {
+		require.NoError(t, hub.Publish(ctx, balanceEvent(t, sequence, joeId)))
+	}
+
+	assert.False(t, hub.Subscribe(joeId.String(), 9).Complete, "10 and 11 left the history")
+	assert.False(t, hub.Subscribe(joeId.String(), 10).Complete)
+
+	sub := hub.Subscribe(joeId.String(), 11)
+	assert.True(t, sub.Complete)
+	assert.Len(t, sub.Replay, 2)
+}
+
+func TestHub_DropsSlowSubscriber(t *testing.T) {
+	hub := stream.NewHub(10, 1)
+	ctx := context.Background()
+
+	slow := hub.Subscribe(joeId.String(), 0)
+	require.NoError(t, hub.Publish(ctx, balanceEvent(t, 1, joeId)))
+	require.NoError(t, hub.Publish(ctx, balanceEvent(t, 2, joeId)), "publishing never blocks")
+
+	assert.Equal(t, int64(1), receive(t, slow).Sequence)
+	_, ok := <-slow.Events
+	assert.False(t, ok, "dropped once its buffer was full")
+	assert.Equal(t, 0, hub.Subscribers())
+
+	slow.Close()
+}
+
+func TestHub_DeliversRelayedEventOnce(t *testing.T) {
+	hub := stream.NewHub(10, 10)
+	ctx := context.Background()
+
+	sub := hub.Subscribe(joeId.String(), 0)
+	defer sub.Close()
+
+	event := balanceEvent(t, 1, joeId)
+	require.NoError(t, hub.Publish(ctx, event))
+	require.NoError(t, hub.Publish(ctx, event))
+
+	receive(t, sub)
+	assert.Empty(t, sub.Events)
+}
+
+func TestHub_CloseEndsSubscriptions(t *testing.T) {
+	hub := stream.NewHub(10, 10)
+	sub := hub.Subscribe(joeId.String(), 0)
+
+	require.NoError(t, hub.Close())
+
+	_, ok := <-sub.Events
+	assert.False(t, ok)
+	sub.Close()
+
+	late := hub.Subscribe(joeId.String(), 0)
+	_, ok = <-late.Events
+	assert.False(t, ok, "a closed hub takes no subscribers")
+}
@@ -0,0 +1,145 @@
+package tracing_tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"log/slog"
+	"moneyTransfer/api/handler"
+	"moneyTransfer/api/middleware"
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/domain/dtos"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/internal/queue"
+	"moneyTransfer/internal/repository"
+	"moneyTransfer/internal/repository/sqlite"
+	"moneyTransfer/internal/tracing"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	aliceId = "7141b92f-a8c8-471e-83e5-7fc72da61cb9"
+	joeId   = "861d7697-b717-43e8-95a2-1a74f9a36ab1"
+	// traceparent is the W3C header of a caller's trace.
+	traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func initTracing(t *testing.T) *tracetest.SpanRecorder {
+	_, err := tracing.Setup(context.Background(), config.TracingConfig{Exporter: config.TraceExporterNone})
+	require.NoError(t, err)
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { provider.Shutdown(context.Background()) })
+	return recorder
+}
+
+func TestTraceContext_RoundTripsThroughJob(t *testing.T) {
+	initTracing(t)
+
+	ctx, span := otel.Tracer("test").Start(context.Background(), "publish")
+	defer span.End()
+
+	data, err := json.Marshal(queue.TransferJob{TransactionId: uuid.New(), TraceContext: tracing.Inject(ctx)})
+	require.NoError(t, err)
+	var job queue.TransferJob
+	require.NoError(t, json.Unmarshal(data, &job))
+
+	extracted := trace.SpanContextFromContext(tracing.Extract(context.Background(), job.TraceContext))
+	assert.Equal(t, span.SpanContext().TraceID(), extracted.TraceID())
+	assert.Equal(t, span.SpanContext().SpanID(), extracted.SpanID())
+
+	assert.Nil(t, tracing.Inject(context.Background()), "nothing to carry without a span")
+}
+
+// TestTransferTrace follows one transfer from the HTTP request through the
+// service, across the queue, into the worker and its SQL statements.
+func TestTransferTrace(t *testing.T) {
+	recorder := initTracing(t)
+
+	db, err := sqlite.NewSQLiteClient(config.SQLiteConfig{Path: filepath.Join(t.TempDir(), "test.db"), BusyTimeout: 5 * time.Second})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	migrator, err := sqlite.NewMigrator(db, discardLog)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	userRepo := repository.NewUserRepository(db)
+	transferRepo := repository.NewTransferRepository(db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	jobs := queue.NewChannelQueue(1, time.Second)
+	completions := queue.NewCompletions()
+	go queue.NewWorker(jobs, userRepo, transferRepo, repository.NewTxManager(db), completions, 0, discardLog).Run(ctx)
+
+	transferService := service.NewTransferService(transferRepo, userRepo, jobs, completions, discardLog)
+	router := mux.NewRouter()
+	router.HandleFunc("/transfers", handler.NewTransferController(transferService, 5*time.Second, discardLog).CreateTransaction).Methods("POST")
+	router.Use(middleware.Tracing)
+
+	body, _ := json.Marshal(dtos.TransactionRequestDto{From: aliceId, To: joeId, Amount: 10})
+	req := httptest.NewRequest(http.MethodPost, "/transfers?wait=5s", bytes.NewReader(body))
+	req.Header.Set("traceparent", traceparent)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// The worker span ends after the response may have been written.
+	require.Eventually(t, func() bool { return spanNamed(recorder, "TransferJob process") != nil }, time.Second, 5*time.Millisecond)
+	spans := recorder.Ended()
+
+	server := spanNamed(recorder, "POST /transfers")
+	require.NotNil(t, server)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String(), "continues the caller's trace")
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind())
+
+	create := spanNamed(recorder, "TransferService.CreateTransfer")
+	publish := spanNamed(recorder, "TransferJob publish")
+	process := spanNamed(recorder, "TransferJob process")
+	require.NotNil(t, create)
+	require.NotNil(t, publish)
+	for _, span := range []sdktrace.ReadOnlySpan{create, publish, process} {
+		assert.Equal(t, server.SpanContext().TraceID(), span.SpanContext().TraceID(), "span %s", span.Name())
+	}
+	assert.Equal(t, server.SpanContext().SpanID(), create.Parent().SpanID())
+	assert.Equal(t, create.SpanContext().SpanID(), publish.Parent().SpanID())
+	assert.Equal(t, publish.SpanContext().SpanID(), process.Parent().SpanID(), "the worker continues the publisher's trace")
+	assert.Equal(t, trace.SpanKindConsumer, process.SpanKind())
+
+	var workerSQL int
+	for _, span := range spans {
+		if strings.HasPrefix(span.Name(), "sql.") && span.Parent().SpanID() == process.SpanContext().SpanID() &&
+			span.SpanContext().TraceID() == process.SpanContext().TraceID() {
+			workerSQL++
+		}
+	}
+	assert.Positive(t, workerSQL, "the worker's repository calls are traced")
+}
+
+func spanNamed(recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
@@ -0,0 +1,275 @@
+package webhook_tests
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"moneyTransfer/internal/config"
+	"moneyTransfer/internal/domain/model"
+	"moneyTransfer/internal/domain/service"
+	"moneyTransfer/internal/repository/memory"
+	"moneyTransfer/internal/webhook"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var discardLog = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestSignature_RoundTrip(t *testing.T) {
+	now := time.Now()
+	body := []byte(`{"type":"TransferSucceeded"}`)
+	signature := webhook.Sign("whsec_secret", now, body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	require.NoError(t, webhook.Verify("whsec_secret", signature, timestamp, body, time.Minute, now))
+	assert.ErrorIs(t, webhook.Verify("whsec_other", signature, timestamp, body, time.Minute, now), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify("whsec_secret", signature, timestamp, []byte(`{}`), time.Minute, now), webhook.ErrInvalidSignature)
+	assert.ErrorIs(t, webhook.Verify("whsec_secret", signature, timestamp, body, time.Minute, now.Add(time.Hour)), webhook.ErrStaleTimestamp)
+}
+
+func TestFanout_QueuesOneDeliveryPerSubscribedWebhook(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	deliveries := memory.NewWebhookDeliveryRepository(store)
+	subscribed := addWebhook(t, store, "https://example.com/a", model.EventTransferSucceeded)
+	addWebhook(t, store, "https://example.com/b", model.EventTransferFailed)
+
+	fanout := newFanout(store)
+	event := transferEvent(t, model.EventTransferSucceeded, uuid.New(), uuid.New())
+
+	// The relay may publish an event twice; the receiver is notified once.
+	require.NoError(t, fanout.Publish(ctx, event))
+	require.NoError(t, fanout.Publish(ctx, event))
+
+	queued, err := deliveries.ListByWebhook(ctx, subscribed.Id.String(), 10)
+	require.NoError(t, err)
+	require.Len(t, queued, 1)
+	assert.Equal(t, event.Id, queued[0].EventId)
+	assert.Equal(t, model.DeliveryPending, queued[0].Status)
+
+	claimed, err := deliveries.Claim(ctx, time.Now().UTC(), time.Now().UTC().Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Len(t, claimed, 1, "only the subscribed webhook gets a delivery")
+}
+
+func TestFanout_OnlyQueuesEventsTheOwnerMaySee(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	webhooks, deliveries := memory.NewWebhookRepository(store), memory.NewWebhookDeliveryRepository(store)
+	alice, bob := uuid.New(), uuid.New()
+
+	reader := addOwner(t, store, model.ScopeWebhooks, model.ScopeTransfersRead)
+	revoked := addOwner(t, store, model.ScopeWebhooks, model.ScopeTransfersRead)
+	require.NoError(t, memory.NewAPIKeyRepository(store).Revoke(ctx, revoked.Id.String(), time.Now().UTC()))
+	manager := addOwner(t, store, model.ScopeWebhooks)
+
+	hooks := map[string]model.Webhook{
+		"watches alice": {OwnerId: reader.Id, Accounts: []uuid.UUID{alice}},
+		"watches bob":   {OwnerId: reader.Id, Accounts: []uuid.UUID{bob}},
+		"revoked owner": {OwnerId: revoked.Id},
+		"no read scope": {OwnerId: manager.Id},
+		"deleted owner": {OwnerId: uuid.New()},
+		"no owner":      {},
+		"all accounts":  {OwnerId: reader.Id},
+	}
+	for name, hook := range hooks {
+		hook.Id, hook.URL, hook.Events, hook.Secret = uuid.New(), "https://example.com/"+name, []string{model.EventTransferSucceeded}, "whsec_"+name
+		require.NoError(t, webhooks.Create(ctx, hook))
+		hooks[name] = hook
+	}
+
+	require.NoError(t, newFanout(store).Publish(ctx, transferEvent(t, model.EventTransferSucceeded, uuid.New(), alice)))
+
+	for name, hook := range hooks {
+		queued, err := deliveries.ListByWebhook(ctx, hook.Id.String(), 10)
+		require.NoError(t, err)
+		want := 0
+		if name == "watches alice" || name == "all accounts" {
+			want = 1
+		}
+		assert.Len(t, queued, want, name)
+	}
+}
+
+func TestFanout_KeepsDeliveringAfterOwnerKeyRotation(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	deliveries := memory.NewWebhookDeliveryRepository(store)
+	hook := addWebhook(t, store, "https://example.com/rotated", model.EventTransferSucceeded)
+
+	keys := service.NewAPIKeyService(memory.NewAPIKeyRepository(store), memory.NewWebhookRepository(store), memory.NewTxManager(store), discardLog)
+	rotated, _, err := keys.Rotate(ctx, hook.OwnerId.String())
+	require.NoError(t, err)
+
+	require.NoError(t, newFanout(store).Publish(ctx, transferEvent(t, model.EventTransferSucceeded, uuid.New(), uuid.New())))
+
+	queued, err := deliveries.ListByWebhook(ctx, hook.Id.String(), 10)
+	require.NoError(t, err)
+	assert.Len(t, queued, 1, "the webhook follows its owner to the new key")
+
+	owned, err := memory.NewWebhookRepository(store).ListByOwner(ctx, rotated.Id.String())
+	require.NoError(t, err)
+	assert.Len(t, owned, 1, "the new key manages the webhook")
+}
+
+func TestDispatcher_SendsSignedRequest(t *testing.T) {
+	var received atomic.Int32
+	var secret string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		err := webhook.Verify(secret, r.Header.Get(webhook.HeaderSignature), r.Header.Get(webhook.HeaderTimestamp), body, time.Minute, time.Now())
+		if err != nil || r.Header.Get(webhook.HeaderEvent) != model.EventTransferSucceeded {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received.Add(1)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	store, dispatcher := newDispatcher(config.WebhookConfig{})
+	deliveries := memory.NewWebhookDeliveryRepository(store)
+	hook := addWebhook(t, store, server.URL, model.EventTransferSucceeded)
+	secret = hook.Secret
+	publish(t, store, model.EventTransferSucceeded)
+
+	sent, err := dispatcher.DispatchBatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, int32(1), received.Load())
+
+	log, err := deliveries.ListByWebhook(ctx, hook.Id.String(), 10)
+	require.NoError(t, err)
+	require.Len(t, log, 1)
+	assert.Equal(t, model.DeliverySucceeded, log[0].Status)
+	assert.Equal(t, http.StatusOK, log[0].ResponseStatus)
+	assert.NotNil(t, log[0].DeliveredAt)
+}
+
+func TestDispatcher_RefusesNonPublicAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a loopback receiver must not be reached")
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	store, dispatcher := newDispatcher(config.WebhookConfig{AllowedHosts: []string{}})
+	deliveries := memory.NewWebhookDeliveryRepository(store)
+	hook := addWebhook(t, store, server.URL, model.EventTransferFailed)
+	publish(t, store, model.EventTransferFailed)
+
+	_, err := dispatcher.DispatchBatch(ctx)
+	require.NoError(t, err)
+
+	log, err := deliveries.ListByWebhook(ctx, hook.Id.String(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryPending, log[0].Status)
+	assert.Equal(t, 1, log[0].Attempts)
+	assert.Contains(t, log[0].LastError, "url must point at a public address")
+}
+
+func TestDispatcher_RetriesWithBackoffThenFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	store, dispatcher := newDispatcher(config.WebhookConfig{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+	deliveries := memory.NewWebhookDeliveryRepository(store)
+	hook := addWebhook(t, store, server.URL, model.EventTransferFailed)
+	publish(t, store, model.EventTransferFailed)
+
+	_, err := dispatcher.DispatchBatch(ctx)
+	require.NoError(t, err)
+
+	log, err := deliveries.ListByWebhook(ctx, hook.Id.String(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryPending, log[0].Status)
+	assert.Equal(t, 1, log[0].Attempts)
+	assert.Equal(t, http.StatusInternalServerError, log[0].ResponseStatus)
+	assert.Equal(t, "unexpected response status 500", log[0].LastError)
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = dispatcher.DispatchBatch(ctx)
+	require.NoError(t, err)
+
+	log, err = deliveries.ListByWebhook(ctx, hook.Id.String(), 10)
+	require.NoError(t, err)
+	assert.Equal(t, model.DeliveryFailed, log[0].Status)
+	assert.Equal(t, 2, log[0].Attempts)
+
+	sent, err := dispatcher.DispatchBatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent, "failed deliveries wait for a manual redelivery")
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	_, dispatcher := newDispatcher(config.WebhookConfig{InitialBackoff: 10 * time.Second, MaxBackoff: time.Minute})
+
+	assert.Equal(t, 10*time.Second, dispatcher.Backoff(1))
+	assert.Equal(t, 20*time.Second, dispatcher.Backoff(2))
+	assert.Equal(t, 40*time.Second, dispatcher.Backoff(3))
+	assert.Equal(t, time.Minute, dispatcher.Backoff(4))
+	assert.Equal(t, time.Minute, dispatcher.Backoff(100))
+}
+
+// newDispatcher fills the settings cfg leaves unset with quick test values.
+func newDispatcher(cfg config.WebhookConfig) (*memory.Store, *webhook.Dispatcher) {
+	defaults := config.WebhookConfig{Timeout: time.Second, MaxAttempts: 3, InitialBackoff: time.Second, MaxBackoff: time.Second, BatchSize: 10, PollInterval: time.Second}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.MaxAttempts == 0 {
+		cfg.MaxAttempts = defaults.MaxAttempts
+	}
+	if cfg.InitialBackoff == 0 {
+		cfg.InitialBackoff, cfg.MaxBackoff = defaults.InitialBackoff, defaults.MaxBackoff
+	}
+	cfg.BatchSize, cfg.PollInterval = defaults.BatchSize, defaults.PollInterval
+	// The test servers listen on loopback, which is refused unless allowed.
+	if cfg.AllowedHosts == nil {
+		cfg.AllowedHosts = []string{"127.0.0.1"}
+	}
+
+	store := memory.NewStore()
+	return store, webhook.NewDispatcher(memory.NewWebhookRepository(store), memory.NewWebhookDeliveryRepository(store), cfg, discardLog)
+}
+
+func addWebhook(t *testing.T, store *memory.Store, url string, events ...string) model.Webhook {
+	t.Helper()
+	owner := addOwner(t, store, model.ScopeWebhooks, model.ScopeTransfersRead)
+	hook := model.Webhook{Id: uuid.New(), OwnerId: owner.Id, URL: url, Events: events, Secret: "whsec_" + url, CreatedAt: time.Now().UTC()}
+	require.NoError(t, memory.NewWebhookRepository(store).Create(context.Background(), hook))
+	return hook
+}
+
+func addOwner(t *testing.T, store *memory.Store, scopes ...string) model.APIKey {
+	t.Helper()
+	key := model.APIKey{Id: uuid.New(), Name: "owner", KeyHash: uuid.NewString(), Scopes: scopes, CreatedAt: time.Now().UTC()}
+	require.NoError(t, memory.NewAPIKeyRepository(store).Create(context.Background(), key))
+	return key
+}
+
+func newFanout(store *memory.Store) *webhook.Fanout {
+	return webhook.NewFanout(memory.NewWebhookRepository(store), memory.NewWebhookDeliveryRepository(store), memory.NewAPIKeyRepository(store))
+}
+
+func transferEvent(t *testing.T, eventType string, senderId, receiverId uuid.UUID) model.DomainEvent {
+	t.Helper()
+	event, err := model.NewTransferEvent(eventType, model.Transaction{Id: uuid.New(), SenderId: senderId, ReceiverId: receiverId, Amount: 10})
+	require.NoError(t, err)
+	return event
+}
+
+func publish(t *testing.T, store *memory.Store, eventType string) {
+	t.Helper()
+	require.NoError(t, newFanout(store).Publish(context.Background(), transferEvent(t, eventType, uuid.New(), uuid.New())))
+}